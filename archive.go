@@ -0,0 +1,455 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archiveDirName is the directory under the output directory holding the archive
+const archiveDirName = ".archive"
+
+// Archive mirrors the session files of the sources into <dir>/.archive, so sessions
+// outlive Claude Code deleting old transcripts. Files are stored gzip-compressed under
+// objects/, named by the SHA-256 of their content, so a file is stored once however
+// many sessions or versions hold it. manifest.json lists the versions of each file:
+// a file that only grew replaces its latest version, while one that was rewritten keeps
+// the versions before it. Sessions whose file is gone are loaded from their latest
+// version. A nil *Archive archives nothing.
+type Archive struct {
+	dir string
+
+	mu       sync.Mutex
+	manifest archiveManifest
+	dirty    bool
+}
+
+// archiveManifest is the layout of manifest.json. It is JSON rather than gob like the
+// parse cache: the archive is kept for good, not rebuilt when it can't be read.
+type archiveManifest struct {
+	// Versions of each file, oldest first, by its absolute path with forward slashes, so
+	// they stay found when sources are renamed or reordered
+	Files map[string][]ArchivedVersion `json:"files"`
+}
+
+// ArchivedVersion is one stored version of a session file
+type ArchivedVersion struct {
+	Hash       string    `json:"hash"` // SHA-256 of the content, naming its object
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"` // Of the session file when it was archived
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ArchiveResult counts what Sync stored
+type ArchiveResult struct {
+	Archived int // Files archived for the first time
+	Updated  int // Files that grew, replacing their latest version
+	Versions int // Files that were rewritten, adding a version
+	Deferred int // Files still growing, left for a later Sync
+	Errors   []error
+}
+
+// OpenArchive opens the archive in outputDir, which is created on the first Save. Unlike
+// the parse cache, a manifest that can't be read is an error.
+func OpenArchive(outputDir string) (*Archive, error) {
+	a := &Archive{
+		dir:      filepath.Join(outputDir, archiveDirName),
+		manifest: archiveManifest{Files: make(map[string][]ArchivedVersion)},
+	}
+
+	data, err := os.ReadFile(a.manifestPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return a, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading archive manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &a.manifest); err != nil {
+		return nil, fmt.Errorf("parsing archive manifest %s: %w", a.manifestPath(), err)
+	}
+	if a.manifest.Files == nil {
+		a.manifest.Files = make(map[string][]ArchivedVersion)
+	}
+	return a, nil
+}
+
+func (a *Archive) manifestPath() string {
+	return filepath.Join(a.dir, "manifest.json")
+}
+
+// objectPath returns where the content with the given hash is stored
+func (a *Archive) objectPath(hash string) string {
+	return filepath.Join(a.dir, "objects", hash[:2], hash+".jsonl.gz")
+}
+
+// archiveKey returns the manifest key of a file or directory: its absolute path, with
+// forward slashes
+func archiveKey(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// Sync archives the session files of every source that changed since they were last
+// archived, in the given project folders (all of them if folders is nil). A file that
+// grew and was written to less than quiet ago is left for a later Sync, so a session
+// being written isn't compressed again on every message; 0 archives it now.
+func (a *Archive) Sync(sources []Source, folders []string, quiet time.Duration) *ArchiveResult {
+	result := &ArchiveResult{}
+	if a == nil {
+		return result
+	}
+	for _, source := range sources {
+		found, err := DiscoverProjects(source.Path)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		for i := range found {
+			if folders != nil && !containsString(folders, found[i].FolderName) {
+				continue
+			}
+			files, err := listSessionFiles(source.Path, &found[i])
+			if err != nil {
+				result.Errors = append(result.Errors, err)
+				continue
+			}
+			for _, file := range files {
+				if err := a.archiveFile(file.Path, quiet, result); err != nil {
+					result.Errors = append(result.Errors, fmt.Errorf("archiving %s: %w", file.Path, err))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// archiveFile stores a session file if it changed since its latest version, unless it
+// grew and is still being written to
+func (a *Archive) archiveFile(filePath string, quiet time.Duration, result *ArchiveResult) error {
+	key := archiveKey(filePath)
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	versions := a.manifest.Files[key]
+	a.mu.Unlock()
+
+	prefixLen := int64(-1)
+	var latest ArchivedVersion
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1]
+		if latest.Size == info.Size() && latest.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		if info.Size() > latest.Size && time.Since(info.ModTime()) < quiet {
+			result.Deferred++
+			return nil
+		}
+		prefixLen = latest.Size
+	}
+
+	hash, prefix, size, err := a.store(filePath, prefixLen)
+	if err != nil {
+		return err
+	}
+	version := ArchivedVersion{Hash: hash, Size: size, ModTime: info.ModTime(), ArchivedAt: time.Now().UTC()}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.dirty = true
+	versions = a.manifest.Files[key]
+	grew := false
+	switch {
+	case len(versions) == 0:
+		versions = append(versions, version)
+		result.Archived++
+	case hash == latest.Hash:
+		// Touched without changing
+		versions[len(versions)-1].ModTime = info.ModTime()
+	case prefix == latest.Hash:
+		// Lines were appended: the new version holds the latest one
+		versions[len(versions)-1] = version
+		grew = true
+		result.Updated++
+	default:
+		versions = append(versions, version)
+		result.Versions++
+	}
+	a.manifest.Files[key] = versions
+
+	if grew {
+		a.dropUnreferenced(latest.Hash)
+	}
+	return nil
+}
+
+// store compresses a file into the object named by its hash. It returns the hash, the
+// hash of the file's first prefixLen bytes ("" if it is shorter or prefixLen is
+// negative) and the number of bytes stored.
+func (a *Archive) store(filePath string, prefixLen int64) (hash, prefix string, size int64, err error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer src.Close()
+
+	objectsDir := filepath.Join(a.dir, "objects")
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return "", "", 0, fmt.Errorf("creating archive directory: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(objectsDir, "tmp-*.gz")
+	if err != nil {
+		return "", "", 0, fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	// Hash what is compressed, so the object matches its name even if the file grows
+	// while it is copied
+	h := sha256.New()
+	zw := gzip.NewWriter(tmpFile)
+	w := io.MultiWriter(h, zw)
+	if prefixLen >= 0 {
+		n, err := io.CopyN(w, src, prefixLen)
+		if err != nil && err != io.EOF {
+			tmpFile.Close()
+			return "", "", 0, fmt.Errorf("copying file: %w", err)
+		}
+		size = n
+		if n == prefixLen {
+			prefix = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	n, err := io.Copy(w, src)
+	if err != nil {
+		tmpFile.Close()
+		return "", "", 0, fmt.Errorf("copying file: %w", err)
+	}
+	size += n
+	if err := zw.Close(); err != nil {
+		tmpFile.Close()
+		return "", "", 0, fmt.Errorf("compressing file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", "", 0, fmt.Errorf("closing temp file: %w", err)
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+
+	objectPath := a.objectPath(hash)
+	if _, err := os.Stat(objectPath); err == nil {
+		return hash, prefix, size, nil // Stored already
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+		return "", "", 0, fmt.Errorf("creating archive directory: %w", err)
+	}
+	if err := os.Rename(tmpPath, objectPath); err != nil {
+		return "", "", 0, fmt.Errorf("renaming temp file: %w", err)
+	}
+	return hash, prefix, size, nil
+}
+
+// dropUnreferenced removes the object with the given hash if no version holds it any
+// more. The caller holds a.mu.
+func (a *Archive) dropUnreferenced(hash string) {
+	for _, versions := range a.manifest.Files {
+		for _, v := range versions {
+			if v.Hash == hash {
+				return
+			}
+		}
+	}
+	os.Remove(a.objectPath(hash))
+}
+
+// Save writes the manifest, if it changed
+func (a *Archive) Save() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&a.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive manifest: %w", err)
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return fmt.Errorf("creating archive directory: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(a.dir, "tmp-*.json")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("writing archive manifest: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, a.manifestPath()); err != nil {
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	a.dirty = false
+	return nil
+}
+
+// missingFiles returns the archived files of a project that aren't among the files found
+// on disk, to be loaded from their latest version
+func (a *Archive) missingFiles(project *Project, present []sessionFile) []sessionFile {
+	if a == nil {
+		return nil
+	}
+	projectDir := filepath.Join(project.SourceDir, project.FolderName)
+	onDisk := make(map[string]bool, len(present))
+	for _, f := range present {
+		if rel, err := filepath.Rel(projectDir, f.Path); err == nil {
+			onDisk[filepath.ToSlash(rel)] = true
+		}
+	}
+
+	prefix := archiveKey(projectDir) + "/"
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var rels []string
+	for key, versions := range a.manifest.Files {
+		rel, ok := strings.CutPrefix(key, prefix)
+		if ok && !onDisk[rel] && len(versions) > 0 {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	files := make([]sessionFile, 0, len(rels))
+	for _, rel := range rels {
+		versions := a.manifest.Files[prefix+rel]
+		file := sessionFile{
+			Path:     a.objectPath(versions[len(versions)-1].Hash),
+			ID:       strings.TrimSuffix(path.Base(rel), ".jsonl"),
+			Archived: true,
+		}
+		// Subagent transcripts of newer Claude Code versions: <session>/subagents/<agent>.jsonl
+		if parts := strings.Split(rel, "/"); len(parts) == 3 && parts[1] == "subagents" {
+			file.ParentID = parts[0]
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// addProjects adds the project folders archived from source that aren't among projects,
+// such as folders removed together with their last session
+func (a *Archive) addProjects(projects []Project, source Source) []Project {
+	if a == nil {
+		return projects
+	}
+	have := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		have[p.FolderName] = true
+	}
+
+	prefix := archiveKey(source.Path) + "/"
+	a.mu.Lock()
+	var folders []string
+	for key := range a.manifest.Files {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		folder, _, _ := strings.Cut(rest, "/")
+		if !have[folder] {
+			have[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+	a.mu.Unlock()
+	sort.Strings(folders)
+
+	for _, folder := range folders {
+		projects = append(projects, Project{
+			FolderName: folder,
+			Path:       resolveProjectPath(folder, nil),
+			Source:     source.Name,
+			SourceDir:  source.Path,
+		})
+	}
+	return projects
+}
+
+// hasProject reports whether files of the project folder were archived from source
+func (a *Archive) hasProject(source Source, folder string) bool {
+	if a == nil {
+		return false
+	}
+	prefix := archiveKey(filepath.Join(source.Path, folder)) + "/"
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key := range a.manifest.Files {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openSessionFile opens a session file, or an archived one, for reading from offset.
+// Archived files are decompressed as they are read.
+func openSessionFile(filePath string, offset int64) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(filePath, ".gz") {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+		return file, nil
+	}
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, zr, offset); err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: file}, nil
+}
+
+// gzipFile is a decompressed archived file, closing the file under it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	sourceDir := t.TempDir()
+	outputDir := t.TempDir()
+	sources := []Source{{Name: "claude", Path: sourceDir}}
+
+	first := `{"type":"user","uuid":"u1","cwd":"/work/app","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Hello"}}
+`
+	more := `{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Hi there"}]}}
+`
+	write := func(folder, name, content string) string {
+		t.Helper()
+		dir := filepath.Join(sourceDir, folder)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return path
+	}
+	syncQuiet := func(quiet time.Duration) (*Archive, *ArchiveResult) {
+		t.Helper()
+		archive, err := OpenArchive(outputDir)
+		if err != nil {
+			t.Fatalf("OpenArchive failed: %v", err)
+		}
+		result := archive.Sync(sources, nil, quiet)
+		if len(result.Errors) > 0 {
+			t.Fatalf("Sync errors: %v", result.Errors)
+		}
+		if err := archive.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		return archive, result
+	}
+	sync := func() (*Archive, *ArchiveResult) {
+		t.Helper()
+		return syncQuiet(0)
+	}
+	objects := func() int {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(outputDir, archiveDirName, "objects", "*", "*.jsonl.gz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(matches)
+	}
+
+	// The same content in two projects is stored once
+	session := write("-work-app", "s1.jsonl", first)
+	write("-work-copy", "s1.jsonl", first)
+	if _, result := sync(); result.Archived != 2 || objects() != 1 {
+		t.Fatalf("First sync archived %d files into %d objects, want 2 into 1", result.Archived, objects())
+	}
+	if _, result := sync(); result.Archived+result.Updated+result.Versions != 0 {
+		t.Errorf("Unchanged files were archived again: %+v", result)
+	}
+
+	// A file still being appended to waits until it has gone quiet
+	write("-work-app", "s1.jsonl", first+more)
+	archive, result := syncQuiet(time.Hour)
+	if result.Deferred != 1 || result.Updated != 0 {
+		t.Errorf("Appending while quiet: %+v, want 1 deferred", result)
+	}
+	if versions := archive.manifest.Files[archiveKey(session)]; len(versions) != 1 || versions[0].Size != int64(len(first)) {
+		t.Errorf("Versions while growing = %+v, want the first one only", versions)
+	}
+
+	// Appended lines replace the latest version, keeping the object the copy still uses
+	archive, result = sync()
+	if result.Updated != 1 || result.Versions != 0 {
+		t.Errorf("Appending: %+v, want 1 updated", result)
+	}
+	if versions := archive.manifest.Files[archiveKey(session)]; len(versions) != 1 || versions[0].Size != int64(len(first+more)) {
+		t.Errorf("Versions after appending = %+v, want the grown file only", versions)
+	}
+	if objects() != 2 {
+		t.Errorf("Objects after appending = %d, want 2", objects())
+	}
+
+	// A rewritten file keeps its earlier version
+	write("-work-app", "s1.jsonl", more)
+	archive, result = sync()
+	if result.Versions != 1 {
+		t.Errorf("Rewriting: %+v, want 1 new version", result)
+	}
+	if versions := archive.manifest.Files[archiveKey(session)]; len(versions) != 2 {
+		t.Errorf("Versions after rewriting = %d, want 2", len(versions))
+	}
+
+	// Files are archived by path, so renaming the source keeps them
+	write("-work-app", "s1.jsonl", first+more)
+	sources[0].Name = "renamed"
+	archive, result = sync()
+	if result.Archived != 0 || result.Versions != 1 {
+		t.Errorf("After renaming the source: %+v, want 1 new version of the known file", result)
+	}
+
+	// Once the files are gone, their sessions load from the latest archived versions
+	live, err := LoadAllProjectMetasFrom(sources, LoadOptions{Archive: archive})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetasFrom failed: %v", err)
+	}
+	if _, err := GenerateAllMarkdown(live, outputDir, sources, false, nil, nil, OrphanKeep); err != nil {
+		t.Fatalf("GenerateAllMarkdown failed: %v", err)
+	}
+	if err := os.Remove(session); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(sourceDir, "-work-copy")); err != nil {
+		t.Fatal(err)
+	}
+	projects, err := LoadAllProjectMetasFrom(sources, LoadOptions{Archive: archive})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetasFrom failed: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("Loaded %d projects, want both from the archive", len(projects))
+	}
+	for _, p := range projects {
+		if len(p.Sessions) != 1 || p.Sessions[0].ID != "s1" || p.Path != "/work/app" || !p.Sessions[0].Archived {
+			t.Fatalf("Project %s = %+v, want s1 from the archive", p.FolderName, p)
+		}
+	}
+
+	// Their Markdown is regenerated to be marked source_missing, and stays marked
+	for run := 0; run < 2; run++ {
+		if _, err := GenerateAllMarkdown(projects, outputDir, sources, false, nil, nil, OrphanKeep); err != nil {
+			t.Fatalf("GenerateAllMarkdown failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, projects[0].Slug(), "s1.md"))
+		if err != nil {
+			t.Fatalf("Failed to read Markdown: %v", err)
+		}
+		if fm, _, err := ParseFrontmatter(content); err != nil || !fm.SourceMissing || fm.Source != "s1.jsonl" {
+			t.Errorf("Run %d: frontmatter = %+v (%v), want source_missing for s1.jsonl", run, fm, err)
+		}
+	}
+
+	var restored *Session
+	for i := range projects {
+		if projects[i].FolderName == "-work-app" {
+			restored = &projects[i].Sessions[0]
+		}
+	}
+	full, err := LoadSessionMessages(restored)
+	if err != nil {
+		t.Fatalf("LoadSessionMessages failed: %v", err)
+	}
+	if len(full.Messages) != 2 || full.Messages[1].Content[0].Text != "Hi there" {
+		t.Errorf("Restored messages = %+v, want both", full.Messages)
+	}
+	if fm := NewFrontmatter(full, ""); fm.Source != "s1.jsonl" || !fm.SourceMissing {
+		t.Errorf("Frontmatter = %+v, want the original file name marked source_missing", fm)
+	}
+}
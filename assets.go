@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// assetsDirName is the folder under each project output directory holding
+// images and documents extracted from sessions
+const assetsDirName = "assets"
+
+// mediaExtensions maps the media types written as assets to their file extensions.
+// Assets are served from the same origin as the pages, so anything a browser would
+// run as a page, such as SVG or HTML, must not get its own extension.
+var mediaExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// mediaExtension returns the file extension for a media type, or ".bin" for types
+// not in mediaExtensions
+func mediaExtension(mediaType string) string {
+	if ext, ok := mediaExtensions[mediaType]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+// assetFileName returns the content-addressed file name of an image or document block,
+// so identical attachments across sessions share one file
+func assetFileName(block ContentBlock) string {
+	sum := sha256.Sum256(block.Data)
+	return hex.EncodeToString(sum[:16]) + mediaExtension(block.MediaType)
+}
+
+// assetLink returns the Markdown link target of an asset, relative to the session file
+func assetLink(block ContentBlock) string {
+	return assetsDirName + "/" + assetFileName(block)
+}
+
+// hasAssetData reports whether a block carries inline media to be written as an asset
+func hasAssetData(block ContentBlock) bool {
+	return (block.Type == "image" || block.Type == "document") && len(block.Data) > 0
+}
+
+// writeAssets writes the images and documents of a session and its subagents, including
+// those returned by tools, to the project assets folder. Existing files are left untouched
+// since their name is derived from their content.
+func (g *MarkdownGenerator) writeAssets(session *Session, projectSlug string) error {
+	assetsDir := filepath.Join(g.outputDir, projectSlug, assetsDirName)
+
+	messages := append([]Message{}, session.Messages...)
+	for _, sub := range session.Subagents {
+		messages = append(messages, sub.Messages...)
+	}
+	for _, msg := range messages {
+		var blocks []ContentBlock
+		for _, block := range msg.Content {
+			blocks = append(blocks, block)
+			blocks = append(blocks, block.Parts...)
+		}
+		for _, block := range blocks {
+			if !hasAssetData(block) {
+				continue
+			}
+
+			if err := os.MkdirAll(assetsDir, 0755); err != nil {
+				return fmt.Errorf("creating assets dir: %w", err)
+			}
+
+			assetPath := filepath.Join(assetsDir, assetFileName(block))
+			if _, err := os.Stat(assetPath); err == nil {
+				continue
+			}
+			if err := g.writeFile(assetPath, block.Data); err != nil {
+				return fmt.Errorf("writing asset %s: %w", filepath.Base(assetPath), err)
+			}
+		}
+	}
+
+	return nil
+}
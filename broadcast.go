@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Change event types sent on /api/events
+const (
+	EventSessionCreated = "session-created"
+	EventSessionUpdated = "session-updated"
+	EventSessionDeleted = "session-deleted"
+	EventStatsChanged   = "stats-changed"
+)
+
+// ChangeEvent tells open pages that the served projects changed
+type ChangeEvent struct {
+	Type         string `json:"type"`
+	Project      string `json:"project,omitempty"` // Project slug
+	SessionID    string `json:"sessionId,omitempty"`
+	Summary      string `json:"summary,omitempty"`
+	MessageCount int    `json:"messageCount,omitempty"`
+}
+
+// eventBufferSize bounds the events queued for a slow client before newer ones are
+// dropped; pages refetch what they show on any event, so a dropped one costs little
+const eventBufferSize = 64
+
+// eventHeartbeat is how often an idle event stream is written to, so proxies and
+// browsers don't time it out
+const eventHeartbeat = 30 * time.Second
+
+// broadcaster sends change events to every connected /api/events client
+type broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan ChangeEvent]struct{}
+	closed  bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{clients: make(map[chan ChangeEvent]struct{})}
+}
+
+// subscribe registers a client. Its channel is closed when the broadcaster is.
+func (b *broadcaster) subscribe() chan ChangeEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan ChangeEvent, eventBufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.clients[ch] = struct{}{}
+	return ch
+}
+
+func (b *broadcaster) unsubscribe(ch chan ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.clients[ch]; ok {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// publish queues events for every client, dropping them for clients that are behind
+func (b *broadcaster) publish(events ...ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		for _, e := range events {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+// close ends every event stream, so server shutdown isn't held up by them
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.clients {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// changeEvents describes how the sessions with the given keys ("projectSlug|sessionID")
+// changed between two loads, followed by a stats event if any did
+func changeEvents(prev, next []Project, changed []string) []ChangeEvent {
+	if len(changed) == 0 {
+		return nil
+	}
+	before := sessionsByKey(prev)
+	after := sessionsByKey(next)
+
+	events := make([]ChangeEvent, 0, len(changed)+1)
+	for _, key := range changed {
+		projectSlug, sessionID, _ := strings.Cut(key, "|")
+		e := ChangeEvent{Project: projectSlug, SessionID: sessionID}
+		session := after[key]
+		switch {
+		case session == nil:
+			e.Type = EventSessionDeleted
+		case before[key] == nil:
+			e.Type = EventSessionCreated
+		default:
+			e.Type = EventSessionUpdated
+		}
+		if session != nil {
+			e.Summary = session.Summary
+			e.MessageCount = session.MessageCount()
+		}
+		events = append(events, e)
+	}
+	return append(events, ChangeEvent{Type: EventStatsChanged})
+}
+
+// sessionsByKey maps the sessions of projects by "projectSlug|sessionID"
+func sessionsByKey(projects []Project) map[string]*Session {
+	sessions := make(map[string]*Session)
+	for i := range projects {
+		for j := range projects[i].Sessions {
+			sessions[projects[i].Slug()+"|"+projects[i].Sessions[j].ID] = &projects[i].Sessions[j]
+		}
+	}
+	return sessions
+}
+
+// handleEvents streams change events to the browser as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Subscribe before the stream is acknowledged, so no change after that is missed
+	events := s.events.subscribe()
+	defer s.events.unsubscribe(events)
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
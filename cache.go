@@ -0,0 +1,640 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// parseCacheFormat is bumped whenever what the cache holds, or how it is parsed, changes
+const parseCacheFormat = 4
+
+// ParseCache keeps what was parsed from each session file between runs: the session
+// metadata and the search entries and stats inputs of its messages. Entries are keyed
+// by file path and reused while the file keeps its size and mtime, or its content hash
+// when only the mtime changed, so a restart only reparses the files that changed.
+// Files that only grew are read from where the last parse stopped. The search entries
+// and stats inputs of each file are kept in a file of their own and read only when its
+// session is indexed or the file grows, so they aren't all held in memory at once.
+type ParseCache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*cacheFileEntry
+	dirty   bool
+
+	// Parses with messages of recently rendered files, kept in memory only
+	live     map[string]*liveParse
+	liveTick int
+
+	metaHits, metaResumes, metaMisses int
+	digestHits, digestMisses          int
+}
+
+// liveParses is the number of parses with messages kept, enough for the sessions being
+// written to at once
+const liveParses = 8
+
+// liveParse is a parse with messages, so the Markdown of a session that grows is
+// regenerated from its new lines only
+type liveParse struct {
+	parse *sessionParse
+	used  int // liveTick when last used
+}
+
+// cacheFileEntry is what the cache holds for one session file
+type cacheFileEntry struct {
+	Size    int64
+	ModTime time.Time
+	Hash    string        // SHA-256 of the content, as in the source_hash frontmatter
+	Session *Session      // As returned by LoadSessionMeta, before sessions are linked
+	Parse   *sessionParse // Resumed when lines are appended to the file, without its digest
+	Digest  string        // File under .cache/digests holding the digest of the parse
+
+	digest *digestBuilder // The digest of the parse, for a cache kept in memory only
+	seen   bool           // Validated against the file in this run; other entries are dropped on Save
+}
+
+// parseCacheFile is the on-disk layout of the cache
+type parseCacheFile struct {
+	Format  int
+	Build   string // Version that wrote the cache; parsing may differ between versions
+	Entries map[string]*cacheFileEntry
+}
+
+// sessionDigest is what the search index and the stats need from a session file
+type sessionDigest struct {
+	Search []searchEntry         // Entries of every message in the file
+	Parts  map[string]*statsPart // By session ID: the file's session and its sidechains
+}
+
+// OpenParseCache loads the cache kept in dir. A missing, unreadable or outdated cache
+// file gives an empty cache, so every session is parsed again.
+func OpenParseCache(dir string) *ParseCache {
+	c := &ParseCache{
+		path:    filepath.Join(dir, ".cache", "sessions.gob"),
+		entries: make(map[string]*cacheFileEntry),
+		live:    make(map[string]*liveParse),
+	}
+
+	f, err := os.Open(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logVerbose("Parse cache unreadable, starting empty: %v", err)
+		}
+		return c
+	}
+	defer f.Close()
+
+	var file parseCacheFile
+	if err := gob.NewDecoder(f).Decode(&file); err != nil {
+		logVerbose("Parse cache unreadable, starting empty: %v", err)
+		return c
+	}
+	if file.Format != parseCacheFormat || file.Build != cacheBuild() || file.Entries == nil {
+		logVerbose("Parse cache written by another version, starting empty")
+		return c
+	}
+	c.entries = file.Entries
+	return c
+}
+
+// newMemoryParseCache returns an empty cache that is kept in memory only and can't be
+// saved, so that callers without a cache still don't reparse files that haven't changed
+func newMemoryParseCache() *ParseCache {
+	return &ParseCache{
+		entries: make(map[string]*cacheFileEntry),
+		live:    make(map[string]*liveParse),
+	}
+}
+
+// cacheBuild identifies the build writing the cache
+func cacheBuild() string {
+	return version + "/" + commit
+}
+
+// Clear drops every entry, so all sessions are parsed again
+func (c *ParseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	clear(c.live)
+	c.dirty = true
+}
+
+// LoadSessionMeta is LoadSessionMeta, answered from the cache when the file is unchanged
+// and reading only the new lines when lines were appended to it
+func (c *ParseCache) LoadSessionMeta(filePath, sessionID string) (*Session, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening session file: %w", err)
+	}
+
+	c.mu.Lock()
+	entry := c.entries[filePath]
+	if entry != nil && entry.Session.ID != sessionID {
+		entry = nil
+	}
+	if entry != nil && entry.Size == info.Size() && entry.ModTime.Equal(info.ModTime()) {
+		entry.seen = true
+		c.metaHits++
+		c.mu.Unlock()
+		return cloneSession(entry.Session), nil
+	}
+	c.mu.Unlock()
+
+	offset := int64(-1)
+	if entry != nil && entry.Parse != nil {
+		offset = entry.Parse.Scan.Offset
+	}
+	hash, prefix, err := hashFile(filePath, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if entry != nil && entry == c.entries[filePath] && entry.Hash == hash {
+		// Touched without changing
+		entry.Size, entry.ModTime = info.Size(), info.ModTime()
+		entry.seen = true
+		c.metaHits++
+		c.dirty = true
+		c.mu.Unlock()
+		return cloneSession(entry.Session), nil
+	}
+	c.mu.Unlock()
+
+	// Lines were appended if the part read last time is unchanged; otherwise the file
+	// was truncated or rewritten and is read again
+	parse := newSessionParse(filePath, sessionID, false, true)
+	resumed := false
+	if entry != nil && entry.Parse != nil && prefix == entry.Parse.Scan.Prefix {
+		digest, err := c.loadDigest(entry)
+		if err != nil {
+			logVerbose("Parse cache: %v", err)
+		}
+		if digest != nil {
+			parse = entry.Parse.clone()
+			parse.Digest = digest
+			resumed = true
+		}
+	}
+	if err := parse.read(); err != nil {
+		return nil, err
+	}
+
+	// The digest is kept apart from the parse
+	digest := parse.Digest
+	parse.Digest = nil
+	session, _ := parse.session()
+	updated := &cacheFileEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hash,
+		Session: session,
+		Parse:   parse,
+		seen:    true,
+	}
+	if err := c.keepDigest(updated, digest); err != nil {
+		logVerbose("Parse cache: %v", err)
+	}
+
+	c.mu.Lock()
+	c.entries[filePath] = updated
+	if resumed {
+		c.metaResumes++
+	} else {
+		c.metaMisses++
+	}
+	c.dirty = true
+	c.mu.Unlock()
+
+	return cloneSession(session), nil
+}
+
+// LoadSessionMessages is LoadSessionMessages, reading only the lines appended since
+// when one of the session files was loaded recently
+func (c *ParseCache) LoadSessionMessages(session *Session) (*Session, error) {
+	if c == nil {
+		return LoadSessionMessages(session)
+	}
+	return loadSessionMessages(session, c.parseSession)
+}
+
+// parseSession is ParseSession, resuming the parse of the file kept in memory if the
+// file only grew since
+func (c *ParseCache) parseSession(filePath, sessionID string) (*Session, error) {
+	c.mu.Lock()
+	live := c.live[filePath]
+	c.mu.Unlock()
+
+	var parse *sessionParse
+	if live != nil && live.parse.SessionID == sessionID {
+		if _, prefix, err := hashFile(filePath, live.parse.Scan.Offset); err == nil && prefix == live.parse.Scan.Prefix {
+			parse = live.parse.clone()
+		}
+	}
+	if parse == nil {
+		parse = newSessionParse(filePath, sessionID, true, false)
+	}
+	if err := parse.read(); err != nil {
+		return nil, err
+	}
+	session, _ := parse.session()
+
+	c.mu.Lock()
+	c.liveTick++
+	c.live[filePath] = &liveParse{parse: parse, used: c.liveTick}
+	if len(c.live) > liveParses {
+		oldest := ""
+		for path, live := range c.live {
+			if oldest == "" || live.used < c.live[oldest].used {
+				oldest = path
+			}
+		}
+		delete(c.live, oldest)
+	}
+	c.mu.Unlock()
+
+	return session, nil
+}
+
+// hashFile returns the SHA-256 of a file, as ComputeFileHash does, and of its first
+// offset bytes; the latter is "" if the file is shorter or offset is negative. Archived
+// files are hashed as they were before compression.
+func hashFile(filePath string, offset int64) (string, string, error) {
+	f, err := openSessionFile(filePath, 0)
+	if err != nil {
+		return "", "", fmt.Errorf("opening file for hash: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var prefix string
+	if offset >= 0 {
+		n, err := io.CopyN(h, f, offset)
+		if err != nil && err != io.EOF {
+			return "", "", fmt.Errorf("computing hash: %w", err)
+		}
+		if n == offset {
+			prefix = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", "", fmt.Errorf("computing hash: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), prefix, nil
+}
+
+// cloneSession copies a cached session deeply enough that linking it into a project
+// doesn't change the cache
+func cloneSession(session *Session) *Session {
+	clone := *session
+	clone.Subagents = append([]Session(nil), session.Subagents...)
+	return &clone
+}
+
+// digest returns the search entries and stats inputs of a session and its subagents,
+// from the cache when all their files are cached, otherwise by loading their messages.
+// A nil cache always loads them.
+func (c *ParseCache) digest(session *Session) (*sessionDigest, error) {
+	files := []string{session.SourcePath}
+	for _, sub := range session.Subagents {
+		if !containsString(files, sub.SourcePath) {
+			files = append(files, sub.SourcePath)
+		}
+	}
+
+	var digests map[string]*sessionDigest
+	if c != nil {
+		c.mu.Lock()
+		entries := make(map[string]*cacheFileEntry, len(files))
+		for _, path := range files {
+			if entry := c.entries[path]; entry != nil && entry.seen && entry.Parse != nil {
+				entries[path] = entry
+			}
+		}
+		c.mu.Unlock()
+
+		// Cached entries are replaced rather than changed, so their digests are read
+		// and finished outside the lock
+		if len(entries) == len(files) {
+			digests = make(map[string]*sessionDigest, len(entries))
+			for path, entry := range entries {
+				digest, err := c.loadDigest(entry)
+				if err != nil {
+					logVerbose("Parse cache: %v", err)
+				}
+				if digest == nil {
+					digests = nil
+					break
+				}
+				parse := entry.Parse.clone()
+				parse.Digest = digest
+				_, digests[path] = parse.finish()
+			}
+		}
+
+		c.mu.Lock()
+		if digests != nil {
+			c.digestHits++
+		} else {
+			c.digestMisses++
+		}
+		c.mu.Unlock()
+	}
+
+	if digests == nil {
+		full, err := c.LoadSessionMessages(session)
+		if err != nil {
+			return nil, err
+		}
+		digests = digestFiles(full)
+	}
+
+	merged := &sessionDigest{Parts: make(map[string]*statsPart)}
+	for _, path := range files {
+		if digest := digests[path]; digest != nil {
+			merged.Search = append(merged.Search, digest.Search...)
+			for id, part := range digest.Parts {
+				merged.Parts[id] = part
+			}
+		}
+	}
+	return merged, nil
+}
+
+// digestsDir returns the folder holding the digests of cached files, or "" for a cache
+// kept in memory only
+func (c *ParseCache) digestsDir() string {
+	if c.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(c.path), "digests")
+}
+
+// keepDigest stores the digest of an entry's parse: in a file named after the session
+// file and its content, or with the entry for a cache kept in memory only
+func (c *ParseCache) keepDigest(entry *cacheFileEntry, digest *digestBuilder) error {
+	dir := c.digestsDir()
+	if dir == "" || digest == nil {
+		entry.digest = digest
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(entry.Parse.Path + "\x00" + entry.Hash))
+	name := hex.EncodeToString(sum[:16]) + ".gob"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating digests directory: %w", err)
+	}
+	if err := writeGobFile(filepath.Join(dir, name), digest); err != nil {
+		return fmt.Errorf("writing digest: %w", err)
+	}
+	entry.Digest = name
+	return nil
+}
+
+// loadDigest returns a copy of the digest of an entry's parse that the caller may add
+// to, or nil if the entry has none
+func (c *ParseCache) loadDigest(entry *cacheFileEntry) (*digestBuilder, error) {
+	if entry.Digest == "" {
+		return entry.digest.clone(), nil
+	}
+
+	f, err := os.Open(filepath.Join(c.digestsDir(), entry.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("opening digest: %w", err)
+	}
+	defer f.Close()
+
+	var digest digestBuilder
+	if err := gob.NewDecoder(f).Decode(&digest); err != nil {
+		return nil, fmt.Errorf("decoding digest %s: %w", entry.Digest, err)
+	}
+	return &digest, nil
+}
+
+// digestFiles computes the digests of the files a session with its messages was read
+// from, by file path
+func digestFiles(full *Session) map[string]*sessionDigest {
+	builders := make(map[string]*digestBuilder)
+	for _, transcript := range append([]Session{*full}, full.Subagents...) {
+		builder := builders[transcript.SourcePath]
+		if builder == nil {
+			builder = &digestBuilder{}
+			builders[transcript.SourcePath] = builder
+		}
+		for i := range transcript.Messages {
+			builder.add(&transcript.Messages[i], transcript.ID)
+		}
+
+		// Take the transcript's own calls, which sessions built in memory have without messages
+		part := builder.part(transcript.ID)
+		part.ToolCalls = part.ToolCalls[:0]
+		for _, call := range transcript.ToolCalls {
+			call.Input, call.Output, call.Parts = "", "", nil
+			part.ToolCalls = append(part.ToolCalls, call)
+		}
+	}
+
+	digests := make(map[string]*sessionDigest, len(builders))
+	for path, builder := range builders {
+		digests[path] = &builder.Digest
+	}
+	return digests
+}
+
+// digestBuilder accumulates the digest of a session file as its messages are read
+type digestBuilder struct {
+	Digest sessionDigest
+	Calls  map[string]callRef // tool_use ID -> its call, to pair it with its result
+}
+
+// callRef locates a tool call in a digest
+type callRef struct {
+	Part  string
+	Index int
+}
+
+// add records a message of the transcript partID. A nil builder records nothing.
+func (d *digestBuilder) add(msg *Message, partID string) {
+	if d == nil {
+		return
+	}
+	if d.Calls == nil {
+		d.Calls = make(map[string]callRef)
+	}
+	part := d.part(partID)
+
+	part.Messages++
+	switch {
+	case msg.Event == nil:
+		part.Measured = part.Measured || msg.Usage != nil
+		part.Usage = append(part.Usage, usageRecord{
+			Timestamp: msg.Timestamp,
+			Model:     msg.Model,
+			Usage:     msg.Usage,
+			Estimate:  estimatedUsage(*msg),
+		})
+	case msg.Event.Kind == EventCompact:
+		part.Usage = append(part.Usage, usageRecord{Timestamp: msg.Timestamp, Compaction: true})
+	}
+
+	// Calls are paired with their results as joinToolCalls does, without input and output
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "tool_use":
+			if _, ok := d.Calls[block.ToolUseID]; ok || block.ToolUseID == "" {
+				continue
+			}
+			d.Calls[block.ToolUseID] = callRef{Part: partID, Index: len(part.ToolCalls)}
+			part.ToolCalls = append(part.ToolCalls, ToolCall{
+				ID:        block.ToolUseID,
+				Name:      block.ToolName,
+				UseUUID:   msg.UUID,
+				StartedAt: msg.Timestamp,
+			})
+		case "tool_result":
+			call := d.call(block.ToolUseID)
+			if call == nil || call.HasResult {
+				continue
+			}
+			call.HasResult = true
+			call.IsError = block.IsError
+			call.AgentID = block.AgentID
+			call.ResultUUID = msg.UUID
+			call.EndedAt = msg.Timestamp
+		}
+	}
+
+	d.Digest.Search = append(d.Digest.Search, searchEntries(msg, func(toolUseID string) string {
+		if call := d.call(toolUseID); call != nil {
+			return call.Name
+		}
+		return ""
+	})...)
+}
+
+// part returns the stats inputs of a transcript, adding them if it has none yet
+func (d *digestBuilder) part(id string) *statsPart {
+	if d.Digest.Parts == nil {
+		d.Digest.Parts = make(map[string]*statsPart)
+	}
+	part := d.Digest.Parts[id]
+	if part == nil {
+		part = &statsPart{}
+		d.Digest.Parts[id] = part
+	}
+	return part
+}
+
+// call returns the tool call with the given tool_use ID, or nil
+func (d *digestBuilder) call(toolUseID string) *ToolCall {
+	ref, ok := d.Calls[toolUseID]
+	if !ok {
+		return nil
+	}
+	return &d.Digest.Parts[ref.Part].ToolCalls[ref.Index]
+}
+
+// clone copies the builder so that adding to the copy leaves it unchanged
+func (d *digestBuilder) clone() *digestBuilder {
+	if d == nil {
+		return nil
+	}
+	c := &digestBuilder{
+		Digest: sessionDigest{Search: slices.Clip(d.Digest.Search)},
+		Calls:  maps.Clone(d.Calls),
+	}
+	if d.Digest.Parts != nil {
+		c.Digest.Parts = make(map[string]*statsPart, len(d.Digest.Parts))
+		for id, part := range d.Digest.Parts {
+			clone := *part
+			clone.Usage = slices.Clip(part.Usage)
+			clone.ToolCalls = slices.Clone(part.ToolCalls) // Results are filled in later
+			c.Digest.Parts[id] = &clone
+		}
+	}
+	return c
+}
+
+// Save writes the cache back to disk, without the entries of files not seen in this run
+func (c *ParseCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path, entry := range c.entries {
+		if !entry.seen {
+			delete(c.entries, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	file := parseCacheFile{Format: parseCacheFormat, Build: cacheBuild(), Entries: c.entries}
+	if err := writeGobFile(c.path, &file); err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+
+	// Drop the digests no entry refers to any more
+	kept := make(map[string]bool, len(c.entries))
+	for _, entry := range c.entries {
+		kept[entry.Digest] = true
+	}
+	if files, err := os.ReadDir(c.digestsDir()); err == nil {
+		for _, f := range files {
+			if !kept[f.Name()] {
+				os.Remove(filepath.Join(c.digestsDir(), f.Name()))
+			}
+		}
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// writeGobFile encodes v to path through a temporary file, so readers never see a
+// partly written file
+func writeGobFile(path string, v any) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "tmp-*.gob")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if err := gob.NewEncoder(tmpFile).Encode(v); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+// Summary reports the cache hits and misses of this run
+func (c *ParseCache) Summary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("sessions %d hits, %d appended, %d misses; search and stats %d hits, %d misses",
+		c.metaHits, c.metaResumes, c.metaMisses, c.digestHits, c.digestMisses)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCache(t *testing.T) {
+	sourceDir := t.TempDir()
+	outputDir := t.TempDir()
+	writeCorpus(t, sourceDir, 2, 3, 2)
+
+	// Older layout subagent, linked to its Task by prompt: the link data must survive the cache
+	projectDir := filepath.Join(sourceDir, "-Users-test-project0")
+	parent := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Investigate"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"task1","name":"Task","input":{"description":"Check the tests","prompt":"Run the tests"}}]}}
+`
+	agent := `{"type":"user","uuid":"t1","isSidechain":true,"sessionId":"parent","timestamp":"2025-12-29T10:00:04.000Z","message":{"role":"user","content":"Run the tests"}}
+`
+	for name, content := range map[string]string{"parent.jsonl": parent, "agent-c3d4.jsonl": agent} {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	load := func() (*ParseCache, []Project) {
+		t.Helper()
+		cache := OpenParseCache(outputDir)
+		projects, err := LoadAllProjectMetas(sourceDir, LoadOptions{Cache: cache})
+		if err != nil {
+			t.Fatalf("LoadAllProjectMetas failed: %v", err)
+		}
+		NewCachedSearchIndex(projects, cache)
+		ComputeCachedStats(projects, DefaultPriceTable(), cache)
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		return cache, projects
+	}
+
+	// The search index and the stats each look up every session, digested while parsing
+	cache, _ := load()
+	if cache.metaHits != 0 || cache.metaMisses != 8 || cache.digestHits != 14 || cache.digestMisses != 0 {
+		t.Errorf("Cold start: %s, want 0 hits and 8 misses for 7 sessions", cache.Summary())
+	}
+
+	// A restart reparses nothing
+	cache, projects := load()
+	if cache.metaHits != 8 || cache.metaMisses != 0 || cache.digestHits != 14 || cache.digestMisses != 0 {
+		t.Errorf("Warm start: %s, want all hits", cache.Summary())
+	}
+	var linked bool
+	for _, p := range projects {
+		for _, s := range p.Sessions {
+			if s.ID == "parent" {
+				linked = len(s.Subagents) == 1 && s.Subagents[0].ParentToolUseID == "task1"
+			}
+		}
+	}
+	if !linked {
+		t.Error("Subagent not linked to its Task from cached metadata")
+	}
+
+	// Cached results match a load without the cache
+	uncached, err := LoadAllProjectMetas(sourceDir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetas failed: %v", err)
+	}
+	want := ComputeStats(uncached, DefaultPriceTable())
+	got := ComputeCachedStats(projects, DefaultPriceTable(), cache)
+	if got.TotalMessages != want.TotalMessages || got.TotalTokens != want.TotalTokens ||
+		got.TotalCost != want.TotalCost || !reflect.DeepEqual(got.ToolStats, want.ToolStats) {
+		t.Errorf("Cached stats = %d messages, %d tokens, %v; want %d, %d, %v",
+			got.TotalMessages, got.TotalTokens, got.ToolStats, want.TotalMessages, want.TotalTokens, want.ToolStats)
+	}
+	query := "task"
+	if got, want := NewCachedSearchIndex(projects, cache).Search(query, "", ""), NewSearchIndex(uncached).Search(query, "", ""); len(got) != len(want) || len(got) == 0 {
+		t.Errorf("Cached search for %q found %d sessions, want %d", query, len(got), len(want))
+	}
+
+	// An appended file is read from where it was left; a touched but unchanged one isn't read
+	changed := filepath.Join(sourceDir, "-Users-test-project1", "session-000.jsonl")
+	f, err := os.OpenFile(changed, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", changed, err)
+	}
+	f.WriteString(`{"type":"user","uuid":"new","timestamp":"2025-12-29T11:00:00.000Z","message":{"role":"user","content":"One more thing"}}` + "\n")
+	f.WriteString(`{"type":"assistant","uuid":"reply","parentUuid":"new","timestamp":"2025-12-29T11:00:01.000Z","message":{"id":"resp_new","role":"assistant","content":[{"type":"text","text":"Sure"}]}}` + "\n")
+	f.Close()
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(projectDir, "parent.jsonl"), later, later); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	cache, projects = load()
+	if cache.metaHits != 7 || cache.metaResumes != 1 || cache.metaMisses != 0 || cache.digestMisses != 0 {
+		t.Errorf("After an append: %s, want 7 hits and 1 appended", cache.Summary())
+	}
+	if got := NewCachedSearchIndex(projects, cache).Search("thing", "", ""); len(got) != 1 {
+		t.Errorf("Appended message found in %d sessions, want 1", len(got))
+	}
+
+	// Message text is kept in one digest per session file, not in the cache file
+	data, err := os.ReadFile(filepath.Join(outputDir, ".cache", "sessions.gob"))
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	if strings.Contains(string(data), "One more thing") {
+		t.Error("Cache file holds message text")
+	}
+	if digests, err := os.ReadDir(filepath.Join(outputDir, ".cache", "digests")); err != nil || len(digests) != 8 {
+		t.Errorf("Got %d digest files (%v), want one per session file", len(digests), err)
+	}
+
+	// A rewritten file is read again
+	content, err := os.ReadFile(changed)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", changed, err)
+	}
+	rewritten := append([]byte(`{"type":"user","uuid":"first","timestamp":"2025-12-29T09:00:00.000Z","message":{"role":"user","content":"Start over"}}`+"\n"), content...)
+	if err := os.WriteFile(changed, rewritten, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", changed, err)
+	}
+	cache, _ = load()
+	if cache.metaHits != 7 || cache.metaResumes != 0 || cache.metaMisses != 1 {
+		t.Errorf("After a rewrite: %s, want 7 hits and 1 miss", cache.Summary())
+	}
+
+	// So is a truncated one
+	if err := os.WriteFile(changed, content[:len(content)/2], 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", changed, err)
+	}
+	cache, _ = load()
+	if cache.metaHits != 7 || cache.metaResumes != 0 || cache.metaMisses != 1 {
+		t.Errorf("After truncation: %s, want 7 hits and 1 miss", cache.Summary())
+	}
+}
+
+func TestParseCache_LoadSessionMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+	first := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Run the tests"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Bash","input":{"command":"go test"}}]}}
+`
+	more := `{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool1","content":"ok"}]}}
+`
+	if err := os.WriteFile(sessionPath, []byte(first), 0644); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	cache := OpenParseCache(t.TempDir())
+	load := func() *Session {
+		t.Helper()
+		meta, err := cache.LoadSessionMeta(sessionPath, "test-session")
+		if err != nil {
+			t.Fatalf("LoadSessionMeta failed: %v", err)
+		}
+		session, err := cache.LoadSessionMessages(meta)
+		if err != nil {
+			t.Fatalf("LoadSessionMessages failed: %v", err)
+		}
+		return session
+	}
+
+	if session := load(); len(session.Messages) != 2 || toolCallIndex(session.ToolCalls).Joined("tool1") != nil {
+		t.Fatalf("Got %d messages before the append, want 2 with the call pending", len(session.Messages))
+	}
+
+	// The result of the earlier call arrives with the appended line
+	f, err := os.OpenFile(sessionPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open session file: %v", err)
+	}
+	f.WriteString(more)
+	f.Close()
+
+	session := load()
+	want, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+	if !reflect.DeepEqual(session.Messages, want.Messages) || !reflect.DeepEqual(session.ToolCalls, want.ToolCalls) {
+		t.Errorf("Resumed messages %+v, want %+v", session.Messages, want.Messages)
+	}
+	if call := toolCallIndex(session.ToolCalls).Joined("tool1"); call == nil || call.Output != "ok" {
+		t.Errorf("Call not joined with its appended result: %+v", call)
+	}
+	if cache.metaResumes != 1 {
+		t.Errorf("Metadata: %s, want 1 appended", cache.Summary())
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -10,10 +11,16 @@ import (
 )
 
 var (
-	servePort  int
-	serveWatch bool
-	serveList  bool
-	serveForce bool
+	servePort    int
+	serveWatch   bool
+	serveList    bool
+	serveForce   bool
+	servePricing string
+	serveJobs    int
+	serveSources []string
+	serveStrict  bool
+	serveOrphans string
+	serveArchive bool
 )
 
 var serveCmd = &cobra.Command{
@@ -28,14 +35,61 @@ The server provides:
 - Search API for full-text search across all messages
 - Real-time stats endpoint
 
+Sessions are read from ~/.claude/projects, or $CLAUDE_CONFIG_DIR/projects
+when CLAUDE_CONFIG_DIR is set. With --source flags, or a sources list in
+~/.config/claude-code-logs/config.yaml, they are read from several roots
+instead, such as a second config dir or a synced backup. Each root is given
+as a path or name=path; the name is shown on its projects:
+
+  sources:
+    - ~/.claude/projects
+    - name: laptop
+      path: ~/Backups/laptop/.claude/projects
+
 With --watch flag, the server will also:
-- Monitor ~/.claude/projects for changes
+- Monitor every source root for changes
 - Automatically regenerate Markdown when sessions are modified
+- Show new and updated sessions in the project tree, search and stats
 - Debounce rapid changes for efficiency
 
 With --list flag, you can interactively select which projects to serve.
 
-With --force flag, regenerate all files regardless of modification time.
+With --force flag, regenerate all files regardless of modification time,
+and reparse all sessions instead of using the parse cache.
+
+Parsed sessions are cached in <dir>/.cache/sessions.gob, so a restart only
+reparses the session files that changed.
+
+With --strict flag, fail when a session file has lines that aren't JSON,
+entry or content block types the parser doesn't know, or timestamps it can't
+read, instead of skipping them. Either way, they are listed on the
+diagnostics page (/diagnostics) and in /api/diagnostics.
+
+With --orphans flag, choose what happens to the Markdown of sessions whose
+JSONL file is gone (e.g. after Claude Code cleaned up old transcripts):
+keep (default) marks it source_missing in its frontmatter and lists the
+session as archived, archive moves it out of the site to <dir>/.orphans,
+delete removes it. Output folders of projects whose folder is gone are
+handled the same way.
+
+With --archive flag, mirror every session file into <dir>/.archive before
+Claude Code cleans old ones up: files are stored gzip-compressed and named by
+content hash, so identical content is stored once, and a file that was
+rewritten rather than appended to keeps its earlier versions. Sessions whose
+file is gone are loaded from the archive, with all their fields.
+
+With --pricing flag, load per-model price overrides from a YAML file
+(default: ~/.config/claude-code-logs/pricing.yaml if it exists). Entries can
+carry an effective date so older messages are costed at the price valid then:
+
+  prices:
+    - model: opus-4-1
+      input: 15
+      output: 75
+    - model: sonnet
+      effective: 2026-01-01
+      input: 2.5
+      output: 12
 
 Example:
   claude-code-logs serve
@@ -44,7 +98,13 @@ Example:
   claude-code-logs serve --watch               (regenerates on changes)
   claude-code-logs serve --list                (select projects interactively)
   claude-code-logs serve --list --watch        (select projects + watch mode)
-  claude-code-logs serve --force               (regenerate all files)`,
+  claude-code-logs serve --force               (regenerate all files)
+  claude-code-logs serve --pricing prices.yaml (custom model prices)
+  claude-code-logs serve --jobs 4              (parse 4 session files at a time)
+  claude-code-logs serve --source ~/.claude/projects --source work=/mnt/work/projects
+  claude-code-logs serve --strict              (fail on log format problems)
+  claude-code-logs serve --orphans delete      (drop sessions Claude Code removed)
+  claude-code-logs serve --archive             (keep the raw session files too)`,
 	RunE: runServe,
 }
 
@@ -54,6 +114,12 @@ func init() {
 	serveCmd.Flags().BoolVarP(&serveWatch, "watch", "w", false, "Enable watch mode (regenerate on changes)")
 	serveCmd.Flags().BoolVarP(&serveList, "list", "l", false, "Interactively select projects to serve")
 	serveCmd.Flags().BoolVarP(&serveForce, "force", "f", false, "Force regeneration of all files (ignore mtime)")
+	serveCmd.Flags().StringVar(&servePricing, "pricing", "", "Pricing override file (default: ~/.config/claude-code-logs/pricing.yaml)")
+	serveCmd.Flags().IntVarP(&serveJobs, "jobs", "j", 0, "Session files to parse in parallel (default: number of CPUs)")
+	serveCmd.Flags().StringArrayVar(&serveSources, "source", nil, "Claude projects directory to read, as path or name=path (repeatable)")
+	serveCmd.Flags().BoolVar(&serveStrict, "strict", false, "Fail on malformed lines and unknown entry types instead of skipping them")
+	serveCmd.Flags().StringVar(&serveOrphans, "orphans", string(OrphanKeep), "Markdown of removed sessions: keep, archive or delete")
+	serveCmd.Flags().BoolVar(&serveArchive, "archive", false, "Archive session files in <dir>/.archive and load removed sessions from it")
 }
 
 // RegisterServeFlags adds serve flags to a command (used for root command default)
@@ -62,6 +128,12 @@ func RegisterServeFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&serveWatch, "watch", "w", false, "Enable watch mode (regenerate on changes)")
 	cmd.Flags().BoolVarP(&serveList, "list", "l", false, "Interactively select projects to serve")
 	cmd.Flags().BoolVarP(&serveForce, "force", "f", false, "Force regeneration of all files (ignore mtime)")
+	cmd.Flags().StringVar(&servePricing, "pricing", "", "Pricing override file (default: ~/.config/claude-code-logs/pricing.yaml)")
+	cmd.Flags().IntVarP(&serveJobs, "jobs", "j", 0, "Session files to parse in parallel (default: number of CPUs)")
+	cmd.Flags().StringArrayVar(&serveSources, "source", nil, "Claude projects directory to read, as path or name=path (repeatable)")
+	cmd.Flags().BoolVar(&serveStrict, "strict", false, "Fail on malformed lines and unknown entry types instead of skipping them")
+	cmd.Flags().StringVar(&serveOrphans, "orphans", string(OrphanKeep), "Markdown of removed sessions: keep, archive or delete")
+	cmd.Flags().BoolVar(&serveArchive, "archive", false, "Archive session files in <dir>/.archive and load removed sessions from it")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -69,6 +141,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 	if servePort < 1 || servePort > 65535 {
 		return fmt.Errorf("invalid port: %d (must be 1-65535)", servePort)
 	}
+	if serveJobs < 0 {
+		return fmt.Errorf("invalid jobs: %d (must be 0 or more)", serveJobs)
+	}
+	orphans, err := ParseOrphanPolicy(serveOrphans)
+	if err != nil {
+		return err
+	}
 
 	// Get output directory
 	outDir, err := getOutputDir()
@@ -80,29 +159,74 @@ func runServe(cmd *cobra.Command, args []string) error {
 	logVerbose("Port: %d", servePort)
 	logVerbose("Watch mode: %v", serveWatch)
 	logVerbose("Force regeneration: %v", serveForce)
+	logVerbose("Orphan policy: %s", orphans)
+	logVerbose("Archive: %v", serveArchive)
+	logVerbose("Parallel jobs: %d", LoadOptions{Jobs: serveJobs}.workers())
 
 	// Check if output directory is writable (creates if needed)
 	if err := ensureWritableDir(outDir); err != nil {
 		return fmt.Errorf("output directory not writable: %w", err)
 	}
 
-	// Get Claude projects path
-	projectsPath, err := DefaultClaudeProjectsPath()
+	// Load model prices (built-in defaults plus optional overrides)
+	pricing, err := LoadPricing(servePricing)
+	if err != nil {
+		return fmt.Errorf("loading pricing: %w", err)
+	}
+
+	// Get the Claude projects directories to read
+	configPath, err := DefaultConfigPath()
 	if err != nil {
 		return err
 	}
+	sources, err := ResolveSources(serveSources, configPath)
+	if err != nil {
+		return fmt.Errorf("resolving sources: %w", err)
+	}
+	for _, source := range sources {
+		logVerbose("Source %s: %s", source.Name, source.Path)
+	}
+
+	// Sessions parsed by earlier runs are reused unless their files changed
+	cache := OpenParseCache(outDir)
+	if serveForce {
+		cache.Clear()
+	}
+
+	// Raw session files are archived before loading, so removed ones load from there
+	var archive *Archive
+	if serveArchive {
+		if archive, err = OpenArchive(outDir); err != nil {
+			return err
+		}
+		fmt.Println("Archiving session files...")
+		result := archive.Sync(sources, nil, 0)
+		fmt.Printf("Archived: %d new, %d updated, %d new versions\n", result.Archived, result.Updated, result.Versions)
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", e)
+		}
+		if err := archive.Save(); err != nil {
+			return fmt.Errorf("saving archive: %w", err)
+		}
+	}
 
 	// Load all projects
 	fmt.Println("Discovering projects...")
 	start := time.Now()
-	projects, err := LoadAllProjects(projectsPath)
+	projects, err := LoadAllProjectMetasFrom(sources, LoadOptions{
+		Jobs:     serveJobs,
+		Progress: terminal(os.Stderr),
+		Cache:    cache,
+		Strict:   serveStrict,
+		Archive:  archive,
+	})
 	if err != nil {
 		return fmt.Errorf("loading projects: %w", err)
 	}
 
 	if len(projects) == 0 {
 		fmt.Println("No Claude projects found.")
-		fmt.Println("Claude projects are typically stored in ~/.claude/projects")
+		fmt.Println("Claude projects are typically stored in ~/.claude/projects (see --source)")
 		fmt.Println("Server will start but will have no content to display.")
 	}
 
@@ -120,6 +244,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 
 	// Generate Markdown
+	listed := projects // With the projects kept from removed folders
 	if len(projects) > 0 {
 		fmt.Println("Generating Markdown...")
 		// Count total sessions
@@ -129,13 +254,24 @@ func runServe(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Printf("Found %d projects with %d sessions\n", len(projects), totalSessions)
 
-		result, err := GenerateAllMarkdown(projects, outDir, projectsPath, serveForce)
+		result, err := GenerateAllMarkdown(projects, outDir, sources, serveForce, pricing, cache, orphans)
 		if err != nil {
 			return fmt.Errorf("generating Markdown: %w", err)
 		}
 
 		// Report results
 		fmt.Printf("Generated: %d, Skipped: %d\n", result.Generated, result.Skipped)
+		if result.Orphans > 0 {
+			switch orphans {
+			case OrphanArchive:
+				fmt.Printf("Removed sessions: %d moved to %s\n", result.Orphans, filepath.Join(outDir, orphansDirName))
+			case OrphanDelete:
+				fmt.Printf("Removed sessions: %d deleted\n", result.Orphans)
+			default:
+				fmt.Printf("Removed sessions: %d kept and marked archived\n", result.Orphans)
+			}
+		}
+		listed = result.withArchivedProjects(projects)
 		if len(result.Errors) > 0 {
 			fmt.Printf("Warnings: %d errors during generation\n", len(result.Errors))
 			for _, e := range result.Errors {
@@ -145,14 +281,33 @@ func runServe(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Completed in %v\n", time.Since(start).Round(time.Millisecond))
 	}
 
+	server, err := NewCachedServer(servePort, outDir, listed, pricing, cache)
+	if err != nil {
+		return fmt.Errorf("creating server: %w", err)
+	}
+
+	// The search index and stats are built: keep what was parsed for the next start
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save parse cache: %v\n", err)
+	}
+	logVerbose("Parse cache: %s", cache.Summary())
+
 	// Handle watch mode
 	if serveWatch {
 		config := WatchConfig{
-			SourceDir:        projectsPath,
+			Sources:          sources,
 			OutputDir:        outDir,
 			PollInterval:     30 * time.Second,
 			DebounceDelay:    2 * time.Second,
 			SelectedProjects: selectedFolders, // nil means all projects
+			Pricing:          pricing,
+			Jobs:             serveJobs,
+			Cache:            cache,
+			Strict:           serveStrict,
+			Orphans:          orphans,
+			Archive:          archive,
+			Projects:         projects,      // Changes reload only the project they happen in
+			OnReload:         server.Reload, // New sessions show up without a restart
 		}
 
 		cancelWatch, err := WatchInBackground(config)
@@ -163,7 +318,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 			if len(selectedFolders) > 0 {
 				fmt.Printf("Watch mode enabled for %d selected projects\n", len(selectedFolders))
 			} else {
-				fmt.Println("Watch mode enabled - HTML will regenerate on changes")
+				fmt.Println("Watch mode enabled - Markdown, search and stats will update on changes")
 			}
 			defer cancelWatch()
 		}
@@ -171,7 +326,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	// Start server
 	fmt.Printf("Starting server on http://127.0.0.1:%d\n", servePort)
-	return StartServer(servePort, outDir, projects)
+	return server.Start()
 }
 
 // ensureWritableDir ensures the directory exists and is writable
@@ -198,6 +353,7 @@ func ensureWritableDir(path string) error {
 func selectProjects(projects []Project) ([]Project, []string, error) {
 	// Build options with useful info
 	options := make([]string, len(projects))
+	showSource := multipleSources(projects)
 	for i, p := range projects {
 		sessionCount := len(p.Sessions)
 		lastActivity := "no sessions"
@@ -208,6 +364,9 @@ func selectProjects(projects []Project) ([]Project, []string, error) {
 			}
 		}
 		options[i] = fmt.Sprintf("%s (%d sessions, %s)", p.Path, sessionCount, lastActivity)
+		if showSource {
+			options[i] = fmt.Sprintf("[%s] %s", p.Source, options[i])
+		}
 	}
 
 	// Create multi-select prompt
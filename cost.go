@@ -1,18 +1,119 @@
 package main
 
-// Anthropic Claude pricing constants (per million tokens)
-// Based on: https://platform.claude.com/docs/en/about-claude/pricing
-const (
-	// Sonnet 4 pricing (default)
-	SonnetInputPricePerMTok  = 3.0  // $3.00 per million input tokens
-	SonnetOutputPricePerMTok = 15.0 // $15.00 per million output tokens
+import (
+	"strings"
+	"unicode"
 )
 
-// CalculateCost computes the estimated API cost based on token counts.
-// Uses Sonnet 4 pricing by default.
+// ModelPrice holds Anthropic Claude prices for one model family (USD per million tokens)
+// Based on: https://platform.claude.com/docs/en/about-claude/pricing
+type ModelPrice struct {
+	Input        float64 // Base input tokens
+	Output       float64 // Output tokens
+	CacheWrite5m float64 // Prompt cache writes with the default 5 minute TTL
+	CacheWrite1h float64 // Prompt cache writes with the 1 hour TTL
+	CacheRead    float64 // Prompt cache hits and refreshes
+}
+
+// DefaultModelFamily is used to price messages whose model is unknown
+const DefaultModelFamily = "sonnet"
+
+// ModelPricing maps a model family (see ModelFamily) to its prices.
+// A bare family name ("opus") prices the latest generation of that family
+// and is the fallback for versions not listed explicitly.
+var ModelPricing = map[string]ModelPrice{
+	"opus":      {Input: 5, Output: 25, CacheWrite5m: 6.25, CacheWrite1h: 10, CacheRead: 0.50},
+	"opus-4-1":  {Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50},
+	"opus-4":    {Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50},
+	"opus-3":    {Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50},
+	"sonnet":    {Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.30},
+	"haiku":     {Input: 1, Output: 5, CacheWrite5m: 1.25, CacheWrite1h: 2, CacheRead: 0.10},
+	"haiku-3-5": {Input: 0.80, Output: 4, CacheWrite5m: 1, CacheWrite1h: 1.6, CacheRead: 0.08},
+	"haiku-3":   {Input: 0.25, Output: 1.25, CacheWrite5m: 0.30, CacheWrite1h: 0.50, CacheRead: 0.03},
+}
+
+// ModelFamily normalizes a model ID to a pricing family key.
+// Examples: "claude-opus-4-1-20250805" -> "opus-4-1", "claude-3-5-haiku-20241022" -> "haiku-3-5",
+// "claude-sonnet-4-5" -> "sonnet-4-5". Returns "" if the ID names no known family.
+func ModelFamily(model string) string {
+	parts := strings.FieldsFunc(strings.ToLower(model), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var name string
+	var version []string
+	for _, part := range parts {
+		if len(part) == 8 && isDigits(part) {
+			// Release date suffix ends the model name (Bedrock/Vertex IDs append more after it)
+			break
+		}
+		switch {
+		case part == "opus" || part == "sonnet" || part == "haiku":
+			name = part
+		case len(part) <= 2 && isDigits(part):
+			version = append(version, part)
+		}
+	}
+
+	if name == "" {
+		return ""
+	}
+	if len(version) == 0 {
+		return name
+	}
+	return name + "-" + strings.Join(version, "-")
+}
+
+// PriceForModel returns the prices for a model ID, falling back to the bare
+// family name and then to DefaultModelFamily
+func PriceForModel(model string) ModelPrice {
+	family := ModelFamily(model)
+	if price, ok := ModelPricing[family]; ok {
+		return price
+	}
+	if price, ok := ModelPricing[modelBaseFamily(family)]; ok {
+		return price
+	}
+	return ModelPricing[DefaultModelFamily]
+}
+
+// modelBaseFamily strips the version from a model family: "opus-4-1" -> "opus"
+func modelBaseFamily(family string) string {
+	if i := strings.Index(family, "-"); i > 0 {
+		return family[:i]
+	}
+	return family
+}
+
+// Cost computes the API cost of a usage record in USD
+func (p ModelPrice) Cost(u Usage) float64 {
+	cacheWrite1h := u.CacheCreation1hInputTokens
+	cacheWrite5m := u.CacheCreationInputTokens - cacheWrite1h
+
+	cost := float64(u.InputTokens) * p.Input
+	cost += float64(u.OutputTokens) * p.Output
+	cost += float64(cacheWrite5m) * p.CacheWrite5m
+	cost += float64(cacheWrite1h) * p.CacheWrite1h
+	cost += float64(u.CacheReadInputTokens) * p.CacheRead
+	return cost / 1_000_000
+}
+
+// CalculateCost computes the estimated API cost of a message's usage
+// priced at the built-in rates of the model that produced it.
 // Returns cost in USD.
-func CalculateCost(inputTokens, outputTokens int64) float64 {
-	inputCost := float64(inputTokens) / 1_000_000 * SonnetInputPricePerMTok
-	outputCost := float64(outputTokens) / 1_000_000 * SonnetOutputPricePerMTok
-	return inputCost + outputCost
+func CalculateCost(model string, usage Usage) float64 {
+	return PriceForModel(model).Cost(usage)
+}
+
+// isDigits reports whether s is non-empty and consists only of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
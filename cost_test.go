@@ -8,66 +8,117 @@ import (
 func TestCalculateCost(t *testing.T) {
 	tests := []struct {
 		name         string
-		inputTokens  int64
-		outputTokens int64
+		model        string
+		usage        Usage
 		expectedCost float64
 	}{
 		{
 			name:         "zero tokens",
-			inputTokens:  0,
-			outputTokens: 0,
+			model:        "claude-sonnet-4-5-20250929",
+			usage:        Usage{},
 			expectedCost: 0.0,
 		},
 		{
 			name:         "1 million input tokens only",
-			inputTokens:  1_000_000,
-			outputTokens: 0,
+			model:        "claude-sonnet-4-5-20250929",
+			usage:        Usage{InputTokens: 1_000_000},
 			expectedCost: 3.0, // $3/MTok
 		},
 		{
 			name:         "1 million output tokens only",
-			inputTokens:  0,
-			outputTokens: 1_000_000,
+			model:        "claude-sonnet-4-5-20250929",
+			usage:        Usage{OutputTokens: 1_000_000},
 			expectedCost: 15.0, // $15/MTok
 		},
 		{
-			name:         "1 million each",
-			inputTokens:  1_000_000,
-			outputTokens: 1_000_000,
-			expectedCost: 18.0, // $3 + $15
+			name:         "typical session (50k input, 10k output)",
+			model:        "claude-sonnet-4-20250514",
+			usage:        Usage{InputTokens: 50_000, OutputTokens: 10_000},
+			expectedCost: 0.30, // $0.15 + $0.15
 		},
 		{
-			name:         "realistic small usage (10k input, 2k output)",
-			inputTokens:  10_000,
-			outputTokens: 2_000,
-			expectedCost: 0.06, // $0.03 + $0.03
+			name:         "cache reads and 5m writes",
+			model:        "claude-sonnet-4-5-20250929",
+			usage:        Usage{CacheCreationInputTokens: 1_000_000, CacheReadInputTokens: 1_000_000},
+			expectedCost: 4.05, // $3.75 + $0.30
 		},
 		{
-			name:         "typical session (50k input, 10k output)",
-			inputTokens:  50_000,
-			outputTokens: 10_000,
-			expectedCost: 0.30, // $0.15 + $0.15
+			name:         "1h cache writes priced separately",
+			model:        "claude-sonnet-4-5-20250929",
+			usage:        Usage{CacheCreationInputTokens: 2_000_000, CacheCreation1hInputTokens: 1_000_000},
+			expectedCost: 9.75, // $3.75 (5m) + $6 (1h)
+		},
+		{
+			name:         "opus 4.1 pricing",
+			model:        "claude-opus-4-1-20250805",
+			usage:        Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			expectedCost: 90.0, // $15 + $75
+		},
+		{
+			name:         "opus 4.5 pricing",
+			model:        "claude-opus-4-5-20251101",
+			usage:        Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			expectedCost: 30.0, // $5 + $25
+		},
+		{
+			name:         "haiku 3.5 pricing",
+			model:        "claude-3-5-haiku-20241022",
+			usage:        Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			expectedCost: 4.8, // $0.80 + $4
+		},
+		{
+			name:         "unknown model uses default pricing",
+			model:        "",
+			usage:        Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			expectedCost: 18.0, // $3 + $15
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CalculateCost(tt.inputTokens, tt.outputTokens)
+			got := CalculateCost(tt.model, tt.usage)
 			// Use tolerance for floating point comparison
 			if math.Abs(got-tt.expectedCost) > 0.0001 {
-				t.Errorf("CalculateCost(%d, %d) = %v, want %v",
-					tt.inputTokens, tt.outputTokens, got, tt.expectedCost)
+				t.Errorf("CalculateCost(%q, %+v) = %v, want %v",
+					tt.model, tt.usage, got, tt.expectedCost)
+			}
+		})
+	}
+}
+
+func TestModelFamily(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected string
+	}{
+		{"claude-opus-4-1-20250805", "opus-4-1"},
+		{"claude-opus-4-20250514", "opus-4"},
+		{"claude-sonnet-4-5-20250929", "sonnet-4-5"},
+		{"claude-3-5-haiku-20241022", "haiku-3-5"},
+		{"claude-3-opus-20240229", "opus-3"},
+		{"claude-haiku-4-5", "haiku-4-5"},
+		{"us.anthropic.claude-sonnet-4-5-20250929-v1:0", "sonnet-4-5"},
+		{"claude-opus-4-1@20250805", "opus-4-1"},
+		{"<synthetic>", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := ModelFamily(tt.model); got != tt.expected {
+				t.Errorf("ModelFamily(%q) = %q, want %q", tt.model, got, tt.expected)
 			}
 		})
 	}
 }
 
-func TestPricingConstants(t *testing.T) {
-	// Verify pricing constants match Anthropic's published rates
-	if SonnetInputPricePerMTok != 3.0 {
-		t.Errorf("SonnetInputPricePerMTok = %v, want 3.0", SonnetInputPricePerMTok)
+func TestPriceForModel_Fallbacks(t *testing.T) {
+	// Unlisted versions use the bare family price
+	if got := PriceForModel("claude-opus-4-7"); got != ModelPricing["opus"] {
+		t.Errorf("PriceForModel(opus-4-7) = %+v, want opus family price", got)
 	}
-	if SonnetOutputPricePerMTok != 15.0 {
-		t.Errorf("SonnetOutputPricePerMTok = %v, want 15.0", SonnetOutputPricePerMTok)
+	// Unknown models use the default family
+	if got := PriceForModel("gpt-4"); got != ModelPricing[DefaultModelFamily] {
+		t.Errorf("PriceForModel(gpt-4) = %+v, want default price", got)
 	}
 }
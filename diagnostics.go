@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParseDiagnostics counts the problems met while parsing a session file: lines that
+// aren't JSON, entry and content block types the parser doesn't know, and timestamps it
+// couldn't read. Lenient parsing skips or works around them, so they are how a change
+// to the Claude Code log format gets noticed.
+type ParseDiagnostics struct {
+	MalformedLines     int            `json:"malformedLines"`
+	UnknownEntryTypes  map[string]int `json:"unknownEntryTypes,omitempty"`
+	UnknownBlockTypes  map[string]int `json:"unknownBlockTypes,omitempty"`
+	TimestampFallbacks int            `json:"timestampFallbacks"`
+	Samples            []string       `json:"samples,omitempty"` // The first problems, with their line numbers
+}
+
+// maxDiagnosticSamples bounds the problems described per file
+const maxDiagnosticSamples = 5
+
+// Count returns the number of problems
+func (d *ParseDiagnostics) Count() int {
+	if d == nil {
+		return 0
+	}
+	n := d.MalformedLines + d.TimestampFallbacks
+	for _, c := range d.UnknownEntryTypes {
+		n += c
+	}
+	for _, c := range d.UnknownBlockTypes {
+		n += c
+	}
+	return n
+}
+
+// String summarizes the problems, e.g. "2 malformed lines, unknown entry types: foo (3)"
+func (d *ParseDiagnostics) String() string {
+	var parts []string
+	if d.MalformedLines > 0 {
+		parts = append(parts, fmt.Sprintf("%d malformed lines", d.MalformedLines))
+	}
+	if len(d.UnknownEntryTypes) > 0 {
+		parts = append(parts, "unknown entry types: "+formatTypeCounts(d.UnknownEntryTypes))
+	}
+	if len(d.UnknownBlockTypes) > 0 {
+		parts = append(parts, "unknown block types: "+formatTypeCounts(d.UnknownBlockTypes))
+	}
+	if d.TimestampFallbacks > 0 {
+		parts = append(parts, fmt.Sprintf("%d unreadable timestamps", d.TimestampFallbacks))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTypeCounts lists types by name with their counts
+func formatTypeCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return strings.Join(names, ", ")
+}
+
+// merge adds the problems of other
+func (d *ParseDiagnostics) merge(other *ParseDiagnostics) {
+	if other == nil {
+		return
+	}
+	d.MalformedLines += other.MalformedLines
+	d.TimestampFallbacks += other.TimestampFallbacks
+	for name, n := range other.UnknownEntryTypes {
+		d.UnknownEntryTypes = addTypeCount(d.UnknownEntryTypes, name, n)
+	}
+	for name, n := range other.UnknownBlockTypes {
+		d.UnknownBlockTypes = addTypeCount(d.UnknownBlockTypes, name, n)
+	}
+}
+
+// clone copies the diagnostics so that recording more leaves them unchanged
+func (d *ParseDiagnostics) clone() ParseDiagnostics {
+	c := *d
+	c.UnknownEntryTypes = maps.Clone(d.UnknownEntryTypes)
+	c.UnknownBlockTypes = maps.Clone(d.UnknownBlockTypes)
+	c.Samples = slices.Clip(d.Samples)
+	return c
+}
+
+// addTypeCount adds n to the count of name, making the map if needed (empty maps
+// don't survive the parse cache)
+func addTypeCount(counts map[string]int, name string, n int) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[name] += n
+	return counts
+}
+
+// lineDiagnostics records the problems of one line of a session file. The zero value
+// records nothing.
+type lineDiagnostics struct {
+	file *ParseDiagnostics
+	line int
+}
+
+func (l lineDiagnostics) malformed(err error) {
+	if l.file == nil {
+		return
+	}
+	l.file.MalformedLines++
+	l.sample("malformed line: %v", err)
+}
+
+func (l lineDiagnostics) unknownEntry(entryType string) {
+	if l.file == nil {
+		return
+	}
+	l.file.UnknownEntryTypes = addTypeCount(l.file.UnknownEntryTypes, entryType, 1)
+	l.sample("unknown entry type %q", entryType)
+}
+
+func (l lineDiagnostics) unknownBlock(blockType string) {
+	if l.file == nil {
+		return
+	}
+	l.file.UnknownBlockTypes = addTypeCount(l.file.UnknownBlockTypes, blockType, 1)
+	l.sample("unknown content block type %q", blockType)
+}
+
+func (l lineDiagnostics) timestampFallback(value string) {
+	if l.file == nil {
+		return
+	}
+	l.file.TimestampFallbacks++
+	l.sample("unreadable timestamp %q", value)
+}
+
+func (l lineDiagnostics) sample(format string, args ...any) {
+	if len(l.file.Samples) < maxDiagnosticSamples {
+		l.file.Samples = append(l.file.Samples, fmt.Sprintf("line %d: ", l.line)+fmt.Sprintf(format, args...))
+	}
+}
+
+// FileDiagnostics are the parse diagnostics of one session file
+type FileDiagnostics struct {
+	Path        string `json:"path"`
+	Project     string `json:"project"`
+	ProjectSlug string `json:"projectSlug"`
+	SessionID   string `json:"sessionId"`
+	ParseDiagnostics
+}
+
+// DiagnosticsReport lists the session files that had parse problems
+type DiagnosticsReport struct {
+	Files        int               `json:"files"` // Session files loaded
+	ProblemFiles []FileDiagnostics `json:"problemFiles"`
+	Totals       ParseDiagnostics  `json:"totals"`
+}
+
+// CollectDiagnostics reports the parse problems of the session and subagent files of
+// projects, files with the most problems first
+func CollectDiagnostics(projects []Project) DiagnosticsReport {
+	report := DiagnosticsReport{ProblemFiles: []FileDiagnostics{}}
+	var add func(project *Project, session *Session)
+	add = func(project *Project, session *Session) {
+		report.Files++
+		if d := session.Diagnostics; d.Count() > 0 {
+			report.ProblemFiles = append(report.ProblemFiles, FileDiagnostics{
+				Path:             session.SourcePath,
+				Project:          project.Path,
+				ProjectSlug:      project.Slug(),
+				SessionID:        session.ID,
+				ParseDiagnostics: *d,
+			})
+			report.Totals.merge(d)
+		}
+		for i := range session.Subagents {
+			// Sidechains found in the session file itself aren't files of their own
+			if session.Subagents[i].SourcePath != session.SourcePath {
+				add(project, &session.Subagents[i])
+			}
+		}
+	}
+	for i := range projects {
+		for j := range projects[i].Sessions {
+			add(&projects[i], &projects[i].Sessions[j])
+		}
+	}
+
+	sort.SliceStable(report.ProblemFiles, func(i, j int) bool {
+		a, b := &report.ProblemFiles[i], &report.ProblemFiles[j]
+		if a.Count() != b.Count() {
+			return a.Count() > b.Count()
+		}
+		return a.Path < b.Path
+	})
+	return report
+}
+
+// strictError returns an error naming the files with parse problems, or nil if there
+// are none
+func strictError(report DiagnosticsReport) error {
+	if len(report.ProblemFiles) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d session file(s) with parse problems (strict mode):", len(report.ProblemFiles))
+	for _, f := range report.ProblemFiles {
+		fmt.Fprintf(&b, "\n  - %s: %s", f.Path, f.String())
+	}
+	return errors.New(b.String())
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// problemLines is a session with one problem of each kind
+var problemLines = []string{
+	`{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Hello"}}`,
+	`{"type":"user","uuid":"u2"`,
+	`{"type":"queue-operation","uuid":"q1"}`,
+	`{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"yesterday","message":{"role":"assistant","content":[{"type":"text","text":"Hi"},{"type":"hologram"}]}}`,
+}
+
+func writeProblemSession(t *testing.T, dir string) string {
+	t.Helper()
+	projectDir := filepath.Join(dir, "-work-app")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	path := filepath.Join(projectDir, "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(problemLines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	path := writeProblemSession(t, t.TempDir())
+
+	for name, load := range map[string]sessionLoader{"ParseSession": ParseSession, "LoadSessionMeta": LoadSessionMeta} {
+		session, err := load(path, "session")
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		d := session.Diagnostics
+		if d == nil {
+			t.Fatalf("%s: no diagnostics", name)
+		}
+		if d.MalformedLines != 1 || d.UnknownEntryTypes["queue-operation"] != 1 ||
+			d.UnknownBlockTypes["hologram"] != 1 || d.TimestampFallbacks != 1 {
+			t.Errorf("%s: diagnostics = %+v, want one problem of each kind", name, d)
+		}
+		if d.Count() != 4 || len(d.Samples) != 4 || !strings.HasPrefix(d.Samples[0], "line 2: ") {
+			t.Errorf("%s: Count() = %d, samples %q", name, d.Count(), d.Samples)
+		}
+	}
+
+	// A clean file has none
+	clean := filepath.Join(t.TempDir(), "clean.jsonl")
+	if err := os.WriteFile(clean, []byte(problemLines[0]+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	session, err := LoadSessionMeta(clean, "clean")
+	if err != nil {
+		t.Fatalf("LoadSessionMeta failed: %v", err)
+	}
+	if session.Diagnostics != nil {
+		t.Errorf("Clean session has diagnostics %+v", session.Diagnostics)
+	}
+}
+
+func TestParseDiagnostics_Resume(t *testing.T) {
+	sourceDir := t.TempDir()
+	path := writeProblemSession(t, sourceDir)
+	cache := OpenParseCache(t.TempDir())
+
+	if _, err := cache.LoadSessionMeta(path, "session"); err != nil {
+		t.Fatalf("LoadSessionMeta failed: %v", err)
+	}
+
+	// Problems in appended lines add to those already found
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := f.WriteString("not json\n"); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	f.Close()
+
+	session, err := cache.LoadSessionMeta(path, "session")
+	if err != nil {
+		t.Fatalf("LoadSessionMeta failed: %v", err)
+	}
+	if got := session.Diagnostics.MalformedLines; got != 2 {
+		t.Errorf("MalformedLines = %d after append, want 2", got)
+	}
+	if got := session.Diagnostics.Count(); got != 5 {
+		t.Errorf("Count() = %d after append, want 5", got)
+	}
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	sourceDir := t.TempDir()
+	path := writeProblemSession(t, sourceDir)
+
+	projects, err := LoadAllProjectMetas(sourceDir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetas failed: %v", err)
+	}
+	report := CollectDiagnostics(projects)
+	if report.Files != 1 || len(report.ProblemFiles) != 1 {
+		t.Fatalf("Report = %+v, want one problem file", report)
+	}
+	file := report.ProblemFiles[0]
+	if file.Path != path || file.SessionID != "session" || file.ProjectSlug != projects[0].Slug() {
+		t.Errorf("Problem file = %+v", file)
+	}
+	if report.Totals.Count() != 4 {
+		t.Errorf("Totals.Count() = %d, want 4", report.Totals.Count())
+	}
+
+	// Strict mode fails on them instead
+	_, err = LoadAllProjectMetas(sourceDir, LoadOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Strict load error = %v, want one naming %s", err, path)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// System event kinds
+const (
+	EventCompact = "compact" // Context compacted, automatically or by /compact
+	EventCommand = "command" // Slash command invocation or its local output
+	EventHook    = "hook"    // Hook output
+	EventNotice  = "notice"  // Other system notices (API errors, warnings, ...)
+)
+
+// SystemEvent describes a transcript entry that is not part of the conversation itself
+type SystemEvent struct {
+	Kind      string // One of the Event* kinds
+	Subtype   string // Entry subtype as written by Claude Code, if any
+	Level     string // "info", "warning" or "error" when given
+	Text      string // Notice text, command line or hook output
+	Trigger   string // Compactions: "auto" or "manual"
+	PreTokens int64  // Compactions: context tokens before compacting
+}
+
+// hookEventNames are the hook events whose output Claude Code records as system entries
+var hookEventNames = regexp.MustCompile(`^(PreToolUse|PostToolUse|Notification|UserPromptSubmit|SessionStart|SessionEnd|Stop|SubagentStop|PreCompact)\b`)
+
+// Markup Claude Code wraps around slash commands and their local output
+var (
+	commandNamePattern   = regexp.MustCompile(`(?s)<command-name>(.*?)</command-name>`)
+	commandArgsPattern   = regexp.MustCompile(`(?s)<command-args>(.*?)</command-args>`)
+	commandOutputPattern = regexp.MustCompile(`(?s)<local-command-(stdout|stderr)>(.*?)</local-command-(?:stdout|stderr)>`)
+)
+
+// parseSystemEntry converts a "system" entry into an event message
+func parseSystemEntry(entry *jsonlEntry, diag lineDiagnostics) *Message {
+	event := &SystemEvent{
+		Kind:    EventNotice,
+		Subtype: entry.Subtype,
+		Level:   entry.Level,
+		Text:    rawString(entry.Content),
+	}
+
+	switch {
+	case entry.Subtype == "compact_boundary":
+		event.Kind = EventCompact
+		if entry.CompactMetadata != nil {
+			event.Trigger = entry.CompactMetadata.Trigger
+			event.PreTokens = entry.CompactMetadata.PreTokens
+		}
+	case entry.Subtype == "local_command":
+		if cmd := commandEvent(event.Text); cmd != nil {
+			event = cmd
+			event.Subtype = entry.Subtype
+		} else {
+			event.Kind = EventCommand
+		}
+	case strings.Contains(entry.Subtype, "hook") || hookEventNames.MatchString(event.Text):
+		event.Kind = EventHook
+	}
+
+	msg := &Message{
+		UUID:      entry.UUID,
+		Role:      "system",
+		Timestamp: parseTimestamp(entry.Timestamp, diag),
+		Sidechain: entry.IsSidechain,
+		Event:     event,
+
+		GitBranch: entry.GitBranch,
+		Version:   entry.Version,
+		UserType:  entry.UserType,
+	}
+	if entry.ParentUUID != nil {
+		msg.ParentUUID = *entry.ParentUUID
+	}
+	// A compact boundary starts a new chain; its logical parent is the last message before it
+	if msg.ParentUUID == "" {
+		msg.ParentUUID = entry.LogicalParentUUID
+	}
+	return msg
+}
+
+// commandEvent recognizes a user message that only records a slash command or its
+// output, returning nil for regular prompts
+func commandEvent(text string) *SystemEvent {
+	text = strings.TrimSpace(text)
+	if m := commandNamePattern.FindStringSubmatch(text); m != nil && strings.HasPrefix(text, "<command-") {
+		line := strings.TrimSpace(m[1])
+		if !strings.HasPrefix(line, "/") {
+			line = "/" + line
+		}
+		if a := commandArgsPattern.FindStringSubmatch(text); a != nil && strings.TrimSpace(a[1]) != "" {
+			line += " " + strings.TrimSpace(a[1])
+		}
+		return &SystemEvent{Kind: EventCommand, Text: line}
+	}
+	if m := commandOutputPattern.FindStringSubmatch(text); m != nil && strings.HasPrefix(text, "<local-command-") {
+		event := &SystemEvent{Kind: EventCommand, Subtype: "local_command_" + m[1], Text: strings.TrimSpace(m[2])}
+		if m[1] == "stderr" {
+			event.Level = "error"
+		}
+		return event
+	}
+	return nil
+}
+
+// Summary returns a one-line description of the event for inline markers
+func (e *SystemEvent) Summary() string {
+	first, _, _ := strings.Cut(strings.TrimSpace(e.Text), "\n")
+	switch e.Kind {
+	case EventCompact:
+		var details []string
+		if e.Trigger != "" {
+			details = append(details, e.Trigger)
+		}
+		if e.PreTokens > 0 {
+			details = append(details, formatTokenCount(int(e.PreTokens))+" tokens")
+		}
+		if len(details) > 0 {
+			return fmt.Sprintf("context compacted here (%s)", strings.Join(details, ", "))
+		}
+		return "context compacted here"
+	case EventCommand:
+		if strings.HasPrefix(e.Subtype, "local_command_") {
+			if first == "" {
+				return "command output"
+			}
+			return "command output: " + first
+		}
+		return first
+	case EventHook:
+		return "hook: " + first
+	}
+	return first
+}
+
+// Details returns the event text after its first line, shown when the marker is expanded
+func (e *SystemEvent) Details() string {
+	_, rest, _ := strings.Cut(strings.TrimSpace(e.Text), "\n")
+	return strings.TrimSpace(rest)
+}
+
+// Compactions returns the number of times the session's context was compacted
+func (s *Session) Compactions() int {
+	if s.Meta.Streamed {
+		return s.Meta.Compactions
+	}
+	n := 0
+	for _, msg := range s.Messages {
+		if msg.Event != nil && msg.Event.Kind == EventCompact {
+			n++
+		}
+	}
+	return n
+}
+
+// MessageCount returns the number of conversation messages, excluding system events
+func (s *Session) MessageCount() int {
+	if s.Meta.Streamed {
+		return s.Meta.MessageCount
+	}
+	n := 0
+	for _, msg := range s.Messages {
+		if msg.Event == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// rawString decodes a JSON string, returning "" for other values
+func rawString(raw json.RawMessage) string {
+	var s string
+	if len(raw) == 0 || json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -20,6 +19,16 @@ type Frontmatter struct {
 	Project    string `yaml:"project"`     // Actual project path (from CWD)
 	Title      string `yaml:"title"`       // Session summary
 	Created    string `yaml:"created"`     // ISO 8601 timestamp
+
+	// Claude Code environment, omitted when the transcript doesn't record it
+	GitBranch      string   `yaml:"git_branch,omitempty"`      // Last checked-out branch
+	GitBranches    []string `yaml:"git_branches,omitempty"`    // All branches, when the session switched
+	Version        string   `yaml:"version,omitempty"`         // Claude Code version
+	PermissionMode string   `yaml:"permission_mode,omitempty"` // Last permission mode
+	UserType       string   `yaml:"user_type,omitempty"`
+
+	// Set by the archive orphan policy once the JSONL is gone
+	SourceMissing bool `yaml:"source_missing,omitempty"`
 }
 
 // Marshal serializes the frontmatter to YAML with delimiters
@@ -79,9 +88,10 @@ func ParseFrontmatter(content []byte) (Frontmatter, []byte, error) {
 	return fm, remaining, nil
 }
 
-// ComputeFileHash computes the SHA256 hash of a file
+// ComputeFileHash computes the SHA256 hash of a file, or of the session file an archived
+// one holds
 func ComputeFileHash(filePath string) (string, error) {
-	f, err := os.Open(filePath)
+	f, err := openSessionFile(filePath, 0)
 	if err != nil {
 		return "", fmt.Errorf("opening file for hash: %w", err)
 	}
@@ -97,11 +107,31 @@ func ComputeFileHash(filePath string) (string, error) {
 
 // NewFrontmatter creates a new Frontmatter from a Session
 func NewFrontmatter(session *Session, sourceHash string) Frontmatter {
-	return Frontmatter{
-		Source:     filepath.Base(session.SourcePath),
+	fm := Frontmatter{
+		Source:     sourceFileName(session),
 		SourceHash: sourceHash,
 		Project:    session.CWD,
 		Title:      session.Summary,
 		Created:    session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+
+		GitBranch:      session.GitBranch,
+		Version:        session.Version,
+		PermissionMode: session.PermissionMode,
+		UserType:       session.UserType,
+
+		SourceMissing: session.Archived,
+	}
+	if len(session.GitBranches) > 1 {
+		fm.GitBranches = session.GitBranches
+	}
+	return fm
+}
+
+// sourceFileName returns the name of a session's file, also when the session was loaded
+// from its archived copy
+func sourceFileName(session *Session) string {
+	if strings.HasSuffix(session.SourcePath, ".gz") {
+		return session.ID + ".jsonl"
 	}
+	return filepath.Base(session.SourcePath)
 }
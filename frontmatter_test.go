@@ -158,6 +158,39 @@ func TestNewFrontmatter(t *testing.T) {
 	}
 }
 
+func TestNewFrontmatter_Environment(t *testing.T) {
+	session := &Session{
+		SourcePath:     "/path/to/session.jsonl",
+		GitBranch:      "feature/x",
+		GitBranches:    []string{"main", "feature/x"},
+		Version:        "1.0.81",
+		PermissionMode: "acceptEdits",
+		UserType:       "external",
+	}
+
+	out, err := NewFrontmatter(session, "hash").Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	for _, part := range []string{"git_branch: feature/x", "git_branches:", "version: 1.0.81", "permission_mode: acceptEdits", "user_type: external"} {
+		if !contains(string(out), part) {
+			t.Errorf("Expected frontmatter to contain %q, got:\n%s", part, out)
+		}
+	}
+
+	// Sessions on a single branch and transcripts without metadata stay compact
+	out, err = NewFrontmatter(&Session{SourcePath: "s.jsonl", GitBranches: []string{"main"}, GitBranch: "main"}, "hash").Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, part := range []string{"git_branches:", "version:", "permission_mode:", "user_type:"} {
+		if contains(string(out), part) {
+			t.Errorf("Expected frontmatter to omit %q, got:\n%s", part, out)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
@@ -33,6 +33,7 @@ func NewGenerator(outputDir string, projects []Project) (*Generator, error) {
 	funcMap := template.FuncMap{
 		"ProjectSlug": ProjectSlug,
 		"RenderText":  RenderText,
+		"ToolLabel":   toolLabel,
 	}
 
 	// Parse templates
@@ -92,11 +93,15 @@ func (g *Generator) CopyLogo() error {
 // GenerateIndex generates the main index.html
 func (g *Generator) GenerateIndex() error {
 	data := struct {
-		Projects    []Project
-		ProjectSlug func(string) string
+		Projects     []Project
+		ProjectSlug  func(string) string
+		MultiSource  bool
+		ProblemFiles int
 	}{
-		Projects:    g.projects,
-		ProjectSlug: ProjectSlug,
+		Projects:     g.projects,
+		ProjectSlug:  ProjectSlug,
+		MultiSource:  multipleSources(g.projects),
+		ProblemFiles: len(CollectDiagnostics(g.projects).ProblemFiles),
 	}
 
 	outputPath := filepath.Join(g.outputDir, "index.html")
@@ -106,7 +111,7 @@ func (g *Generator) GenerateIndex() error {
 // GenerateProjectPages generates the project index and all session pages
 func (g *Generator) GenerateProjectPages(project *Project) error {
 	// Create project directory
-	projectDir := filepath.Join(g.outputDir, ProjectSlug(project.Path))
+	projectDir := filepath.Join(g.outputDir, project.Slug())
 	if err := os.MkdirAll(projectDir, 0755); err != nil {
 		return fmt.Errorf("creating project directory: %w", err)
 	}
@@ -130,36 +135,47 @@ func (g *Generator) GenerateProjectPages(project *Project) error {
 // GenerateProjectIndex generates the project's index.html
 func (g *Generator) GenerateProjectIndex(project *Project) error {
 	data := struct {
-		Project     *Project
-		AllProjects []Project
-		ProjectSlug func(string) string
+		Project       *Project
+		AllProjects   []Project
+		Conversations []Conversation
+		ProjectSlug   func(string) string
+		MultiSource   bool
 	}{
-		Project:     project,
-		AllProjects: g.projects,
-		ProjectSlug: ProjectSlug,
+		Project:       project,
+		AllProjects:   g.projects,
+		Conversations: GroupConversations(project.Sessions),
+		ProjectSlug:   ProjectSlug,
+		MultiSource:   multipleSources(g.projects),
 	}
 
-	outputPath := filepath.Join(g.outputDir, ProjectSlug(project.Path), "index.html")
+	outputPath := filepath.Join(g.outputDir, project.Slug(), "index.html")
 	return g.writeTemplate(g.projectTmpl, data, outputPath)
 }
 
 // GenerateSession generates a session HTML file
 func (g *Generator) GenerateSession(session *Session, project *Project) error {
+	session, err := LoadSessionMessages(session)
+	if err != nil {
+		return fmt.Errorf("loading messages: %w", err)
+	}
+
 	data := struct {
 		Session     *Session
+		ToolCalls   toolCallLookup
 		Project     *Project
 		AllProjects []Project
 		ProjectSlug func(string) string
 		RenderText  func(string) template.HTML
 	}{
 		Session:     session,
+		ToolCalls:   toolCallIndex(session.ToolCalls),
 		Project:     project,
 		AllProjects: g.projects,
 		ProjectSlug: ProjectSlug,
 		RenderText:  RenderText,
 	}
 
-	outputPath := filepath.Join(g.outputDir, ProjectSlug(project.Path), session.ID+".html")
+	outputPath := filepath.Join(g.outputDir, project.Slug(), session.ID+".html")
 	return g.writeTemplate(g.sessionTmpl, data, outputPath)
 }
 
@@ -200,7 +216,8 @@ func (g *Generator) writeTemplate(tmpl *template.Template, data interface{}, out
 	return nil
 }
 
-// ProjectSlug converts a project path to a URL-safe slug
+// ProjectSlug converts a project path to a URL-safe slug. Paths can share a slug, so
+// loaded projects are named by Project.Slug, which disambiguates them.
 // Example: "/Users/john/project" -> "users-john-project"
 func ProjectSlug(path string) string {
 	if path == "" {
@@ -156,6 +156,43 @@ func TestNewGenerator(t *testing.T) {
 	}
 }
 
+func TestGenerateSessionJoinsToolCalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	messages := []Message{
+		{UUID: "a1", Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ToolUseID: "t1", ToolName: "Bash", ToolInput: `{"command":"ls"}`}}},
+		{UUID: "u1", ParentUUID: "a1", Role: "user", Content: []ContentBlock{{Type: "tool_result", ToolUseID: "t1", ToolOutput: "main.go"}}},
+		{UUID: "u2", ParentUUID: "u1", Role: "user", Content: []ContentBlock{{Type: "tool_result", ToolUseID: "unknown", ToolOutput: "stray"}}},
+	}
+	session := &Session{ID: "s1", Summary: "Tools", Messages: messages, ToolCalls: joinToolCalls(messages)}
+	project := &Project{Path: "/test/project", FolderName: "-test-project", Sessions: []Session{*session}}
+
+	gen, err := NewGenerator(tmpDir, []Project{*project})
+	if err != nil {
+		t.Fatalf("NewGenerator failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, ProjectSlug(project.Path)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.GenerateSession(session, project); err != nil {
+		t.Fatalf("GenerateSession failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, ProjectSlug(project.Path), "s1.html"))
+	if err != nil {
+		t.Fatalf("Failed to read session HTML: %v", err)
+	}
+	html := string(content)
+	if !strings.Contains(html, "Result:\nmain.go") {
+		t.Error("Expected the result shown with its call")
+	}
+	if strings.Contains(html, "result-1-0") {
+		t.Error("Expected the message holding only the joined result to be skipped")
+	}
+	if !strings.Contains(html, "result-2-0") {
+		t.Error("Expected the result without a call to be shown on its own")
+	}
+}
+
 func TestGenerateAll(t *testing.T) {
 	// Create temp directory for output
 	tmpDir, err := os.MkdirTemp("", "generator-test-*")
@@ -415,6 +452,7 @@ func TestTemplatesAreValid(t *testing.T) {
 	funcMap := template.FuncMap{
 		"ProjectSlug": ProjectSlug,
 		"RenderText":  RenderText,
+		"ToolLabel":   toolLabel,
 	}
 
 	templates := map[string]string{
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LoadOptions controls how session files are parsed when projects are loaded
+type LoadOptions struct {
+	Jobs     int         // Session files parsed at once (0 = number of CPUs)
+	Progress io.Writer   // Where to draw a progress line while loading (nil = none)
+	Cache    *ParseCache // Reuses metadata of unchanged files and resumes appended ones when loading without messages (nil = none)
+	Strict   bool        // Fail when a session file has parse problems instead of reporting them
+	Archive  *Archive    // Loads sessions whose file is gone from their archived version (nil = none)
+}
+
+// workers returns the number of files to parse at once
+func (o LoadOptions) workers() int {
+	if o.Jobs > 0 {
+		return o.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// sessionFile is a session or subagent transcript file found in a project directory
+type sessionFile struct {
+	Path     string
+	ID       string
+	ParentID string // Session owning the subagents/ directory holding the file, "" for top-level files
+	Archived bool   // An archived copy of a file that is gone
+}
+
+// parsedFile is the result of loading a sessionFile
+type parsedFile struct {
+	Session *Session
+	Err     error
+}
+
+// parseSessionFiles loads files with up to opts.Jobs workers. Results are returned in
+// the order of files, whatever order the workers finish in.
+func parseSessionFiles(files []sessionFile, load sessionLoader, opts LoadOptions) []parsedFile {
+	results := make([]parsedFile, len(files))
+	if len(files) == 0 {
+		return results
+	}
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.workers(), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				session, err := load(files[i].Path, files[i].ID)
+				results[i] = parsedFile{Session: session, Err: err}
+				done <- struct{}{}
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	bar := newProgress(opts.Progress, len(files))
+	for range done {
+		bar.step()
+	}
+	bar.finish()
+
+	return results
+}
+
+// progress draws a "Loading sessions: n/total" line, rewritten in place
+type progress struct {
+	w     io.Writer
+	total int
+	count int
+	drawn time.Time
+}
+
+// progressInterval limits how often the progress line is redrawn
+const progressInterval = 100 * time.Millisecond
+
+func newProgress(w io.Writer, total int) *progress {
+	return &progress{w: w, total: total}
+}
+
+// step counts one more file and redraws the line if it is due
+func (p *progress) step() {
+	p.count++
+	if p.w == nil || (p.count < p.total && time.Since(p.drawn) < progressInterval) {
+		return
+	}
+	p.drawn = time.Now()
+	fmt.Fprintf(p.w, "\rLoading sessions: %d/%d", p.count, p.total)
+}
+
+// finish clears the progress line so later output starts on an empty line
+func (p *progress) finish() {
+	if p.w != nil && !p.drawn.IsZero() {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+}
+
+// terminal returns f if it is a terminal, so progress is only drawn for people watching
+func terminal(f *os.File) io.Writer {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return f
+}
+
+// printLoadWarnings reports the problems met while loading, once loading is over so they
+// aren't interleaved with the progress line or with each other
+func printLoadWarnings(warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %d problem(s) while loading sessions:\n", len(warnings))
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "  - %s\n", w)
+	}
+}
@@ -2,37 +2,63 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
 
 // MarkdownGenerator handles Markdown file generation from parsed session data
 type MarkdownGenerator struct {
-	outputDir string
-	sourceDir string
-	force     bool
+	outputDir  string
+	sourceDir  string
+	sourceDirs []string // Every source, to tell a removed project from one not loaded
+	force      bool
+	pricing    *PriceTable  // Prices for the subagent cost totals
+	cache      *ParseCache  // Parses of growing session files to resume (nil = none)
+	orphans    OrphanPolicy // What to do with the Markdown of removed sessions ("" = keep)
 }
 
 // GenerationResult contains statistics about the generation process
 type GenerationResult struct {
 	Generated int
 	Skipped   int
+	Orphans   int // Markdown files of removed sessions kept, archived or deleted
 	Errors    []error
+
+	// Projects whose folder is gone from every source, holding the sessions kept by the
+	// keep orphan policy
+	ArchivedProjects []Project
 }
 
 // NewMarkdownGenerator creates a new MarkdownGenerator instance
 func NewMarkdownGenerator(outputDir, sourceDir string, force bool) *MarkdownGenerator {
 	return &MarkdownGenerator{
-		outputDir: outputDir,
-		sourceDir: sourceDir,
-		force:     force,
+		outputDir:  outputDir,
+		sourceDir:  sourceDir,
+		sourceDirs: []string{sourceDir},
+		force:      force,
+		pricing:    DefaultPriceTable(),
 	}
 }
 
 // GenerateAll generates Markdown files for all projects and sessions
 func (g *MarkdownGenerator) GenerateAll(projects []Project) (*GenerationResult, error) {
+	return g.generate(projects, func(*Project) bool { return true })
+}
+
+// GenerateProject generates the Markdown files of the projects in folder (one per source
+// holding it) and the main index listing all projects, leaving the other projects' files
+// as they are
+func (g *MarkdownGenerator) GenerateProject(projects []Project, folder string) (*GenerationResult, error) {
+	return g.generate(projects, func(p *Project) bool { return p.FolderName == folder })
+}
+
+// generate generates the session files and index of the projects include accepts, and
+// the main index
+func (g *MarkdownGenerator) generate(projects []Project, include func(*Project) bool) (*GenerationResult, error) {
 	result := &GenerationResult{}
 
 	// Create output directory
@@ -43,7 +69,10 @@ func (g *MarkdownGenerator) GenerateAll(projects []Project) (*GenerationResult,
 	// Generate session files for each project
 	for i := range projects {
 		project := &projects[i]
-		projectSlug := ProjectSlug(project.Path)
+		if !include(project) {
+			continue
+		}
+		projectSlug := project.Slug()
 		projectDir := filepath.Join(g.outputDir, projectSlug)
 
 		// Create project directory
@@ -52,13 +81,19 @@ func (g *MarkdownGenerator) GenerateAll(projects []Project) (*GenerationResult,
 			continue
 		}
 
+		// Sources of each session, so lineage links are refreshed when a session is resumed
+		sourcesByID := make(map[string]string, len(project.Sessions))
+		for _, s := range project.Sessions {
+			sourcesByID[s.ID] = s.SourcePath
+		}
+
 		// Generate session files
 		for j := range project.Sessions {
 			session := &project.Sessions[j]
 			mdPath := filepath.Join(projectDir, session.ID+".md")
 
 			// Check if regeneration is needed
-			if !g.ShouldRegenerate(session.SourcePath, mdPath) {
+			if !g.sessionNeedsRegeneration(session, mdPath, sourcesByID) {
 				result.Skipped++
 				continue
 			}
@@ -71,22 +106,41 @@ func (g *MarkdownGenerator) GenerateAll(projects []Project) (*GenerationResult,
 			result.Generated++
 		}
 
+		g.handleOrphans(project, projectSlug, result)
+
 		// Generate project index (MD)
 		if err := g.GenerateProjectIndex(project, projectSlug); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("project index %s: %w", projectSlug, err))
 		}
 	}
 
+	// Output directories of projects that are gone
+	result.ArchivedProjects = g.handleOrphanedProjects(projects, result)
+
 	// Generate main index (MD)
-	if err := g.GenerateMainIndex(projects); err != nil {
+	if err := g.GenerateMainIndex(result.withArchivedProjects(projects)); err != nil {
 		result.Errors = append(result.Errors, fmt.Errorf("main index: %w", err))
 	}
 
 	return result, nil
 }
 
+// withArchivedProjects returns the projects followed by the archived projects found
+// while generating them
+func (r *GenerationResult) withArchivedProjects(projects []Project) []Project {
+	if len(r.ArchivedProjects) == 0 {
+		return projects
+	}
+	return append(slices.Clip(projects), r.ArchivedProjects...)
+}
+
 // GenerateSession generates a Markdown file for a single session
 func (g *MarkdownGenerator) GenerateSession(session *Session, projectSlug string) error {
+	session, err := g.cache.LoadSessionMessages(session)
+	if err != nil {
+		return fmt.Errorf("loading messages: %w", err)
+	}
+
 	// Compute source hash
 	sourceHash, err := ComputeFileHash(session.SourcePath)
 	if err != nil {
@@ -101,15 +155,76 @@ func (g *MarkdownGenerator) GenerateSession(session *Session, projectSlug string
 		return fmt.Errorf("marshaling frontmatter: %w", err)
 	}
 
+	// Write pasted images and documents referenced by the markdown
+	if err := g.writeAssets(session, projectSlug); err != nil {
+		return err
+	}
+
 	// Build markdown content
 	var content strings.Builder
 	content.Write(fmBytes)
 	content.WriteString("\n")
 
-	// Format messages
-	for _, msg := range session.Messages {
-		content.WriteString(g.formatMessage(&msg))
-		content.WriteString("\n")
+	// Navigation between sessions of a resumed conversation
+	if session.PredecessorID != "" {
+		content.WriteString(fmt.Sprintf("<div class=\"session-nav\">\n\nContinued from [session %s](%s.md)\n\n</div>\n\n",
+			shortID(session.PredecessorID), session.PredecessorID))
+	}
+
+	// Subagents are rendered after the message holding the Task call that spawned them
+	subagentsByToolUse := make(map[string][]*Session)
+	var unlinked []*Session
+	for i := range session.Subagents {
+		sub := &session.Subagents[i]
+		if sub.ParentToolUseID == "" {
+			unlinked = append(unlinked, sub)
+			continue
+		}
+		subagentsByToolUse[sub.ParentToolUseID] = append(subagentsByToolUse[sub.ParentToolUseID], sub)
+	}
+
+	// Format the main conversation path; alternate branches follow in their own sections
+	tree := BuildConversationTree(session)
+	calls := toolCallIndex(session.ToolCalls)
+	content.WriteString(g.formatPath(tree.Main, tree, subagentsByToolUse, calls))
+
+	if len(session.SuccessorIDs) > 0 {
+		links := make([]string, len(session.SuccessorIDs))
+		for i, id := range session.SuccessorIDs {
+			links[i] = fmt.Sprintf("[session %s](%s.md)", shortID(id), id)
+		}
+		content.WriteString(fmt.Sprintf("<div class=\"session-nav\">\n\nContinued in %s\n\n</div>\n\n", strings.Join(links, ", ")))
+	}
+
+	if len(unlinked) > 0 {
+		content.WriteString("## Subagents\n\n")
+		for _, sub := range unlinked {
+			content.WriteString(g.formatSubagent(sub))
+		}
+	}
+
+	if len(tree.Branches) > 0 {
+		byUUID := make(map[string]Message, len(session.Messages))
+		for _, msg := range session.Messages {
+			byUUID[msg.UUID] = msg
+		}
+
+		content.WriteString("<h2 class=\"branches-heading\">Branches</h2>\n\n")
+		content.WriteString("<div class=\"branches\">\n\n")
+		for _, branch := range tree.Branches {
+			content.WriteString(fmt.Sprintf("<div class=\"branch\" id=\"branch-%d\" data-fork=\"%s\">\n\n",
+				branch.Number, html.EscapeString(branch.ForkUUID)))
+			content.WriteString(fmt.Sprintf("### Branch %d\n\n", branch.Number))
+			fork := byUUID[branch.ForkUUID]
+			if excerpt := messageExcerpt(fork, 80); excerpt != "" {
+				content.WriteString(fmt.Sprintf("> Forked after the %s message: \"%s\"\n\n", fork.Role, excerpt))
+			} else {
+				content.WriteString(fmt.Sprintf("> Forked after a %s message\n\n", fork.Role))
+			}
+			content.WriteString(g.formatPath(branch.Messages, tree, subagentsByToolUse, calls))
+			content.WriteString("</div>\n\n")
+		}
+		content.WriteString("</div>\n")
 	}
 
 	// Write MD file
@@ -127,7 +242,7 @@ func (g *MarkdownGenerator) GenerateMainIndex(projects []Project) error {
 
 	// Sort projects by last update (most recent first) - already sorted by parser
 	for _, project := range projects {
-		projectSlug := ProjectSlug(project.Path)
+		projectSlug := project.Slug()
 		sessionCount := len(project.Sessions)
 
 		lastActivity := ""
@@ -175,10 +290,51 @@ func (g *MarkdownGenerator) GenerateProjectIndex(project *Project, projectSlug s
 		))
 	}
 
+	// Sessions continued with --resume/--continue, oldest first
+	var resumed []Conversation
+	for _, c := range GroupConversations(project.Sessions) {
+		if len(c.Sessions) > 1 {
+			resumed = append(resumed, c)
+		}
+	}
+	if len(resumed) > 0 {
+		content.WriteString("\n## Conversations\n\n")
+		for _, c := range resumed {
+			links := make([]string, len(c.Sessions))
+			for i, s := range c.Sessions {
+				links[i] = fmt.Sprintf("[%s](%s.md)", s.ID, s.ID)
+			}
+			content.WriteString(fmt.Sprintf("- %s: %s\n", escapeMarkdownTableCell(c.Sessions[0].Summary), strings.Join(links, " → ")))
+		}
+	}
+
+	// Sessions whose file is gone, kept by the archive orphan policy
+	if len(project.ArchivedSessions) > 0 {
+		content.WriteString("\n## Archived\n\n")
+		content.WriteString("| Session | Title | Created |\n")
+		content.WriteString("|---------|-------|--------|\n")
+		for _, session := range project.ArchivedSessions {
+			content.WriteString(fmt.Sprintf("| [%s](%s.md) | %s | %s |\n",
+				session.ID,
+				session.ID,
+				escapeMarkdownTableCell(session.Summary),
+				session.CreatedAt.Format("2006-01-02"),
+			))
+		}
+	}
+
 	outputPath := filepath.Join(g.outputDir, projectSlug, "index.md")
 	return g.writeFile(outputPath, []byte(content.String()))
 }
 
+// shortID returns the first 8 characters of a session ID
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
 // ShouldRegenerate determines if a session needs regeneration based on mtime
 func (g *MarkdownGenerator) ShouldRegenerate(jsonlPath, mdPath string) bool {
 	if g.force {
@@ -202,38 +358,232 @@ func (g *MarkdownGenerator) ShouldRegenerate(jsonlPath, mdPath string) bool {
 	return jsonlInfo.ModTime().After(mdInfo.ModTime())
 }
 
+// sessionNeedsRegeneration checks the session, its subagent transcripts and the
+// sessions it links to as predecessor or successor
+func (g *MarkdownGenerator) sessionNeedsRegeneration(session *Session, mdPath string, sourcesByID map[string]string) bool {
+	sources := []string{session.SourcePath}
+	for _, sub := range session.Subagents {
+		sources = append(sources, sub.SourcePath)
+	}
+	for _, id := range append([]string{session.PredecessorID}, session.SuccessorIDs...) {
+		if src, ok := sourcesByID[id]; ok {
+			sources = append(sources, src)
+		}
+	}
+
+	for _, src := range sources {
+		if g.ShouldRegenerate(src, mdPath) {
+			return true
+		}
+	}
+
+	// A session now loaded from the archive is marked source_missing
+	return session.Archived && !markedSourceMissing(mdPath)
+}
+
+// markedSourceMissing reports whether the frontmatter of a session's Markdown has
+// source_missing set
+func markedSourceMissing(mdPath string) bool {
+	content, err := os.ReadFile(mdPath)
+	if err != nil {
+		return false
+	}
+	fm, _, err := ParseFrontmatter(content)
+	return err == nil && fm.SourceMissing
+}
+
 // formatMessage formats a single message as Markdown
-func (g *MarkdownGenerator) formatMessage(msg *Message) string {
+func (g *MarkdownGenerator) formatMessage(msg *Message, calls map[string]*ToolCall) string {
 	var content strings.Builder
 
 	// Role header (capitalize first letter)
 	role := capitalizeFirst(msg.Role)
 	content.WriteString(fmt.Sprintf("## %s\n\n", role))
+	content.WriteString(g.formatBlocks(msg.Content, calls))
+
+	return content.String()
+}
+
+// onlyJoinedResults reports whether a message carries nothing but tool results that are
+// already shown with their call, so it needs no section of its own
+func onlyJoinedResults(msg *Message, calls map[string]*ToolCall) bool {
+	if len(msg.Content) == 0 {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.Type != "tool_result" {
+			return false
+		}
+		if call := calls[block.ToolUseID]; call == nil || !call.HasResult {
+			return false
+		}
+	}
+	return true
+}
+
+// formatEvent formats a system event as an inline marker between messages.
+// Events with more than one line of text expand to show the rest.
+func formatEvent(event *SystemEvent) string {
+	class := "system-event " + event.Kind
+	if event.Level == "warning" || event.Level == "error" {
+		class += " " + event.Level
+	}
+	summary := html.EscapeString(event.Summary())
+	if details := event.Details(); details != "" {
+		return fmt.Sprintf("<details class=\"%s\"><summary>%s</summary>\n\n<pre>%s</pre>\n\n</details>\n\n",
+			class, summary, html.EscapeString(details))
+	}
+	return fmt.Sprintf("<div class=\"%s\">— %s —</div>\n\n", class, summary)
+}
+
+// formatPath formats the messages of one conversation path, with the subagents spawned
+// by its Task calls and a fork marker after each message where branches diverge
+func (g *MarkdownGenerator) formatPath(messages []Message, tree *ConversationTree, subagentsByToolUse map[string][]*Session, calls map[string]*ToolCall) string {
+	var content strings.Builder
+
+	for _, msg := range messages {
+		switch {
+		case msg.Event != nil:
+			content.WriteString(formatEvent(msg.Event))
+		case onlyJoinedResults(&msg, calls):
+			// Shown with the calls they answer
+		default:
+			content.WriteString(g.formatMessage(&msg, calls))
+		}
+		for _, block := range msg.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+			for _, sub := range subagentsByToolUse[block.ToolUseID] {
+				content.WriteString(g.formatSubagent(sub))
+			}
+		}
+		if branches := tree.Forks[msg.UUID]; msg.UUID != "" && len(branches) > 0 {
+			ids := make([]string, len(branches))
+			for i, b := range branches {
+				ids[i] = fmt.Sprintf("branch-%d", tree.Branches[b].Number)
+			}
+			content.WriteString(fmt.Sprintf("<div class=\"fork-point\" data-fork=\"%s\" data-branches=\"%s\"></div>\n\n",
+				html.EscapeString(msg.UUID), strings.Join(ids, " ")))
+		}
+		content.WriteString("\n")
+	}
 
-	// Format content blocks
+	return content.String()
+}
+
+// messageExcerpt returns the start of a message's text on a single line
+func messageExcerpt(msg Message, maxLen int) string {
 	for _, block := range msg.Content {
+		if block.Type != "text" || block.Text == "" {
+			continue
+		}
+		text := strings.Join(strings.Fields(block.Text), " ")
+		if len(text) > maxLen {
+			text = text[:maxLen-3] + "..."
+		}
+		return text
+	}
+	return ""
+}
+
+// formatSubagent formats a subagent transcript as a collapsible section with its own totals.
+// Roles use level 4 headings so the session view keeps them inside the parent message.
+func (g *MarkdownGenerator) formatSubagent(sub *Session) string {
+	var content strings.Builder
+
+	tokens, cost := SessionTotals(*sub, g.pricing)
+	title := sub.Summary
+	if title == "" {
+		title = sub.ID
+	}
+	content.WriteString("<details class=\"subagent\">\n")
+	content.WriteString(fmt.Sprintf("<summary>Subagent: %s (%d messages · %s tokens · $%.2f)</summary>\n\n",
+		html.EscapeString(title), sub.MessageCount(), formatTokenCount(tokens), cost))
+
+	calls := toolCallIndex(sub.ToolCalls)
+	for _, msg := range sub.Messages {
+		if msg.Event != nil {
+			content.WriteString(formatEvent(msg.Event))
+			continue
+		}
+		if onlyJoinedResults(&msg, calls) {
+			continue
+		}
+		content.WriteString(fmt.Sprintf("#### %s\n\n", capitalizeFirst(msg.Role)))
+		content.WriteString(g.formatBlocks(msg.Content, calls))
+	}
+
+	content.WriteString("</details>\n\n")
+	return content.String()
+}
+
+// formatBlocks formats the content blocks of a message as Markdown. Tool calls found in
+// calls are rendered together with their result, which is then skipped where it appears.
+func (g *MarkdownGenerator) formatBlocks(blocks []ContentBlock, calls map[string]*ToolCall) string {
+	var content strings.Builder
+
+	for _, block := range blocks {
 		switch block.Type {
 		case "text":
 			content.WriteString(block.Text)
 			content.WriteString("\n\n")
 
+		case "thinking":
+			if block.Text == "" {
+				continue
+			}
+			content.WriteString("<details class=\"thinking\">\n<summary>Thinking</summary>\n\n")
+			content.WriteString(block.Text)
+			content.WriteString("\n\n</details>\n\n")
+
+		case "redacted_thinking":
+			content.WriteString("<details class=\"thinking\">\n<summary>Thinking (redacted)</summary>\n\n")
+			content.WriteString("_This reasoning was encrypted by the API and cannot be shown._\n\n")
+			content.WriteString("</details>\n\n")
+
+		case "image":
+			switch {
+			case hasAssetData(block):
+				content.WriteString(fmt.Sprintf("![Image](%s)\n\n", assetLink(block)))
+			case block.SourceURL != "":
+				content.WriteString(fmt.Sprintf("![Image](%s)\n\n", block.SourceURL))
+			}
+
+		case "document":
+			title := block.Title
+			if title == "" {
+				title = fmt.Sprintf("Document (%s)", block.MediaType)
+			}
+			title = escapeMarkdownLinkText(title)
+			switch {
+			case hasAssetData(block):
+				content.WriteString(fmt.Sprintf("[%s](%s)\n\n", title, assetLink(block)))
+			case block.SourceURL != "":
+				content.WriteString(fmt.Sprintf("[%s](%s)\n\n", title, block.SourceURL))
+			}
+
 		case "tool_use":
-			content.WriteString(fmt.Sprintf("<details>\n<summary>Tool: %s</summary>\n\n", block.ToolName))
-			content.WriteString("```json\n")
-			content.WriteString(block.ToolInput)
-			content.WriteString("\n```\n\n")
+			if call := calls[block.ToolUseID]; call != nil && call.HasResult {
+				content.WriteString(g.formatToolCall(call))
+				continue
+			}
+			view := renderToolInput(block.ToolName, block.ToolInput)
+			content.WriteString(fmt.Sprintf("<details>\n<summary>%s</summary>\n\n",
+				html.EscapeString(toolSummary(block.ToolName, view.Label))))
+			content.WriteString(view.Body)
 			content.WriteString("</details>\n\n")
 
 		case "tool_result":
-			content.WriteString("<details>\n<summary>Result</summary>\n\n")
-			content.WriteString("```\n")
-			// Truncate very long tool outputs
-			output := block.ToolOutput
-			if len(output) > 10000 {
-				output = output[:10000] + "\n... (truncated)"
+			if call := calls[block.ToolUseID]; call != nil && call.HasResult {
+				continue
+			}
+			if block.IsError {
+				content.WriteString("<details class=\"tool-error\">\n<summary>Result (error)</summary>\n\n")
+			} else {
+				content.WriteString("<details>\n<summary>Result</summary>\n\n")
 			}
-			content.WriteString(output)
-			content.WriteString("\n```\n\n")
+			content.WriteString(g.formatToolOutput(block.ToolOutput, block.Parts))
 			content.WriteString("</details>\n\n")
 		}
 	}
@@ -241,6 +591,58 @@ func (g *MarkdownGenerator) formatMessage(msg *Message) string {
 	return content.String()
 }
 
+// formatToolCall formats a tool call and its result as one collapsible unit
+func (g *MarkdownGenerator) formatToolCall(call *ToolCall) string {
+	var content strings.Builder
+
+	view := renderToolInput(call.Name, call.Input)
+	class := "tool-call"
+	summary := toolSummary(call.Name, view.Label)
+	if d := call.DurationLabel(); d != "" {
+		summary += " · " + d
+	}
+	if call.IsError {
+		class += " tool-error"
+		summary += " · failed"
+	}
+	content.WriteString(fmt.Sprintf("<details class=\"%s\">\n<summary>%s</summary>\n\n", class, html.EscapeString(summary)))
+	content.WriteString(view.Body)
+	if call.IsError {
+		content.WriteString("**Result (error)**\n\n")
+	} else {
+		content.WriteString("**Result**\n\n")
+	}
+	content.WriteString(g.formatToolOutput(call.Output, call.Parts))
+	content.WriteString("</details>\n\n")
+
+	return content.String()
+}
+
+// toolSummary returns the collapsed title of a tool call, e.g. "Tool: Read · main.go"
+func toolSummary(name, label string) string {
+	if label == "" {
+		return "Tool: " + name
+	}
+	return "Tool: " + name + " · " + label
+}
+
+// formatToolOutput formats the text of a tool result as a code block followed by
+// any images or documents it returned
+func (g *MarkdownGenerator) formatToolOutput(output string, parts []ContentBlock) string {
+	var content strings.Builder
+
+	content.WriteString("```\n")
+	// Truncate very long tool outputs
+	if len(output) > 10000 {
+		output = output[:10000] + "\n... (truncated)"
+	}
+	content.WriteString(output)
+	content.WriteString("\n```\n\n")
+	content.WriteString(g.formatBlocks(parts, nil))
+
+	return content.String()
+}
+
 // writeFile writes content to a file using atomic write
 func (g *MarkdownGenerator) writeFile(outputPath string, content []byte) error {
 	dir := filepath.Dir(outputPath)
@@ -286,6 +688,21 @@ func escapeMarkdownTableCell(s string) string {
 	return s
 }
 
+// escapeMarkdownLinkText escapes characters that would end or break the text of a
+// markdown link
+func escapeMarkdownLinkText(s string) string {
+	return markdownLinkTextEscaper.Replace(s)
+}
+
+var markdownLinkTextEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"[", "\\[",
+	"]", "\\]",
+	"(", "\\(",
+	")", "\\)",
+	"\n", " ",
+)
+
 // capitalizeFirst capitalizes the first letter of a string
 func capitalizeFirst(s string) string {
 	if s == "" {
@@ -294,8 +711,33 @@ func capitalizeFirst(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
 }
 
-// GenerateAllMarkdown is the main entry point for generating all Markdown files
-func GenerateAllMarkdown(projects []Project, outputDir, sourceDir string, force bool) (*GenerationResult, error) {
-	gen := NewMarkdownGenerator(outputDir, sourceDir, force)
+// formatTokenCount formats a token count compactly: 950, 12.3k, 1.2M
+func formatTokenCount(tokens int) string {
+	switch {
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+}
+
+// GenerateAllMarkdown is the main entry point for generating all Markdown files.
+// pricing prices the subagent totals; nil uses the built-in prices. With a cache, the
+// sessions that grew since they were last generated are parsed from their new lines.
+// orphans is applied to the Markdown of sessions whose file is gone, and to the output
+// directories of projects gone from every source.
+func GenerateAllMarkdown(projects []Project, outputDir string, sources []Source, force bool, pricing *PriceTable, cache *ParseCache, orphans OrphanPolicy) (*GenerationResult, error) {
+	gen := NewMarkdownGenerator(outputDir, "", force)
+	if len(sources) > 0 {
+		gen.sourceDir = sources[0].Path
+	}
+	gen.sourceDirs = sourcePaths(sources)
+	if pricing != nil {
+		gen.pricing = pricing
+	}
+	gen.cache = cache
+	gen.orphans = orphans
 	return gen.GenerateAll(projects)
 }
@@ -368,9 +368,7 @@ func TestToolCallFormatting(t *testing.T) {
 	// Check tool call formatting
 	expectedParts := []string{
 		"<details>",
-		"<summary>Tool: Read</summary>",
-		"```json",
-		`{"file_path":"/test/file.txt"}`,
+		"<summary>Tool: Read · /test/file.txt</summary>",
 		"</details>",
 		"<summary>Result</summary>",
 		"File contents here",
@@ -382,3 +380,646 @@ func TestToolCallFormatting(t *testing.T) {
 		}
 	}
 }
+
+func TestThinkingFormatting(t *testing.T) {
+	gen := NewMarkdownGenerator(t.TempDir(), t.TempDir(), false)
+
+	msg := &Message{
+		Role: "assistant",
+		Content: []ContentBlock{
+			{Type: "thinking", Text: "Check the parser first"},
+			{Type: "redacted_thinking"},
+			{Type: "text", Text: "The parser is fine."},
+		},
+	}
+
+	md := gen.formatMessage(msg, nil)
+
+	expectedParts := []string{
+		`<details class="thinking">`,
+		"<summary>Thinking</summary>",
+		"Check the parser first",
+		"<summary>Thinking (redacted)</summary>",
+		"The parser is fine.",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected MD to contain %q, got:\n%s", part, md)
+		}
+	}
+	if strings.Contains(md, "unknown block type") {
+		t.Errorf("Thinking blocks rendered as unknown:\n%s", md)
+	}
+}
+
+func TestToolCallPairing(t *testing.T) {
+	gen := NewMarkdownGenerator(t.TempDir(), t.TempDir(), false)
+	start := time.Date(2025, 12, 29, 10, 0, 0, 0, time.UTC)
+
+	session := &Session{
+		ID: "session-1",
+		Messages: []Message{
+			{UUID: "a1", Role: "assistant", Timestamp: start, Content: []ContentBlock{
+				{Type: "tool_use", ToolUseID: "t1", ToolName: "Bash", ToolInput: `{"command":"ls"}`},
+				{Type: "tool_use", ToolUseID: "t2", ToolName: "Read", ToolInput: `{"file_path":"/x"}`},
+			}},
+			{UUID: "u1", ParentUUID: "a1", Role: "user", Timestamp: start.Add(1500 * time.Millisecond), Content: []ContentBlock{
+				{Type: "tool_result", ToolUseID: "t1", ToolOutput: "main.go"},
+				{Type: "tool_result", ToolUseID: "t2", IsError: true, ToolOutput: "File does not exist."},
+			}},
+			{UUID: "a2", ParentUUID: "u1", Role: "assistant", Timestamp: start.Add(2 * time.Second), Content: []ContentBlock{
+				{Type: "text", Text: "Done"},
+			}},
+		},
+	}
+	session.ToolCalls = joinToolCalls(session.Messages)
+
+	tree := BuildConversationTree(session)
+	md := gen.formatPath(tree.Main, tree, nil, toolCallIndex(session.ToolCalls))
+
+	expectedParts := []string{
+		"<details class=\"tool-call\">\n<summary>Tool: Bash · 1.5s</summary>",
+		"```bash\nls\n```",
+		"**Result**\n\n```\nmain.go\n```",
+		"<details class=\"tool-call tool-error\">\n<summary>Tool: Read · /x · 1.5s · failed</summary>",
+		"**Result (error)**\n\n```\nFile does not exist.\n```",
+		"## Assistant\n\nDone",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", part, md)
+		}
+	}
+
+	// The message holding only the results is folded into the calls
+	if strings.Contains(md, "## User") || strings.Contains(md, "<summary>Result") {
+		t.Errorf("Joined results should not be rendered separately:\n%s", md)
+	}
+}
+
+func TestToolErrorFormatting(t *testing.T) {
+	gen := NewMarkdownGenerator(t.TempDir(), t.TempDir(), false)
+
+	md := gen.formatBlocks([]ContentBlock{
+		{Type: "tool_result", ToolUseID: "t1", IsError: true, ToolOutput: "command not found"},
+		{Type: "tool_result", ToolUseID: "t2", ToolOutput: "Screenshot taken", Parts: []ContentBlock{
+			{Type: "image", MediaType: "image/png", Data: []byte("\x89PNG\r\n\x1a\n")},
+		}},
+	}, nil)
+
+	expectedParts := []string{
+		"<details class=\"tool-error\">\n<summary>Result (error)</summary>",
+		"command not found",
+		"<details>\n<summary>Result</summary>",
+		"![Image](assets/",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected MD to contain %q, got:\n%s", part, md)
+		}
+	}
+}
+
+func TestSystemEventFormatting(t *testing.T) {
+	tree := &ConversationTree{}
+	messages := []Message{
+		{UUID: "u1", Role: "user", Content: []ContentBlock{{Type: "text", Text: "Hello"}}},
+		{UUID: "c1", Role: "system", Event: &SystemEvent{Kind: EventCompact, Trigger: "auto", PreTokens: 155000}},
+		{UUID: "c2", Role: "system", Event: &SystemEvent{Kind: EventCommand, Text: "/model <sonnet>"}},
+		{UUID: "c3", Role: "system", Event: &SystemEvent{Kind: EventCommand, Subtype: "local_command_stdout", Text: "Total cost: $1.20\nDuration: 3m"}},
+		{UUID: "n1", Role: "system", Event: &SystemEvent{Kind: EventNotice, Level: "error", Text: "API Error: overloaded"}},
+	}
+
+	gen := NewMarkdownGenerator(t.TempDir(), t.TempDir(), false)
+	md := gen.formatPath(messages, tree, nil, nil)
+
+	expectedParts := []string{
+		`<div class="system-event compact">— context compacted here (auto, 155.0k tokens) —</div>`,
+		`<div class="system-event command">— /model &lt;sonnet&gt; —</div>`,
+		`<details class="system-event command"><summary>command output: Total cost: $1.20</summary>`,
+		"<pre>Duration: 3m</pre>",
+		`<div class="system-event notice error">— API Error: overloaded —</div>`,
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected MD to contain %q, got:\n%s", part, md)
+		}
+	}
+	if strings.Count(md, "## ") != 1 {
+		t.Errorf("Expected events to render without role headings, got:\n%s", md)
+	}
+}
+
+func TestImageAssets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	png := []byte("\x89PNG\r\n\x1a\n")
+	session := &Session{
+		ID:         "imagetest",
+		SourcePath: filepath.Join(tmpDir, "missing.jsonl"),
+		Messages: []Message{
+			{
+				Role: "user",
+				Content: []ContentBlock{
+					{Type: "image", MediaType: "image/png", Data: png},
+					{Type: "text", Text: "Same screenshot again"},
+					{Type: "image", MediaType: "image/png", Data: png},
+				},
+			},
+		},
+	}
+
+	outDir := filepath.Join(tmpDir, "output")
+	projectDir := filepath.Join(outDir, "test-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	gen := NewMarkdownGenerator(outDir, tmpDir, false)
+	if err := gen.GenerateSession(session, "test-project"); err != nil {
+		t.Fatalf("GenerateSession failed: %v", err)
+	}
+
+	// Identical images are stored once
+	assets, err := os.ReadDir(filepath.Join(projectDir, assetsDirName))
+	if err != nil {
+		t.Fatalf("Failed to read assets dir: %v", err)
+	}
+	if len(assets) != 1 {
+		t.Fatalf("Expected 1 asset file, got %d", len(assets))
+	}
+	name := assets[0].Name()
+	if !strings.HasSuffix(name, ".png") {
+		t.Errorf("Expected .png asset, got %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, assetsDirName, name))
+	if err != nil || string(data) != string(png) {
+		t.Errorf("Asset content mismatch: %q (err %v)", data, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(projectDir, "imagetest.md"))
+	if err != nil {
+		t.Fatalf("Failed to read generated MD: %v", err)
+	}
+	link := "![Image](assets/" + name + ")"
+	if strings.Count(string(content), link) != 2 {
+		t.Errorf("Expected MD to reference %q twice, got:\n%s", link, content)
+	}
+}
+
+func TestDocumentAssets(t *testing.T) {
+	gen := NewMarkdownGenerator(t.TempDir(), t.TempDir(), false)
+
+	md := gen.formatBlocks([]ContentBlock{
+		{Type: "document", MediaType: "application/pdf", Title: "Spec [draft](v2)", Data: []byte("%PDF-1.4")},
+		{Type: "document", MediaType: "image/svg+xml", Data: []byte("<svg onload=alert(1)>")},
+	}, nil)
+
+	if !strings.Contains(md, `[Spec \[draft\]\(v2\)](assets/`) {
+		t.Errorf("Expected the title escaped in the link text, got:\n%s", md)
+	}
+	if strings.Contains(md, ".svg") || !strings.Contains(md, ".bin)") {
+		t.Errorf("Expected the SVG document saved as .bin, got:\n%s", md)
+	}
+}
+
+func TestSubagentFormatting(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	session := &Session{
+		ID:         "parent",
+		SourcePath: filepath.Join(tmpDir, "missing.jsonl"),
+		Messages: []Message{
+			{Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ToolName: "Task", ToolUseID: "task1", ToolInput: `{}`}}},
+			{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Thanks"}}},
+		},
+		Subagents: []Session{
+			{
+				ID:              "agent-1",
+				Summary:         "Find the parser",
+				ParentToolUseID: "task1",
+				Messages: []Message{
+					{Role: "user", Content: []ContentBlock{{Type: "text", Text: "Locate parser.go"}}},
+					{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "It is in the root"}}, Usage: &Usage{OutputTokens: 1500}},
+				},
+			},
+		},
+	}
+
+	outDir := filepath.Join(tmpDir, "output")
+	if err := os.MkdirAll(filepath.Join(outDir, "test-project"), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	gen := NewMarkdownGenerator(outDir, tmpDir, false)
+	if err := gen.GenerateSession(session, "test-project"); err != nil {
+		t.Fatalf("GenerateSession failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "test-project", "parent.md"))
+	if err != nil {
+		t.Fatalf("Failed to read generated MD: %v", err)
+	}
+	md := string(content)
+
+	expectedParts := []string{
+		`<details class="subagent">`,
+		"<summary>Subagent: Find the parser (2 messages · 1.5k tokens · $0.02)</summary>",
+		"#### User\n\nLocate parser.go",
+		"#### Assistant\n\nIt is in the root",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected MD to contain %q, got:\n%s", part, md)
+		}
+	}
+
+	// The subagent is nested before the next top-level message
+	if strings.Index(md, "Subagent: Find the parser") > strings.Index(md, "\n## User\n") {
+		t.Errorf("Expected subagent inside the Task message, got:\n%s", md)
+	}
+}
+
+func TestBranchFormatting(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	session := &Session{
+		ID:         "branched",
+		SourcePath: filepath.Join(tmpDir, "missing.jsonl"),
+		Messages: []Message{
+			{UUID: "u1", Role: "user", Content: []ContentBlock{{Type: "text", Text: "Write a test"}}},
+			{UUID: "a1", ParentUUID: "u1", Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "First attempt"}}},
+			{UUID: "a1b", ParentUUID: "u1", Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "Second attempt"}}},
+		},
+	}
+
+	outDir := filepath.Join(tmpDir, "output")
+	if err := os.MkdirAll(filepath.Join(outDir, "test-project"), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	gen := NewMarkdownGenerator(outDir, tmpDir, false)
+	if err := gen.GenerateSession(session, "test-project"); err != nil {
+		t.Fatalf("GenerateSession failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outDir, "test-project", "branched.md"))
+	if err != nil {
+		t.Fatalf("Failed to read generated MD: %v", err)
+	}
+	md := string(content)
+
+	expectedParts := []string{
+		`<div class="fork-point" data-fork="u1" data-branches="branch-1"></div>`,
+		`<div class="branch" id="branch-1" data-fork="u1">`,
+		"### Branch 1",
+		`> Forked after the user message: "Write a test"`,
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(md, part) {
+			t.Errorf("Expected MD to contain %q, got:\n%s", part, md)
+		}
+	}
+
+	// The latest attempt is the main path; the earlier one is the branch
+	if strings.Index(md, "Second attempt") > strings.Index(md, "### Branch 1") {
+		t.Errorf("Expected latest reply on the main path, got:\n%s", md)
+	}
+	if strings.Index(md, "First attempt") < strings.Index(md, "### Branch 1") {
+		t.Errorf("Expected earlier reply in the branch section, got:\n%s", md)
+	}
+}
+
+func TestResumedSessionLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	project := &Project{
+		Path: "/Users/test/myproject",
+		Sessions: []Session{
+			{
+				ID:             "2222bbbb-resumed",
+				Summary:        "Resumed",
+				CreatedAt:      time.Date(2026, 1, 17, 10, 0, 0, 0, time.UTC),
+				UpdatedAt:      time.Date(2026, 1, 18, 10, 0, 0, 0, time.UTC),
+				PredecessorID:  "1111aaaa-original",
+				ConversationID: "1111aaaa-original",
+				SourcePath:     filepath.Join(tmpDir, "missing.jsonl"),
+			},
+			{
+				ID:             "1111aaaa-original",
+				Summary:        "Original",
+				CreatedAt:      time.Date(2026, 1, 17, 10, 0, 0, 0, time.UTC),
+				UpdatedAt:      time.Date(2026, 1, 17, 11, 0, 0, 0, time.UTC),
+				SuccessorIDs:   []string{"2222bbbb-resumed"},
+				ConversationID: "1111aaaa-original",
+				SourcePath:     filepath.Join(tmpDir, "missing.jsonl"),
+			},
+		},
+	}
+
+	projectSlug := "users-test-myproject"
+	if err := os.MkdirAll(filepath.Join(tmpDir, projectSlug), 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+
+	gen := NewMarkdownGenerator(tmpDir, tmpDir, false)
+	for i := range project.Sessions {
+		if err := gen.GenerateSession(&project.Sessions[i], projectSlug); err != nil {
+			t.Fatalf("GenerateSession failed: %v", err)
+		}
+	}
+	if err := gen.GenerateProjectIndex(project, projectSlug); err != nil {
+		t.Fatalf("GenerateProjectIndex failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"2222bbbb-resumed.md":  "Continued from [session 1111aaaa](1111aaaa-original.md)",
+		"1111aaaa-original.md": "Continued in [session 2222bbbb](2222bbbb-resumed.md)",
+		"index.md":             "- Original: [1111aaaa-original](1111aaaa-original.md) → [2222bbbb-resumed](2222bbbb-resumed.md)",
+	}
+	for name, part := range expected {
+		content, err := os.ReadFile(filepath.Join(tmpDir, projectSlug, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if !strings.Contains(string(content), part) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", name, part, content)
+		}
+	}
+}
+
+func TestRenderToolInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      string
+		input     string
+		wantLabel string
+		wantBody  []string
+	}{
+		{
+			name:      "edit as unified diff",
+			tool:      "Edit",
+			input:     `{"file_path":"/p/main.go","old_string":"a := 1\nb := 2\nc := 3","new_string":"a := 1\nb := 20\nc := 3"}`,
+			wantLabel: "/p/main.go",
+			wantBody:  []string{"```diff\n--- /p/main.go\n+++ /p/main.go\n a := 1\n-b := 2\n+b := 20\n c := 3\n```"},
+		},
+		{
+			name:      "multi edit",
+			tool:      "MultiEdit",
+			input:     `{"file_path":"/p/a.py","edits":[{"old_string":"x","new_string":"y"},{"old_string":"1","new_string":"2"}]}`,
+			wantLabel: "/p/a.py (2 edits)",
+			wantBody:  []string{"@@ edit 1 of 2 @@\n-x\n+y\n@@ edit 2 of 2 @@\n-1\n+2"},
+		},
+		{
+			name:      "write with language from path",
+			tool:      "Write",
+			input:     "{\"file_path\":\"/p/README.md\",\"content\":\"# Title\\n\\n```go\\nx\\n```\\n\"}",
+			wantLabel: "/p/README.md",
+			wantBody:  []string{"````markdown\n# Title\n\n```go\nx\n```\n````"},
+		},
+		{
+			name:      "bash with description",
+			tool:      "Bash",
+			input:     `{"command":"go test ./...","description":"Run tests"}`,
+			wantLabel: "Run tests",
+			wantBody:  []string{"```bash\ngo test ./...\n```"},
+		},
+		{
+			name:      "read range",
+			tool:      "Read",
+			input:     `{"file_path":"/p/main.go","offset":10,"limit":50}`,
+			wantLabel: "/p/main.go (lines 10–59)",
+		},
+		{
+			name:      "grep",
+			tool:      "Grep",
+			input:     `{"pattern":"func main","path":"/p","glob":"*.go","-i":true}`,
+			wantLabel: `"func main" in /p (*.go, case-insensitive)`,
+		},
+		{
+			name:      "glob",
+			tool:      "Glob",
+			input:     `{"pattern":"**/*.ts"}`,
+			wantLabel: "**/*.ts",
+		},
+		{
+			name:      "todo checklist",
+			tool:      "TodoWrite",
+			input:     `{"todos":[{"content":"Parse input","status":"completed"},{"content":"Render","status":"in_progress"},{"content":"Test","status":"pending"}]}`,
+			wantLabel: "1 of 3 done",
+			wantBody:  []string{"- [x] Parse input\n- [ ] **Render** _(in progress)_\n- [ ] Test\n"},
+		},
+		{
+			name:     "unknown tool falls back to JSON",
+			tool:     "WebFetch",
+			input:    `{"url":"https://example.com"}`,
+			wantBody: []string{"```json\n{\"url\":\"https://example.com\"}\n```"},
+		},
+		{
+			name:     "unexpected input falls back to JSON",
+			tool:     "Bash",
+			input:    `{"cmd":"ls"}`,
+			wantBody: []string{"```json\n{\"cmd\":\"ls\"}\n```"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := renderToolInput(tt.tool, tt.input)
+			if view.Label != tt.wantLabel {
+				t.Errorf("Label = %q, want %q", view.Label, tt.wantLabel)
+			}
+			for _, part := range tt.wantBody {
+				if !strings.Contains(view.Body, part) {
+					t.Errorf("Expected body to contain %q, got:\n%s", part, view.Body)
+				}
+			}
+			if tt.wantBody == nil && view.Body != "" {
+				t.Errorf("Expected no body, got:\n%s", view.Body)
+			}
+		})
+	}
+}
+
+func TestOrphanPolicies(t *testing.T) {
+	writeOrphanFixture := func(t *testing.T) (*Project, string) {
+		t.Helper()
+		sourceDir := t.TempDir()
+		outputDir := t.TempDir()
+		project := &Project{
+			Path:       "/Users/test/myproject",
+			FolderName: "-Users-test-myproject",
+			SourceDir:  sourceDir,
+			Sessions:   []Session{{ID: "kept", Summary: "Kept Session"}},
+		}
+
+		// "unreadable" still has its file, only failed to load
+		if err := os.MkdirAll(filepath.Join(sourceDir, project.FolderName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(sourceDir, project.FolderName, "unreadable.jsonl"), []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		projectDir := filepath.Join(outputDir, "myproject")
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		gone := "---\nsource: gone.jsonl\nsource_hash: sha256:abc\nproject: /Users/test/myproject\ntitle: Gone Session\ncreated: 2026-01-10T09:00:00Z\ngit_branch: main\n---\n\n## User\n\nHello\n\n![Image](assets/shot.png)\n\n## Assistant\n\nHi\n"
+		if err := os.MkdirAll(filepath.Join(projectDir, assetsDirName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range map[string]string{
+			"kept.md":         "---\nsource: kept.jsonl\n---\n",
+			"gone.md":         gone,
+			"unreadable.md":   "---\nsource: unreadable.jsonl\n---\n",
+			"index.md":        "# Project\n",
+			"assets/shot.png": "\x89PNG",
+		} {
+			if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return project, outputDir
+	}
+
+	t.Run("keep", func(t *testing.T) {
+		project, outputDir := writeOrphanFixture(t)
+		gen := NewMarkdownGenerator(outputDir, project.SourceDir, false)
+		gen.orphans = OrphanKeep
+
+		result := &GenerationResult{}
+		gen.handleOrphans(project, "myproject", result)
+		if len(result.Errors) > 0 {
+			t.Fatalf("handleOrphans errors: %v", result.Errors)
+		}
+		if result.Orphans != 1 || len(project.ArchivedSessions) != 1 {
+			t.Fatalf("Expected 1 archived session, got %d (%d orphans)", len(project.ArchivedSessions), result.Orphans)
+		}
+		archived := project.ArchivedSessions[0]
+		if archived.ID != "gone" || archived.Summary != "Gone Session" || !archived.Archived {
+			t.Errorf("Unexpected archived session: %+v", archived)
+		}
+		if archived.MessageCount() != 2 || archived.GitBranch != "main" {
+			t.Errorf("Expected 2 messages on main, got %d on %q", archived.MessageCount(), archived.GitBranch)
+		}
+
+		content, err := os.ReadFile(filepath.Join(outputDir, "myproject", "gone.md"))
+		if err != nil {
+			t.Fatalf("Archived Markdown is gone: %v", err)
+		}
+		fm, body, err := ParseFrontmatter(content)
+		if err != nil {
+			t.Fatalf("ParseFrontmatter failed: %v", err)
+		}
+		if !fm.SourceMissing || fm.Title != "Gone Session" {
+			t.Errorf("Expected frontmatter marked source_missing, got %+v", fm)
+		}
+		if !strings.Contains(string(body), "## Assistant\n\nHi") {
+			t.Errorf("Expected the body to be kept, got %q", body)
+		}
+
+		if err := gen.GenerateProjectIndex(project, "myproject"); err != nil {
+			t.Fatalf("GenerateProjectIndex failed: %v", err)
+		}
+		index, _ := os.ReadFile(filepath.Join(outputDir, "myproject", "index.md"))
+		if !strings.Contains(string(index), "## Archived") || !strings.Contains(string(index), "[gone](gone.md)") {
+			t.Errorf("Expected the project index to list the archived session, got:\n%s", index)
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		project, outputDir := writeOrphanFixture(t)
+		gen := NewMarkdownGenerator(outputDir, project.SourceDir, false)
+		gen.orphans = OrphanArchive
+
+		result := &GenerationResult{}
+		gen.handleOrphans(project, "myproject", result)
+		if result.Orphans != 1 || len(result.Errors) > 0 || len(project.ArchivedSessions) != 0 {
+			t.Fatalf("Expected 1 orphan moved and none listed, got %d, %v and %d listed", result.Orphans, result.Errors, len(project.ArchivedSessions))
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, "myproject", "gone.md")); err == nil {
+			t.Error("Expected the Markdown to be moved out of the project")
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, orphansDirName, "myproject", "gone.md"))
+		if err != nil {
+			t.Fatalf("Moved Markdown is missing: %v", err)
+		}
+		if fm, _, err := ParseFrontmatter(content); err != nil || !fm.SourceMissing {
+			t.Errorf("Expected moved frontmatter marked source_missing, got %+v (%v)", fm, err)
+		}
+		for _, dir := range []string{"myproject", filepath.Join(orphansDirName, "myproject")} {
+			if _, err := os.Stat(filepath.Join(outputDir, dir, assetsDirName, "shot.png")); err != nil {
+				t.Errorf("Expected the linked asset in %s: %v", dir, err)
+			}
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		project, outputDir := writeOrphanFixture(t)
+		gen := NewMarkdownGenerator(outputDir, project.SourceDir, false)
+		gen.orphans = OrphanDelete
+
+		result := &GenerationResult{}
+		gen.handleOrphans(project, "myproject", result)
+		if result.Orphans != 1 || len(result.Errors) > 0 {
+			t.Fatalf("Expected 1 orphan and no errors, got %d and %v", result.Orphans, result.Errors)
+		}
+		for name, want := range map[string]bool{"gone.md": false, "kept.md": true, "unreadable.md": true, "index.md": true} {
+			_, err := os.Stat(filepath.Join(outputDir, "myproject", name))
+			if exists := err == nil; exists != want {
+				t.Errorf("%s exists = %v, want %v", name, exists, want)
+			}
+		}
+	})
+
+	t.Run("removed project", func(t *testing.T) {
+		project, outputDir := writeOrphanFixture(t)
+		gen := NewMarkdownGenerator(outputDir, project.SourceDir, false)
+
+		// A project that wasn't loaded but still has its files, as with --list, is left alone
+		result, err := gen.GenerateAll(nil)
+		if err != nil {
+			t.Fatalf("GenerateAll failed: %v", err)
+		}
+		if result.Orphans != 0 || len(result.ArchivedProjects) != 0 {
+			t.Fatalf("Expected a project with files left alone, got %d orphans", result.Orphans)
+		}
+
+		// Once its folder is gone, its sessions are kept and it is listed
+		if err := os.RemoveAll(filepath.Join(project.SourceDir, project.FolderName)); err != nil {
+			t.Fatal(err)
+		}
+		if result, err = gen.GenerateAll(nil); err != nil {
+			t.Fatalf("GenerateAll failed: %v", err)
+		}
+		if result.Orphans != 3 || len(result.ArchivedProjects) != 1 {
+			t.Fatalf("Expected 3 orphans in 1 project, got %d in %d", result.Orphans, len(result.ArchivedProjects))
+		}
+		kept := result.ArchivedProjects[0]
+		if kept.Slug() != "myproject" || kept.Path != "/Users/test/myproject" || len(kept.ArchivedSessions) != 3 {
+			t.Errorf("Unexpected archived project: %+v", kept)
+		}
+		content, _ := os.ReadFile(filepath.Join(outputDir, "myproject", "gone.md"))
+		if fm, _, err := ParseFrontmatter(content); err != nil || !fm.SourceMissing {
+			t.Errorf("Expected frontmatter marked source_missing, got %+v (%v)", fm, err)
+		}
+		index, _ := os.ReadFile(filepath.Join(outputDir, "index.md"))
+		if !strings.Contains(string(index), "(myproject/index.md)") {
+			t.Errorf("Expected the main index to list the project, got:\n%s", index)
+		}
+
+		// Deleting its sessions removes its output directory
+		gen.orphans = OrphanDelete
+		if result, err = gen.GenerateAll(nil); err != nil {
+			t.Fatalf("GenerateAll failed: %v", err)
+		}
+		if result.Orphans != 3 || len(result.ArchivedProjects) != 0 {
+			t.Errorf("Expected 3 orphans deleted, got %d with %d projects kept", result.Orphans, len(result.ArchivedProjects))
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, "myproject")); !os.IsNotExist(err) {
+			t.Errorf("Expected the project output directory removed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OrphanPolicy says what becomes of the Markdown of a session whose JSONL file is gone,
+// e.g. after Claude Code cleaned up old transcripts or a file was renamed
+type OrphanPolicy string
+
+const (
+	OrphanKeep    OrphanPolicy = "keep"    // Keep the Markdown, marked source_missing, and list the session as archived
+	OrphanArchive OrphanPolicy = "archive" // Move the Markdown, marked source_missing, out of the site to .orphans
+	OrphanDelete  OrphanPolicy = "delete"  // Remove the Markdown
+)
+
+// ParseOrphanPolicy checks an orphan policy name; "" is keep
+func ParseOrphanPolicy(name string) (OrphanPolicy, error) {
+	switch policy := OrphanPolicy(strings.ToLower(name)); policy {
+	case "":
+		return OrphanKeep, nil
+	case OrphanKeep, OrphanArchive, OrphanDelete:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid orphan policy %q (use keep, archive or delete)", name)
+}
+
+// orphanedSessions returns the IDs of the session Markdown files in a project's output
+// directory whose session file no longer exists
+func orphanedSessions(projectDir string, project *Project) ([]string, error) {
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading project output directory: %w", err)
+	}
+
+	loaded := make(map[string]bool, len(project.Sessions))
+	for _, s := range project.Sessions {
+		loaded[s.ID] = true
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".md") || name == "index.md" || strings.HasPrefix(name, "tmp-") {
+			continue
+		}
+		id := strings.TrimSuffix(name, ".md")
+		if loaded[id] {
+			continue
+		}
+		// A file that is there but failed to load isn't an orphan
+		if _, err := os.Stat(filepath.Join(project.SourceDir, project.FolderName, id+".jsonl")); err == nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// orphansDirName is the folder of the output directory that the archive orphan policy
+// moves the Markdown of removed sessions to, out of the browsable site
+const orphansDirName = ".orphans"
+
+// handleOrphans applies the orphan policy to the Markdown of the project's sessions that
+// are gone. Sessions kept in place are set as the project's ArchivedSessions, newest
+// first.
+func (g *MarkdownGenerator) handleOrphans(project *Project, projectSlug string, result *GenerationResult) {
+	projectDir := filepath.Join(g.outputDir, projectSlug)
+	ids, err := orphanedSessions(projectDir, project)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("orphans of %s: %w", projectSlug, err))
+		return
+	}
+	project.ArchivedSessions = g.applyOrphanPolicy(project, projectSlug, ids, result)
+}
+
+// handleOrphanedProjects applies the orphan policy to the output directories of projects
+// whose folder is gone from every source. Those whose sessions are kept in place are
+// returned, with only archived sessions, to be listed with the other projects.
+func (g *MarkdownGenerator) handleOrphanedProjects(projects []Project, result *GenerationResult) []Project {
+	slugs := make(map[string]bool, len(projects))
+	for i := range projects {
+		slugs[projects[i].Slug()] = true
+	}
+	entries, err := os.ReadDir(g.outputDir)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("reading output directory: %w", err))
+		return nil
+	}
+
+	var kept []Project
+	for _, entry := range entries {
+		slug := entry.Name()
+		if !entry.IsDir() || slugs[slug] || strings.HasPrefix(slug, ".") {
+			continue
+		}
+		projectDir := filepath.Join(g.outputDir, slug)
+		project, ids := g.orphanedProject(projectDir)
+		if project == nil {
+			continue
+		}
+		project.slug = slug
+
+		project.ArchivedSessions = g.applyOrphanPolicy(project, slug, ids, result)
+		if len(project.ArchivedSessions) == 0 {
+			// Moved or deleted: drop what is left of the project's output
+			os.Remove(filepath.Join(projectDir, "index.md"))
+			os.RemoveAll(filepath.Join(projectDir, assetsDirName))
+			os.Remove(projectDir) // Left alone if it holds anything else
+			continue
+		}
+		if err := g.GenerateProjectIndex(project, slug); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("project index %s: %w", slug, err))
+		}
+		kept = append(kept, *project)
+	}
+	return kept
+}
+
+// orphanedProject returns the project an output directory holds the session Markdown of,
+// with the IDs of its sessions, or nil if it holds none or the file of one of them is
+// still in a source, as for a project left out with --list
+func (g *MarkdownGenerator) orphanedProject(projectDir string) (*Project, []string) {
+	entries, err := os.ReadDir(projectDir)
+	if err != nil {
+		return nil, nil
+	}
+
+	var project *Project
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".md") || name == "index.md" || strings.HasPrefix(name, "tmp-") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(projectDir, name))
+		if err != nil {
+			continue
+		}
+		fm, _, err := ParseFrontmatter(content)
+		if err != nil || fm.Source == "" {
+			continue
+		}
+		if g.sourceFileExists(fm.Source) {
+			return nil, nil
+		}
+		if project == nil {
+			project = &Project{Path: fm.Project, FolderName: EncodeProjectPath(fm.Project)}
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".md"))
+	}
+	return project, ids
+}
+
+// sourceFileExists reports whether a session file of the given name is in a project
+// folder of any source
+func (g *MarkdownGenerator) sourceFileExists(name string) bool {
+	for _, dir := range g.sourceDirs {
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*", name)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOrphanPolicy keeps, moves or deletes the Markdown of the given sessions of a
+// project, whose files are gone, and returns those kept in place, newest first
+func (g *MarkdownGenerator) applyOrphanPolicy(project *Project, projectSlug string, ids []string, result *GenerationResult) []Session {
+	projectDir := filepath.Join(g.outputDir, projectSlug)
+
+	var archived []Session
+	for _, id := range ids {
+		mdPath := filepath.Join(projectDir, id+".md")
+		switch g.orphans {
+		case OrphanDelete:
+			if err := os.Remove(mdPath); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("deleting orphan %s: %w", id, err))
+				continue
+			}
+			logVerbose("Deleted orphaned Markdown: %s", mdPath)
+		case OrphanArchive:
+			if err := g.moveOrphan(projectDir, projectSlug, id); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("archiving orphan %s: %w", id, err))
+				continue
+			}
+			logVerbose("Moved orphaned Markdown to %s: %s", orphansDirName, mdPath)
+		default:
+			session, err := g.keepOrphan(mdPath, id, project)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("keeping orphan %s: %w", id, err))
+				continue
+			}
+			archived = append(archived, *session)
+		}
+		result.Orphans++
+	}
+
+	sort.Slice(archived, func(i, j int) bool {
+		return archived[i].CreatedAt.After(archived[j].CreatedAt)
+	})
+	return archived
+}
+
+// keepOrphan marks the Markdown of a session whose file is gone with source_missing
+// and returns the session as far as its Markdown describes it
+func (g *MarkdownGenerator) keepOrphan(mdPath, id string, project *Project) (*Session, error) {
+	content, err := os.ReadFile(mdPath)
+	if err != nil {
+		return nil, err
+	}
+	fm, body, err := ParseFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fm.SourceMissing {
+		fm.SourceMissing = true
+		fmBytes, err := fm.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		if err := g.writeFile(mdPath, append(append(fmBytes, '\n'), body...)); err != nil {
+			return nil, err
+		}
+	}
+
+	created, _ := time.Parse(time.RFC3339, fm.Created)
+	session := &Session{
+		ID:             id,
+		Summary:        fm.Title,
+		CWD:            fm.Project,
+		CreatedAt:      created,
+		UpdatedAt:      created,
+		SourcePath:     filepath.Join(project.SourceDir, project.FolderName, fm.Source),
+		GitBranch:      fm.GitBranch,
+		GitBranches:    fm.GitBranches,
+		Version:        fm.Version,
+		PermissionMode: fm.PermissionMode,
+		UserType:       fm.UserType,
+		Archived:       true,
+	}
+	if len(session.GitBranches) == 0 && session.GitBranch != "" {
+		session.GitBranches = []string{session.GitBranch}
+	}
+	session.Meta.Streamed = true
+	session.Meta.MessageCount = countMarkdownMessages(body)
+	return session, nil
+}
+
+// assetLinkPattern matches the asset links of a session's Markdown
+var assetLinkPattern = regexp.MustCompile(`\(` + assetsDirName + `/([^)/\s]+)\)`)
+
+// moveOrphan moves the Markdown of a session whose file is gone to the orphans folder,
+// marked with source_missing, along with copies of the assets it links to
+func (g *MarkdownGenerator) moveOrphan(projectDir, projectSlug, id string) error {
+	mdPath := filepath.Join(projectDir, id+".md")
+	content, err := os.ReadFile(mdPath)
+	if err != nil {
+		return err
+	}
+	fm, body, err := ParseFrontmatter(content)
+	if err != nil {
+		return err
+	}
+	fm.SourceMissing = true
+	fmBytes, err := fm.Marshal()
+	if err != nil {
+		return err
+	}
+
+	destDir := filepath.Join(g.outputDir, orphansDirName, projectSlug)
+	for _, m := range assetLinkPattern.FindAllSubmatch(body, -1) {
+		name := string(m[1])
+		dest := filepath.Join(destDir, assetsDirName, name)
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(projectDir, assetsDirName, name))
+		if err != nil {
+			continue // Links to an asset that was never written
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("creating orphans dir: %w", err)
+		}
+		if err := g.writeFile(dest, data); err != nil {
+			return fmt.Errorf("copying asset %s: %w", name, err)
+		}
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("creating orphans dir: %w", err)
+	}
+	if err := g.writeFile(filepath.Join(destDir, id+".md"), append(append(fmBytes, '\n'), body...)); err != nil {
+		return err
+	}
+	return os.Remove(mdPath)
+}
+
+// countMarkdownMessages counts the messages of a session's Markdown by their headings
+func countMarkdownMessages(body []byte) int {
+	n := 0
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "## User" || line == "## Assistant" {
+			n++
+		}
+	}
+	return n
+}
+
+// findSession returns the project's session or archived session with the given ID
+func (p *Project) findSession(id string) *Session {
+	for i := range p.Sessions {
+		if p.Sessions[i].ID == id {
+			return &p.Sessions[i]
+		}
+	}
+	for i := range p.ArchivedSessions {
+		if p.ArchivedSessions[i].ID == id {
+			return &p.ArchivedSessions[i]
+		}
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
-	"bufio"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,8 +13,12 @@ import (
 	"time"
 )
 
-// DefaultClaudeProjectsPath returns the default path to Claude projects
+// DefaultClaudeProjectsPath returns the default path to Claude projects: the projects
+// directory of $CLAUDE_CONFIG_DIR if set, else of ~/.claude
 func DefaultClaudeProjectsPath() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return sourcePath(filepath.Join(dir, "projects"))
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("getting home directory: %w", err)
@@ -20,7 +26,10 @@ func DefaultClaudeProjectsPath() (string, error) {
 	return filepath.Join(home, ".claude", "projects"), nil
 }
 
-// DecodeProjectPath converts an encoded folder name to a human-readable path
+// DecodeProjectPath converts an encoded folder name to a human-readable path. Dashes
+// are taken as separators, a double dash as a separator followed by a dot (hidden
+// directories), and a leading drive letter as a Windows path. The result is only a
+// guess for folders whose sessions have no cwd; see resolveProjectPath.
 // Example: "-Users-name-project" -> "/Users/name/project"
 func DecodeProjectPath(folderName string) string {
 	if folderName == "" {
@@ -32,19 +41,24 @@ func DecodeProjectPath(folderName string) string {
 		return "/"
 	}
 
-	// Remove leading dash and split by dash
+	// Windows paths: "C--Users-name-project" -> "C:\Users\name\project"
+	if m := windowsFolder.FindStringSubmatch(folderName); m != nil {
+		return m[1] + `:\` + decodeSegments(m[2], `\`)
+	}
+
 	if !strings.HasPrefix(folderName, "-") {
 		return folderName // Not an encoded path
 	}
 
-	// Remove leading dash
-	path := folderName[1:]
-
-	// Replace dashes with slashes
-	path = strings.ReplaceAll(path, "-", "/")
+	// Remove leading dash and prepend slash
+	return "/" + decodeSegments(folderName[1:], "/")
+}
 
-	// Prepend slash
-	return "/" + path
+// decodeSegments replaces the dashes of an encoded path with sep, reading a double dash
+// as sep followed by a dot since path segments can't be empty
+func decodeSegments(encoded, sep string) string {
+	encoded = strings.ReplaceAll(encoded, "--", sep+".")
+	return strings.ReplaceAll(encoded, "-", sep)
 }
 
 // DiscoverProjects scans the Claude projects directory and returns all projects
@@ -69,19 +83,14 @@ func DiscoverProjects(projectsPath string) ([]Project, error) {
 
 	var projects []Project
 	for _, entry := range entries {
-		// Skip non-directories and hidden files
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
-		}
-
-		// Skip entries that don't look like encoded paths
-		if !strings.HasPrefix(entry.Name(), "-") {
+		// Skip non-directories and entries that don't look like encoded paths
+		if !entry.IsDir() || !isProjectFolder(entry.Name()) {
 			continue
 		}
 
 		project := Project{
 			FolderName: entry.Name(),
-			Path:       DecodeProjectPath(entry.Name()),
+			Path:       resolveProjectPath(entry.Name(), nil),
 			Sessions:   nil, // Sessions are loaded lazily
 		}
 		projects = append(projects, project)
@@ -95,8 +104,41 @@ func DiscoverProjects(projectsPath string) ([]Project, error) {
 	return projects, nil
 }
 
-// ListSessions lists all sessions for a project
+// isProjectFolder reports whether a directory name looks like an encoded project path
+func isProjectFolder(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false // Hidden
+	}
+	return strings.HasPrefix(name, "-") || windowsFolder.MatchString(name)
+}
+
+// ListSessions lists all sessions for a project, with their messages
 func ListSessions(projectsPath string, project *Project) ([]Session, error) {
+	return listSessions(projectsPath, project, ParseSession)
+}
+
+// ListSessionMetas lists all sessions for a project without their messages. Each session
+// is read once as a stream, so memory use doesn't grow with the size of its file.
+func ListSessionMetas(projectsPath string, project *Project) ([]Session, error) {
+	return listSessions(projectsPath, project, LoadSessionMeta)
+}
+
+// sessionLoader loads the session in a file: ParseSession or LoadSessionMeta
+type sessionLoader func(filePath, sessionID string) (*Session, error)
+
+func listSessions(projectsPath string, project *Project, load sessionLoader) ([]Session, error) {
+	files, err := listSessionFiles(projectsPath, project)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, warnings := buildSessions(files, parseSessionFiles(files, load, LoadOptions{}))
+	printLoadWarnings(warnings)
+	return sessions, nil
+}
+
+// listSessionFiles returns the session and subagent transcript files of a project
+func listSessionFiles(projectsPath string, project *Project) ([]sessionFile, error) {
 	projectDir := filepath.Join(projectsPath, project.FolderName)
 
 	entries, err := os.ReadDir(projectDir)
@@ -104,140 +146,183 @@ func ListSessions(projectsPath string, project *Project) ([]Session, error) {
 		return nil, fmt.Errorf("reading project directory %s: %w", project.Path, err)
 	}
 
-	var sessions []Session
+	var files []sessionFile
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+		if entry.IsDir() {
+			// Newer Claude Code versions store subagent transcripts in <session>/subagents/
+			subagentDir := filepath.Join(projectDir, entry.Name(), "subagents")
+			files = append(files, subagentFiles(subagentDir, entry.Name())...)
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), ".jsonl") {
 			continue
 		}
 
 		// Extract session ID from filename (UUID.jsonl)
-		sessionID := strings.TrimSuffix(entry.Name(), ".jsonl")
+		files = append(files, sessionFile{
+			Path: filepath.Join(projectDir, entry.Name()),
+			ID:   strings.TrimSuffix(entry.Name(), ".jsonl"),
+		})
+	}
+
+	return files, nil
+}
 
-		// Parse session to get metadata
-		sessionPath := filepath.Join(projectDir, entry.Name())
-		session, err := ParseSession(sessionPath, sessionID)
+// buildSessions turns a project's loaded files into its sessions, newest first, with
+// subagent transcripts attached and resumed sessions linked. Files that failed to load
+// are skipped and reported in the returned warnings.
+func buildSessions(files []sessionFile, parsed []parsedFile) ([]Session, []string) {
+	var sessions []Session
+	var subagents []Session
+	var warnings []string
+	for i, file := range files {
+		session, err := parsed[i].Session, parsed[i].Err
 		if err != nil {
-			// Log warning but continue with other sessions
-			fmt.Fprintf(os.Stderr, "Warning: failed to parse session %s: %v\n", sessionID, err)
+			// Skip the file but continue with other sessions
+			warnings = append(warnings, fmt.Sprintf("failed to parse %s: %v", file.Path, err))
+			continue
+		}
+		if session.Diagnostics.Count() > 0 {
+			warnings = append(warnings, fmt.Sprintf("parse problems in %s: %s", file.Path, session.Diagnostics))
+		}
+		session.Archived = file.Archived
+
+		if file.ParentID != "" {
+			if session.ParentSessionID == "" {
+				session.ParentSessionID = file.ParentID
+			}
+			subagents = append(subagents, *session)
+			continue
+		}
+
+		// Older versions store subagent transcripts as agent-<id>.jsonl next to the sessions
+		if session.ParentSessionID != "" {
+			subagents = append(subagents, *session)
 			continue
 		}
 
 		sessions = append(sessions, *session)
 	}
 
+	sessions = attachSubagents(sessions, subagents)
+	linkSessionLineage(sessions)
+	for i := range sessions {
+		sessions[i].Meta.dropLinks()
+		for j := range sessions[i].Subagents {
+			sessions[i].Subagents[j].Meta.dropLinks()
+		}
+	}
+
 	// Sort sessions by creation date (newest first)
 	sort.Slice(sessions, func(i, j int) bool {
 		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
 	})
 
-	return sessions, nil
+	return sessions, warnings
 }
 
 // ParseSession parses a JSONL session file into a Session struct
 func ParseSession(filePath string, sessionID string) (*Session, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("opening session file: %w", err)
-	}
-	defer file.Close()
+	return parseSessionFile(filePath, sessionID, true)
+}
 
-	session := &Session{
-		ID:         sessionID,
-		Messages:   []Message{},
-		SourcePath: filePath,
+// OnBranch reports whether the session ran on the given git branch
+func (s *Session) OnBranch(branch string) bool {
+	for _, b := range s.GitBranches {
+		if b == branch {
+			return true
+		}
 	}
+	return false
+}
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for very long lines (some Claude sessions have 20MB+ lines)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 50*1024*1024) // 50MB max line size
-
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Bytes()
+// BranchList returns the session's git branches separated by spaces
+func (s *Session) BranchList() string {
+	return strings.Join(s.GitBranches, " ")
+}
 
-		if len(line) == 0 {
-			continue
+// ToolErrors returns the number of failed tool calls in the session, excluding subagents
+func (s *Session) ToolErrors() int {
+	if s.Meta.Streamed {
+		return s.Meta.ToolErrors
+	}
+	n := 0
+	for _, msg := range s.Messages {
+		for _, block := range msg.Content {
+			if block.Type == "tool_result" && block.IsError {
+				n++
+			}
 		}
+	}
+	return n
+}
 
-		// Parse the JSONL entry
-		var entry jsonlEntry
-		if err := json.Unmarshal(line, &entry); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: skipping malformed JSON at line %d in %s: %v\n",
-				lineNum, sessionID, err)
-			continue
+// ToolErrorSessions returns the number of sessions in the project with failed tool calls
+func (p *Project) ToolErrorSessions() int {
+	n := 0
+	for i := range p.Sessions {
+		if p.Sessions[i].ToolErrors() > 0 {
+			n++
 		}
+	}
+	return n
+}
 
-		// Handle different entry types
-		switch entry.Type {
-		case "summary":
-			session.Summary = entry.Summary
-
-		case "user", "assistant":
-			msg, err := parseMessage(&entry)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to parse message at line %d in %s: %v\n",
-					lineNum, sessionID, err)
-				continue
-			}
-			session.Messages = append(session.Messages, *msg)
-
-			// Capture CWD from first entry that has it (actual project path)
-			if session.CWD == "" && entry.CWD != "" {
-				session.CWD = entry.CWD
-			}
-
-			// Update session timestamps
-			if session.CreatedAt.IsZero() || msg.Timestamp.Before(session.CreatedAt) {
-				session.CreatedAt = msg.Timestamp
-			}
-			if msg.Timestamp.After(session.UpdatedAt) {
-				session.UpdatedAt = msg.Timestamp
+// GitBranches returns the git branches of all sessions in the project, sorted
+func (p *Project) GitBranches() []string {
+	seen := make(map[string]bool)
+	var branches []string
+	for i := range p.Sessions {
+		for _, b := range p.Sessions[i].GitBranches {
+			if !seen[b] {
+				seen[b] = true
+				branches = append(branches, b)
 			}
-
-		case "file-history-snapshot":
-			// Skip these entries
-			continue
-
-		default:
-			// Unknown type, skip silently
-			continue
 		}
 	}
+	sort.Strings(branches)
+	return branches
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading session file: %w", err)
+// fallbackSummary returns the first user message text, truncated for use as a title
+func fallbackSummary(firstPrompt string) string {
+	// Truncate to first 100 chars
+	if len(firstPrompt) > 100 {
+		return firstPrompt[:97] + "..."
 	}
+	return firstPrompt
+}
 
-	// If no summary, use first user message as fallback
-	if session.Summary == "" && len(session.Messages) > 0 {
-		for _, msg := range session.Messages {
-			if msg.Role == "user" {
-				for _, block := range msg.Content {
-					if block.Type == "text" && block.Text != "" {
-						// Truncate to first 100 chars
-						summary := block.Text
-						if len(summary) > 100 {
-							summary = summary[:97] + "..."
-						}
-						session.Summary = summary
-						break
-					}
-				}
-				break
-			}
+// parseTimestamp parses an entry timestamp, falling back to the current time
+// for unrecognized formats and the zero time when there is none
+func parseTimestamp(value string, diag lineDiagnostics) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// Try alternate format
+		t, err = time.Parse("2006-01-02T15:04:05.000Z", value)
+		if err != nil {
+			diag.timestampFallback(value)
+			return time.Now() // Fallback
 		}
 	}
-
-	return session, nil
+	return t
 }
 
-// parseMessage converts a JSONL entry to a Message struct
-func parseMessage(entry *jsonlEntry) (*Message, error) {
+// parseMessage converts a JSONL entry to a Message struct, recording unknown content
+// and unreadable timestamps in diag
+func parseMessage(entry *jsonlEntry, diag lineDiagnostics) (*Message, error) {
 	msg := &Message{
-		UUID:    entry.UUID,
-		Content: []ContentBlock{},
+		UUID:      entry.UUID,
+		Content:   []ContentBlock{},
+		Sidechain: entry.IsSidechain,
+
+		GitBranch:      entry.GitBranch,
+		Version:        entry.Version,
+		PermissionMode: entry.PermissionMode,
+		UserType:       entry.UserType,
 	}
 
 	// Handle parentUuid (can be null)
@@ -245,21 +330,7 @@ func parseMessage(entry *jsonlEntry) (*Message, error) {
 		msg.ParentUUID = *entry.ParentUUID
 	}
 
-	// Parse timestamp
-	if entry.Timestamp != "" {
-		t, err := time.Parse(time.RFC3339, entry.Timestamp)
-		if err != nil {
-			// Try alternate format
-			t, err = time.Parse("2006-01-02T15:04:05.000Z", entry.Timestamp)
-			if err != nil {
-				msg.Timestamp = time.Now() // Fallback
-			} else {
-				msg.Timestamp = t
-			}
-		} else {
-			msg.Timestamp = t
-		}
-	}
+	msg.Timestamp = parseTimestamp(entry.Timestamp, diag)
 
 	// Parse message content
 	if len(entry.Message) == 0 {
@@ -272,6 +343,20 @@ func parseMessage(entry *jsonlEntry) (*Message, error) {
 	}
 
 	msg.Role = msgContent.Role
+	msg.ResponseID = msgContent.ID
+	msg.Model = msgContent.Model
+
+	if msgContent.Usage != nil {
+		msg.Usage = &Usage{
+			InputTokens:              msgContent.Usage.InputTokens,
+			OutputTokens:             msgContent.Usage.OutputTokens,
+			CacheCreationInputTokens: msgContent.Usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     msgContent.Usage.CacheReadInputTokens,
+		}
+		if cc := msgContent.Usage.CacheCreation; cc != nil {
+			msg.Usage.CacheCreation1hInputTokens = cc.Ephemeral1hInputTokens
+		}
+	}
 
 	// Parse content blocks
 	// Content can be a string or an array of blocks
@@ -306,71 +391,365 @@ func parseMessage(entry *jsonlEntry) (*Message, error) {
 				cb.ToolInput = string(block.Input)
 			}
 
+		case "thinking":
+			cb.Text = block.Thinking
+
+		case "redacted_thinking":
+			// Encrypted reasoning; nothing readable to keep
+
+		case "image", "document":
+			cb.Title = block.Title
+			if err := decodeMediaSource(&cb, block.Source); err != nil {
+				cb.Type = "text"
+				cb.Text = fmt.Sprintf("[%s: %v]", block.Type, err)
+			}
+
 		case "tool_result":
 			cb.ToolUseID = block.ToolUseID
-			// Content can be a string or complex object
-			if len(block.Content) > 0 {
-				var textContent string
-				if err := json.Unmarshal(block.Content, &textContent); err != nil {
-					// Store as JSON string if not a simple string
-					cb.ToolOutput = string(block.Content)
-				} else {
-					cb.ToolOutput = textContent
-				}
-			}
+			cb.IsError = block.IsError
+			cb.ToolOutput, cb.Parts = decodeToolResultContent(block.Content)
 
 		default:
 			// Unknown block type, store type for debugging
+			diag.unknownBlock(block.Type)
 			cb.Text = fmt.Sprintf("[unknown block type: %s]", block.Type)
 		}
 
 		msg.Content = append(msg.Content, cb)
 	}
 
+	// Task results name the subagent that ran; link it from the tool_result block
+	if agentID := toolUseResultAgentID(entry.ToolUseResult); agentID != "" {
+		for i := range msg.Content {
+			if msg.Content[i].Type == "tool_result" {
+				msg.Content[i].AgentID = agentID
+			}
+		}
+	}
+
 	return msg, nil
 }
 
+// decodeToolResultContent returns the text of a tool_result and its image/document parts.
+// Content can be a string or an array of text and image blocks; anything else is kept as JSON.
+func decodeToolResultContent(raw json.RawMessage) (string, []ContentBlock) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var blocks []rawContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return string(raw), nil
+	}
+
+	var texts []string
+	var parts []ContentBlock
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "image", "document":
+			part := ContentBlock{Type: block.Type, Title: block.Title}
+			if err := decodeMediaSource(&part, block.Source); err != nil {
+				texts = append(texts, fmt.Sprintf("[%s: %v]", block.Type, err))
+				continue
+			}
+			parts = append(parts, part)
+		default:
+			texts = append(texts, fmt.Sprintf("[%s]", block.Type))
+		}
+	}
+	return strings.Join(texts, "\n"), parts
+}
+
+// decodeMediaSource fills the media fields of an image or document block from its source
+func decodeMediaSource(cb *ContentBlock, source *rawMediaSource) error {
+	if source == nil {
+		return fmt.Errorf("missing source")
+	}
+
+	cb.MediaType = source.MediaType
+	switch source.Type {
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(source.Data)
+		if err != nil {
+			return fmt.Errorf("decoding base64 data: %w", err)
+		}
+		cb.Data = data
+	case "text":
+		cb.Data = []byte(source.Data)
+		if cb.MediaType == "" {
+			cb.MediaType = "text/plain"
+		}
+	case "url":
+		cb.SourceURL = source.URL
+	default:
+		return fmt.Errorf("unsupported source type %q", source.Type)
+	}
+	return nil
+}
+
+// Conversation is a chain of sessions continued with --resume or --continue
+type Conversation struct {
+	ID       string     // ID of the first session
+	Sessions []*Session // Oldest first
+}
+
+// LastActivity returns the last update of any session in the conversation
+func (c Conversation) LastActivity() time.Time {
+	var last time.Time
+	for _, s := range c.Sessions {
+		if s.UpdatedAt.After(last) {
+			last = s.UpdatedAt
+		}
+	}
+	return last
+}
+
+// linkSessionLineage detects sessions resumed from an earlier session of the project and
+// links them. A session continues an earlier one when it replays that session's message
+// UUIDs, its first message is parented to one of them, or a summary leafUuid points into it.
+func linkSessionLineage(sessions []Session) {
+	order := make([]int, len(sessions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return startsBefore(&sessions[order[a]], &sessions[order[b]])
+	})
+
+	owner := make(map[string]int) // message UUID -> index of the earliest session holding it
+	for _, i := range order {
+		session := &sessions[i]
+		session.PredecessorID = ""
+		session.SuccessorIDs = nil
+
+		pred := -1
+		consider := func(uuid string) {
+			if j, ok := owner[uuid]; ok && j != i && (pred < 0 || sessions[j].CreatedAt.After(sessions[pred].CreatedAt)) {
+				pred = j
+			}
+		}
+		for _, uuid := range session.Meta.UUIDs {
+			consider(uuid)
+		}
+		if session.Meta.RootParent != "" {
+			consider(session.Meta.RootParent)
+		}
+		for _, leaf := range session.LeafRefs {
+			consider(leaf)
+		}
+
+		if pred >= 0 {
+			session.PredecessorID = sessions[pred].ID
+		}
+		for _, uuid := range session.Meta.UUIDs {
+			if _, ok := owner[uuid]; !ok {
+				owner[uuid] = i
+			}
+		}
+	}
+
+	byID := make(map[string]int, len(sessions))
+	for i := range sessions {
+		byID[sessions[i].ID] = i
+	}
+	for _, i := range order {
+		if p, ok := byID[sessions[i].PredecessorID]; ok {
+			sessions[p].SuccessorIDs = append(sessions[p].SuccessorIDs, sessions[i].ID)
+		}
+	}
+
+	// Conversation ID: the first session of the chain (processed oldest first)
+	for _, i := range order {
+		session := &sessions[i]
+		session.ConversationID = session.ID
+		if p, ok := byID[session.PredecessorID]; ok {
+			session.ConversationID = sessions[p].ConversationID
+		}
+	}
+}
+
+// startsBefore orders sessions by start time. A resumed session replays its predecessor's
+// messages with their original timestamps, so ties go to the session that ended first.
+func startsBefore(a, b *Session) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.UpdatedAt.Before(b.UpdatedAt)
+}
+
+// GroupConversations groups a project's sessions into conversations, most recently
+// active first. Sessions that were never resumed form a conversation of their own.
+func GroupConversations(sessions []Session) []Conversation {
+	index := make(map[string]int)
+	var conversations []Conversation
+	for i := range sessions {
+		id := sessions[i].ConversationID
+		if id == "" {
+			id = sessions[i].ID
+		}
+		c, ok := index[id]
+		if !ok {
+			c = len(conversations)
+			index[id] = c
+			conversations = append(conversations, Conversation{ID: id})
+		}
+		conversations[c].Sessions = append(conversations[c].Sessions, &sessions[i])
+	}
+
+	for _, c := range conversations {
+		sort.SliceStable(c.Sessions, func(a, b int) bool {
+			return startsBefore(c.Sessions[a], c.Sessions[b])
+		})
+	}
+	sort.SliceStable(conversations, func(a, b int) bool {
+		return conversations[a].LastActivity().After(conversations[b].LastActivity())
+	})
+
+	return conversations
+}
+
 // LoadProjectWithSessions loads a project and all its sessions
 func LoadProjectWithSessions(projectsPath string, project *Project) error {
-	sessions, err := ListSessions(projectsPath, project)
+	return loadProject(projectsPath, project, ListSessions)
+}
+
+// LoadProjectMetas loads a project and all its sessions, without their messages
+func LoadProjectMetas(projectsPath string, project *Project) error {
+	return loadProject(projectsPath, project, ListSessionMetas)
+}
+
+func loadProject(projectsPath string, project *Project, list func(string, *Project) ([]Session, error)) error {
+	sessions, err := list(projectsPath, project)
 	if err != nil {
 		return err
 	}
+	setProjectSessions(project, sessions)
+	return nil
+}
+
+// setProjectSessions sets a project's sessions and takes its path from their cwd
+func setProjectSessions(project *Project, sessions []Session) {
 	project.Sessions = sessions
 
-	// Use actual CWD from sessions if available (fixes path decoding ambiguity)
-	// Sessions are sorted newest first, so use the first session's CWD
+	var cwds []string
 	for _, s := range sessions {
 		if s.CWD != "" {
-			project.Path = s.CWD
-			break
+			cwds = append(cwds, s.CWD)
 		}
 	}
-
-	return nil
+	if len(cwds) > 0 {
+		project.Path = resolveProjectPath(project.FolderName, cwds)
+	}
 }
 
 // LoadAllProjects discovers and loads all projects with their sessions
-func LoadAllProjects(projectsPath string) ([]Project, error) {
-	projects, err := DiscoverProjects(projectsPath)
-	if err != nil {
-		return nil, err
+func LoadAllProjects(projectsPath string, opts LoadOptions) ([]Project, error) {
+	return LoadAllProjectsFrom([]Source{{Path: projectsPath}}, opts)
+}
+
+// LoadAllProjectsFrom discovers and loads the projects of every source, tagging each
+// with the source it came from
+func LoadAllProjectsFrom(sources []Source, opts LoadOptions) ([]Project, error) {
+	return loadAllProjects(sources, ParseSession, opts)
+}
+
+// LoadAllProjectMetas discovers and loads all projects with their sessions, without
+// their messages. Load them with LoadSessionMessages when a session is needed in full.
+func LoadAllProjectMetas(projectsPath string, opts LoadOptions) ([]Project, error) {
+	return LoadAllProjectMetasFrom([]Source{{Path: projectsPath}}, opts)
+}
+
+// LoadAllProjectMetasFrom is LoadAllProjectMetas for the projects of every source
+func LoadAllProjectMetasFrom(sources []Source, opts LoadOptions) ([]Project, error) {
+	if opts.Cache != nil {
+		return loadAllProjects(sources, opts.Cache.LoadSessionMeta, opts)
 	}
+	return loadAllProjects(sources, LoadSessionMeta, opts)
+}
 
+// loadAllProjects loads the projects of every source. A source that can't be read is
+// skipped with a warning, unless none can.
+func loadAllProjects(sources []Source, load sessionLoader, opts LoadOptions) ([]Project, error) {
+	var projects []Project
+	var warnings []string
+	var failed int
+	for _, source := range sources {
+		found, err := DiscoverProjects(source.Path)
+		found = opts.Archive.addProjects(found, source)
+		if err != nil {
+			if len(found) == 0 {
+				failed++
+				if failed == len(sources) {
+					return nil, err
+				}
+				warnings = append(warnings, fmt.Sprintf("skipping source %s: %v", source.Path, err))
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("loading source %s from the archive: %v", source.Path, err))
+		}
+		for i := range found {
+			found[i].Source = source.Name
+			found[i].SourceDir = source.Path
+		}
+		projects = append(projects, found...)
+	}
+
+	warnings = append(warnings, loadProjectSessions(projects, load, opts)...)
+	assignProjectSlugs(projects)
+	if opts.Strict {
+		if err := strictError(CollectDiagnostics(projects)); err != nil {
+			return nil, err
+		}
+	}
+	printLoadWarnings(warnings)
+	sortProjectsByUpdate(projects)
+
+	return projects, nil
+}
+
+// loadProjectSessions loads the sessions of projects, returning the problems met. The
+// files of all projects are parsed by one pool, so that a few large projects don't
+// leave the other workers idle.
+func loadProjectSessions(projects []Project, load sessionLoader, opts LoadOptions) []string {
+	var warnings []string
+
+	// bounds[i]:bounds[i+1] are project i's files
+	var files []sessionFile
+	bounds := []int{0}
 	for i := range projects {
-		if err := LoadProjectWithSessions(projectsPath, &projects[i]); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to load sessions for %s: %v\n",
-				projects[i].Path, err)
+		list, err := listSessionFiles(projects[i].SourceDir, &projects[i])
+		archived := opts.Archive.missingFiles(&projects[i], list)
+		if err != nil && (len(archived) == 0 || !errors.Is(err, fs.ErrNotExist)) {
 			// Continue with other projects
+			warnings = append(warnings, fmt.Sprintf("failed to load sessions for %s: %v", projects[i].Path, err))
 		}
+		files = append(files, list...)
+		files = append(files, archived...)
+		bounds = append(bounds, len(files))
 	}
 
-	// Sort projects by last update date (most recent first)
+	parsed := parseSessionFiles(files, load, opts)
+	for i := range projects {
+		lo, hi := bounds[i], bounds[i+1]
+		sessions, problems := buildSessions(files[lo:hi], parsed[lo:hi])
+		setProjectSessions(&projects[i], sessions)
+		warnings = append(warnings, problems...)
+	}
+	return warnings
+}
+
+// sortProjectsByUpdate sorts projects by last update date (most recent first)
+func sortProjectsByUpdate(projects []Project) {
 	sort.Slice(projects, func(i, j int) bool {
 		return getProjectLastUpdate(&projects[i]).After(getProjectLastUpdate(&projects[j]))
 	})
-
-	return projects, nil
 }
 
 // getProjectLastUpdate returns the most recent UpdatedAt time from a project's sessions
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,6 +40,16 @@ func TestDecodeProjectPath(t *testing.T) {
 			input:    "some-folder",
 			expected: "some-folder",
 		},
+		{
+			name:     "hidden directory",
+			input:    "-Users-name--config-app",
+			expected: "/Users/name/.config/app",
+		},
+		{
+			name:     "windows path",
+			input:    "C--Users-name-project",
+			expected: `C:\Users\name\project`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -200,6 +212,198 @@ func TestParseSession_WithToolCalls(t *testing.T) {
 	}
 }
 
+func TestParseSession_WithUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	// One API response split into two entries sharing message.id; only the last keeps usage
+	jsonlContent := `{"type":"user","uuid":"msg1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Read the file"}}
+{"type":"assistant","uuid":"msg2","parentUuid":"msg1","timestamp":"2025-12-29T10:00:01.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"text","text":"Reading it"}],"usage":{"input_tokens":10,"output_tokens":1,"cache_creation_input_tokens":200,"cache_read_input_tokens":3000}}}
+{"type":"assistant","uuid":"msg3","parentUuid":"msg2","timestamp":"2025-12-29T10:00:02.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Read","input":{}}],"usage":{"input_tokens":10,"output_tokens":42,"cache_creation_input_tokens":200,"cache_read_input_tokens":3000}}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(session.Messages))
+	}
+	if session.Messages[0].Usage != nil {
+		t.Error("Expected user message to have no usage")
+	}
+	if session.Messages[1].Usage != nil {
+		t.Error("Expected usage of split response to be dropped from the earlier entry")
+	}
+
+	usage := session.Messages[2].Usage
+	if usage == nil {
+		t.Fatal("Expected last entry of response to carry usage")
+	}
+	want := Usage{InputTokens: 10, OutputTokens: 42, CacheCreationInputTokens: 200, CacheReadInputTokens: 3000}
+	if *usage != want {
+		t.Errorf("Usage = %+v, want %+v", *usage, want)
+	}
+	if usage.Total() != 3252 {
+		t.Errorf("Usage.Total() = %d, want 3252", usage.Total())
+	}
+}
+
+func TestParseSession_WithThinking(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"assistant","uuid":"msg1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"assistant","content":[{"type":"thinking","thinking":"Consider the edge cases","signature":"sig"},{"type":"redacted_thinking","data":"opaque"},{"type":"text","text":"Done"}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.Messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(session.Messages))
+	}
+
+	content := session.Messages[0].Content
+	if len(content) != 3 {
+		t.Fatalf("Expected 3 content blocks, got %d", len(content))
+	}
+	if content[0].Type != "thinking" || content[0].Text != "Consider the edge cases" {
+		t.Errorf("Expected thinking block with reasoning, got %+v", content[0])
+	}
+	if content[1].Type != "redacted_thinking" || content[1].Text != "" {
+		t.Errorf("Expected empty redacted_thinking block, got %+v", content[1])
+	}
+}
+
+func TestParseSession_WithImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	// "iVBORw0KGgo=" is the base64 PNG signature
+	jsonlContent := `{"type":"user","uuid":"msg1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}},{"type":"document","title":"notes","source":{"type":"text","media_type":"text/plain","data":"hello"}},{"type":"text","text":"See screenshot"}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	content := session.Messages[0].Content
+	if len(content) != 3 {
+		t.Fatalf("Expected 3 content blocks, got %d", len(content))
+	}
+
+	image := content[0]
+	if image.Type != "image" || image.MediaType != "image/png" {
+		t.Errorf("Expected image/png block, got type %q media type %q", image.Type, image.MediaType)
+	}
+	if string(image.Data) != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("Expected decoded PNG signature, got %q", image.Data)
+	}
+
+	doc := content[1]
+	if doc.Type != "document" || doc.Title != "notes" || string(doc.Data) != "hello" {
+		t.Errorf("Expected text document 'notes', got %+v", doc)
+	}
+}
+
+func TestParseSession_StructuredToolResult(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"user","uuid":"msg1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"Screenshot taken"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}},{"type":"text","text":"1280x720"}]}]}}
+{"type":"user","uuid":"msg2","parentUuid":"msg1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":"Exit code 127\ncommand not found: gofmtx"}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	result := session.Messages[0].Content[0]
+	if result.ToolOutput != "Screenshot taken\n1280x720" {
+		t.Errorf("ToolOutput = %q, want the text parts joined", result.ToolOutput)
+	}
+	if len(result.Parts) != 1 || result.Parts[0].Type != "image" || string(result.Parts[0].Data) != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("Expected one decoded image part, got %+v", result.Parts)
+	}
+	if result.IsError {
+		t.Errorf("Successful result marked as error")
+	}
+
+	failed := session.Messages[1].Content[0]
+	if !failed.IsError || failed.ToolOutput != "Exit code 127\ncommand not found: gofmtx" {
+		t.Errorf("Expected failed result with output, got %+v", failed)
+	}
+	if session.ToolErrors() != 1 {
+		t.Errorf("ToolErrors() = %d, want 1", session.ToolErrors())
+	}
+}
+
+func TestParseSession_ToolCalls(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"assistant","uuid":"a1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}},{"type":"tool_use","id":"t2","name":"Read","input":{"file_path":"/x"}}]}}
+{"type":"user","uuid":"u1","parentUuid":"a1","timestamp":"2025-12-29T10:00:01.500Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"main.go"}]}}
+{"type":"user","uuid":"u2","parentUuid":"u1","timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":"File does not exist."},{"type":"tool_result","tool_use_id":"t9","content":"orphan"}]}}
+{"type":"assistant","uuid":"a2","parentUuid":"u2","timestamp":"2025-12-29T10:00:03.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t3","name":"Grep","input":{"pattern":"x"}}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.ToolCalls) != 3 {
+		t.Fatalf("Expected 3 tool calls (orphan result ignored), got %d", len(session.ToolCalls))
+	}
+
+	bash := session.ToolCalls[0]
+	if bash.Name != "Bash" || bash.Output != "main.go" || bash.Status() != ToolCallOK {
+		t.Errorf("Unexpected Bash call: %+v", bash)
+	}
+	if bash.Duration() != 1500*time.Millisecond {
+		t.Errorf("Bash duration = %v, want 1.5s", bash.Duration())
+	}
+	if bash.UseUUID != "a1" || bash.ResultUUID != "u1" {
+		t.Errorf("Expected call in a1 answered by u1, got %s -> %s", bash.UseUUID, bash.ResultUUID)
+	}
+
+	read := session.ToolCalls[1]
+	if read.Status() != ToolCallError || read.Output != "File does not exist." {
+		t.Errorf("Expected failed Read call, got %+v", read)
+	}
+
+	grep := session.ToolCalls[2]
+	if grep.Status() != ToolCallPending || grep.Duration() != 0 {
+		t.Errorf("Expected pending Grep call without duration, got %+v", grep)
+	}
+}
+
 func TestParseSession_MalformedJSON(t *testing.T) {
 	tmpDir := t.TempDir()
 	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
@@ -301,6 +505,273 @@ func TestListSessions(t *testing.T) {
 	}
 }
 
+func TestListSessions_Subagents(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-test-project")
+	subagentDir := filepath.Join(projectDir, "parent", "subagents")
+	if err := os.MkdirAll(subagentDir, 0755); err != nil {
+		t.Fatalf("Failed to create subagent directory: %v", err)
+	}
+
+	// Parent session spawns two Tasks; the first result names its agent, the second does not
+	parent := `{"type":"user","uuid":"u1","sessionId":"parent","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Investigate"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"parent","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"task1","name":"Task","input":{"description":"Find the parser","prompt":"Locate parser.go"}},{"type":"tool_use","id":"task2","name":"Task","input":{"description":"Check the tests","prompt":"Run the tests"}}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","sessionId":"parent","timestamp":"2025-12-29T10:01:00.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"task1","content":"Found it"}]},"toolUseResult":{"status":"completed","agentId":"a1b2"}}
+`
+	// Newer layout: <session>/subagents/agent-<id>.jsonl, linked by agent ID
+	nested := `{"type":"user","uuid":"s1","isSidechain":true,"agentId":"a1b2","sessionId":"parent","timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"user","content":"Locate parser.go"}}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"agentId":"a1b2","sessionId":"parent","timestamp":"2025-12-29T10:00:03.000Z","message":{"role":"assistant","content":[{"type":"text","text":"It is in the root"}]}}
+`
+	// Older layout: agent-<id>.jsonl next to the sessions, linked by prompt
+	flat := `{"type":"user","uuid":"t1","isSidechain":true,"sessionId":"parent","timestamp":"2025-12-29T10:00:04.000Z","message":{"role":"user","content":"Run the tests"}}
+`
+
+	files := map[string]string{
+		filepath.Join(projectDir, "parent.jsonl"):      parent,
+		filepath.Join(subagentDir, "agent-a1b2.jsonl"): nested,
+		filepath.Join(projectDir, "agent-c3d4.jsonl"):  flat,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	project := &Project{FolderName: "-Users-test-project", Path: "/Users/test/project"}
+	sessions, err := ListSessions(tmpDir, project)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+
+	if len(sessions) != 1 {
+		t.Fatalf("Expected subagent transcripts to be grouped under 1 session, got %d", len(sessions))
+	}
+	subagents := sessions[0].Subagents
+	if len(subagents) != 2 {
+		t.Fatalf("Expected 2 subagents, got %d", len(subagents))
+	}
+
+	want := []struct{ id, toolUseID, summary string }{
+		{"agent-a1b2", "task1", "Find the parser"},
+		{"agent-c3d4", "task2", "Check the tests"},
+	}
+	for i, w := range want {
+		if subagents[i].ID != w.id || subagents[i].ParentToolUseID != w.toolUseID || subagents[i].Summary != w.summary {
+			t.Errorf("Subagent %d = {%s %s %q}, want {%s %s %q}", i,
+				subagents[i].ID, subagents[i].ParentToolUseID, subagents[i].Summary, w.id, w.toolUseID, w.summary)
+		}
+	}
+}
+
+func TestParseSession_InlineSidechain(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Main prompt"}}
+{"type":"user","uuid":"s1","isSidechain":true,"timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"user","content":"Sub prompt"}}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Sub answer"}]}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:03.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Main answer"}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.Messages) != 2 {
+		t.Errorf("Expected 2 main messages, got %d", len(session.Messages))
+	}
+	if session.ParentSessionID != "" {
+		t.Errorf("Main session should have no parent, got %q", session.ParentSessionID)
+	}
+	if len(session.Subagents) != 1 || len(session.Subagents[0].Messages) != 2 {
+		t.Fatalf("Expected 1 sidechain with 2 messages, got %+v", session.Subagents)
+	}
+	if session.Subagents[0].Summary != "Sub prompt" {
+		t.Errorf("Sidechain summary = %q, want %q", session.Subagents[0].Summary, "Sub prompt")
+	}
+}
+
+func TestListSessions_ResumedSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-test-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create project directory: %v", err)
+	}
+
+	original := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Start"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Started"}]}}
+`
+	// --resume replays the earlier messages with their original timestamps
+	resumed := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Start"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Started"}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-12-30T09:00:00.000Z","message":{"role":"user","content":"Carry on"}}
+`
+	// --continue starts a new file whose summary points at the resumed session's leaf
+	continued := `{"type":"summary","summary":"Carrying on","leafUuid":"u2"}
+{"type":"user","uuid":"u3","timestamp":"2025-12-31T09:00:00.000Z","message":{"role":"user","content":"Finish up"}}
+`
+	unrelated := `{"type":"user","uuid":"x1","timestamp":"2025-12-30T12:00:00.000Z","message":{"role":"user","content":"Other"}}
+`
+
+	// File names sort in the opposite order of the lineage
+	files := map[string]string{
+		"c-original.jsonl":  original,
+		"b-resumed.jsonl":   resumed,
+		"a-continued.jsonl": continued,
+		"d-unrelated.jsonl": unrelated,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	project := &Project{FolderName: "-Users-test-project", Path: "/Users/test/project"}
+	sessions, err := ListSessions(tmpDir, project)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+
+	byID := make(map[string]Session)
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+	want := []struct{ id, predecessor, conversation string }{
+		{"c-original", "", "c-original"},
+		{"b-resumed", "c-original", "c-original"},
+		{"a-continued", "b-resumed", "c-original"},
+		{"d-unrelated", "", "d-unrelated"},
+	}
+	for _, w := range want {
+		s := byID[w.id]
+		if s.PredecessorID != w.predecessor || s.ConversationID != w.conversation {
+			t.Errorf("%s: predecessor=%q conversation=%q, want %q %q",
+				w.id, s.PredecessorID, s.ConversationID, w.predecessor, w.conversation)
+		}
+	}
+	if got := byID["c-original"].SuccessorIDs; len(got) != 1 || got[0] != "b-resumed" {
+		t.Errorf("c-original successors = %v, want [b-resumed]", got)
+	}
+
+	conversations := GroupConversations(sessions)
+	if len(conversations) != 2 {
+		t.Fatalf("Expected 2 conversations, got %d", len(conversations))
+	}
+	// Most recently active first, sessions oldest first
+	chain := conversations[0]
+	if chain.ID != "c-original" || len(chain.Sessions) != 3 {
+		t.Fatalf("First conversation = %s with %d sessions, want c-original with 3", chain.ID, len(chain.Sessions))
+	}
+	for i, id := range []string{"c-original", "b-resumed", "a-continued"} {
+		if chain.Sessions[i].ID != id {
+			t.Errorf("Conversation session %d = %s, want %s", i, chain.Sessions[i].ID, id)
+		}
+	}
+}
+
+func TestParseSession_Environment(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"user","uuid":"u1","gitBranch":"main","version":"1.0.80","userType":"external","permissionMode":"default","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Start"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","gitBranch":"main","version":"1.0.80","userType":"external","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"assistant","content":[{"type":"text","text":"OK"}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","gitBranch":"feature/x","version":"1.0.81","userType":"external","permissionMode":"acceptEdits","timestamp":"2025-12-29T10:05:00.000Z","message":{"role":"user","content":"Switched"}}
+{"type":"assistant","uuid":"a2","parentUuid":"u2","gitBranch":"feature/x","version":"1.0.81","userType":"external","timestamp":"2025-12-29T10:05:01.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if session.GitBranch != "feature/x" || session.Version != "1.0.81" || session.UserType != "external" {
+		t.Errorf("Session environment = %q %q %q, want feature/x 1.0.81 external",
+			session.GitBranch, session.Version, session.UserType)
+	}
+	// Assistant entries don't carry the mode; the last one reported is kept
+	if session.PermissionMode != "acceptEdits" {
+		t.Errorf("PermissionMode = %q, want acceptEdits", session.PermissionMode)
+	}
+	if len(session.GitBranches) != 2 || session.GitBranches[0] != "main" || session.GitBranches[1] != "feature/x" {
+		t.Errorf("GitBranches = %v, want [main feature/x]", session.GitBranches)
+	}
+	if !session.OnBranch("main") || session.OnBranch("release") {
+		t.Errorf("OnBranch gave wrong answers for %v", session.GitBranches)
+	}
+	if session.Messages[0].GitBranch != "main" || session.Messages[2].PermissionMode != "acceptEdits" {
+		t.Errorf("Per-message environment not recorded: %+v", session.Messages[0])
+	}
+}
+
+func TestParseSession_SystemEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"<command-name>/model</command-name>\n<command-message>model</command-message>\n<command-args>opus</command-args>"}}
+{"type":"user","uuid":"u2","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"user","content":"<local-command-stdout>Set model to opus</local-command-stdout>"}}
+{"type":"system","uuid":"s1","parentUuid":"u2","level":"info","timestamp":"2025-12-29T10:00:02.000Z","content":"PostToolUse:Edit [gofmt -w] completed successfully"}
+{"type":"system","uuid":"s2","parentUuid":"s1","level":"error","timestamp":"2025-12-29T10:00:03.000Z","content":"API Error: Request timed out"}
+{"type":"system","subtype":"compact_boundary","uuid":"s3","parentUuid":null,"logicalParentUuid":"s2","timestamp":"2025-12-29T10:00:04.000Z","content":"Conversation compacted","compactMetadata":{"trigger":"manual","preTokens":90000}}
+{"type":"user","uuid":"u3","parentUuid":"s3","timestamp":"2025-12-29T10:00:05.000Z","message":{"role":"user","content":"Real prompt"}}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.Messages) != 6 {
+		t.Fatalf("Expected 6 messages, got %d", len(session.Messages))
+	}
+
+	want := []struct{ kind, level, text string }{
+		{EventCommand, "", "/model opus"},
+		{EventCommand, "", "Set model to opus"},
+		{EventHook, "info", "PostToolUse:Edit [gofmt -w] completed successfully"},
+		{EventNotice, "error", "API Error: Request timed out"},
+		{EventCompact, "", "Conversation compacted"},
+	}
+	for i, w := range want {
+		ev := session.Messages[i].Event
+		if ev == nil {
+			t.Errorf("Message %d: expected a %s event", i, w.kind)
+			continue
+		}
+		if ev.Kind != w.kind || ev.Level != w.level || ev.Text != w.text || session.Messages[i].Role != "system" {
+			t.Errorf("Message %d event = %+v (role %s), want %s/%s %q", i, ev, session.Messages[i].Role, w.kind, w.level, w.text)
+		}
+	}
+	if ev := session.Messages[4].Event; ev.Trigger != "manual" || ev.PreTokens != 90000 {
+		t.Errorf("Compact metadata = %s/%d, want manual/90000", ev.Trigger, ev.PreTokens)
+	}
+	if session.Messages[4].ParentUUID != "s2" {
+		t.Errorf("Compact boundary parent = %q, want logical parent s2", session.Messages[4].ParentUUID)
+	}
+	if session.Messages[5].Event != nil {
+		t.Errorf("Regular prompt parsed as an event")
+	}
+	// Commands don't become the session title
+	if session.Summary != "Real prompt" {
+		t.Errorf("Summary = %q, want %q", session.Summary, "Real prompt")
+	}
+	if session.MessageCount() != 1 || session.Compactions() != 1 {
+		t.Errorf("MessageCount=%d Compactions=%d, want 1 and 1", session.MessageCount(), session.Compactions())
+	}
+}
+
 func TestDefaultClaudeProjectsPath(t *testing.T) {
 	path, err := DefaultClaudeProjectsPath()
 	if err != nil {
@@ -316,3 +787,81 @@ func TestDefaultClaudeProjectsPath(t *testing.T) {
 		t.Errorf("Expected absolute path, got %q", path)
 	}
 }
+
+// writeCorpus writes a projects directory of generated sessions, each a prompt followed
+// by turns of tool calls and results
+func writeCorpus(tb testing.TB, dir string, projects, sessions, turns int) {
+	tb.Helper()
+	for p := 0; p < projects; p++ {
+		projectDir := filepath.Join(dir, fmt.Sprintf("-Users-test-project%d", p))
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			tb.Fatalf("Failed to create project directory: %v", err)
+		}
+		for s := 0; s < sessions; s++ {
+			var b strings.Builder
+			start := time.Date(2025, 12, 1+s%28, 10, p, 0, 0, time.UTC)
+			fmt.Fprintf(&b, `{"type":"user","uuid":"p%d-s%d-u0","cwd":"/Users/test/project%d","timestamp":%q,"message":{"role":"user","content":"Task %d of project %d"}}`+"\n",
+				p, s, p, start.Format(time.RFC3339), s, p)
+			parent := fmt.Sprintf("p%d-s%d-u0", p, s)
+			for t := 1; t <= turns; t++ {
+				at := start.Add(time.Duration(t) * time.Second).Format(time.RFC3339)
+				fmt.Fprintf(&b, `{"type":"assistant","uuid":"p%d-s%d-a%d","parentUuid":%q,"timestamp":%q,"message":{"id":"resp_%d","role":"assistant","content":[{"type":"tool_use","id":"tool%d","name":"Bash","input":{"command":"go test ./..."}}],"usage":{"input_tokens":100,"output_tokens":20}}}`+"\n",
+					p, s, t, parent, at, t, t)
+				fmt.Fprintf(&b, `{"type":"user","uuid":"p%d-s%d-u%d","parentUuid":"p%d-s%d-a%d","timestamp":%q,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool%d","content":%q}]}}`+"\n",
+					p, s, t, p, s, t, at, t, strings.Repeat("ok  \tpackage\t0.01s\n", 20))
+				parent = fmt.Sprintf("p%d-s%d-u%d", p, s, t)
+			}
+			path := filepath.Join(projectDir, fmt.Sprintf("session-%03d.jsonl", s))
+			if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+				tb.Fatalf("Failed to write %s: %v", path, err)
+			}
+		}
+	}
+}
+
+func TestLoadAllProjects_Jobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeCorpus(t, tmpDir, 4, 6, 3)
+
+	// Parallel loading gives the same projects and sessions, in the same order
+	summarize := func(projects []Project) []string {
+		var out []string
+		for _, p := range projects {
+			for _, s := range p.Sessions {
+				out = append(out, fmt.Sprintf("%s/%s:%d", p.Path, s.ID, s.MessageCount()))
+			}
+		}
+		return out
+	}
+	var want []string
+	for _, jobs := range []int{1, 3, 16} {
+		projects, err := LoadAllProjectMetas(tmpDir, LoadOptions{Jobs: jobs})
+		if err != nil {
+			t.Fatalf("LoadAllProjectMetas(jobs=%d) failed: %v", jobs, err)
+		}
+		got := summarize(projects)
+		if len(got) != 24 {
+			t.Fatalf("jobs=%d: expected 24 sessions, got %d", jobs, len(got))
+		}
+		if want == nil {
+			want = got
+		} else if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("jobs=%d loaded\n%v\nwant\n%v", jobs, got, want)
+		}
+	}
+}
+
+func BenchmarkLoadAllProjectMetas(b *testing.B) {
+	tmpDir := b.TempDir()
+	writeCorpus(b, tmpDir, 20, 25, 40)
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := LoadAllProjectMetas(tmpDir, LoadOptions{Jobs: jobs}); err != nil {
+					b.Fatalf("LoadAllProjectMetas failed: %v", err)
+				}
+			}
+		})
+	}
+}
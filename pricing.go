@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PriceTable resolves model prices, optionally varying over time
+type PriceTable struct {
+	prices map[string][]datedPrice // model family -> prices sorted by effective date
+}
+
+// datedPrice is a price valid from an effective date (zero = always)
+type datedPrice struct {
+	effective time.Time
+	price     ModelPrice
+}
+
+// pricingFile is the YAML layout of a pricing override file
+type pricingFile struct {
+	Prices []pricingFileEntry `yaml:"prices"`
+}
+
+// pricingFileEntry is a single price override in a pricing file.
+// Cache rates left at zero are derived from the input price.
+type pricingFileEntry struct {
+	Model        string  `yaml:"model"`     // Model family ("opus-4-1", "sonnet") or full model ID
+	Effective    string  `yaml:"effective"` // YYYY-MM-DD the price applies from (optional)
+	Input        float64 `yaml:"input"`
+	Output       float64 `yaml:"output"`
+	CacheWrite5m float64 `yaml:"cache_write_5m"`
+	CacheWrite1h float64 `yaml:"cache_write_1h"`
+	CacheRead    float64 `yaml:"cache_read"`
+}
+
+// DefaultPricingPath returns the default location of the pricing override file
+func DefaultPricingPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "claude-code-logs", "pricing.yaml"), nil
+}
+
+// DefaultPriceTable returns a price table with the built-in ModelPricing
+func DefaultPriceTable() *PriceTable {
+	t := &PriceTable{prices: make(map[string][]datedPrice)}
+	for family, price := range ModelPricing {
+		t.set(family, time.Time{}, price)
+	}
+	return t
+}
+
+// LoadPriceTable loads a pricing file and applies it on top of the built-in prices
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading pricing file: %w", err)
+	}
+
+	var file pricingFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing pricing file %s: %w", path, err)
+	}
+
+	t := DefaultPriceTable()
+	for i, entry := range file.Prices {
+		family := ModelFamily(entry.Model)
+		if family == "" {
+			return nil, fmt.Errorf("pricing entry %d: unknown model %q", i+1, entry.Model)
+		}
+
+		var effective time.Time
+		if entry.Effective != "" {
+			effective, err = time.Parse("2006-01-02", entry.Effective)
+			if err != nil {
+				return nil, fmt.Errorf("pricing entry %d: invalid effective date %q (want YYYY-MM-DD)", i+1, entry.Effective)
+			}
+		}
+
+		t.set(family, effective, entry.modelPrice())
+	}
+
+	return t, nil
+}
+
+// LoadPricing loads the pricing table for the CLI.
+// An explicit path must exist; the default path is optional and falls back to built-in prices.
+func LoadPricing(path string) (*PriceTable, error) {
+	if path != "" {
+		return LoadPriceTable(path)
+	}
+
+	defaultPath, err := DefaultPricingPath()
+	if err != nil {
+		return DefaultPriceTable(), nil
+	}
+	if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
+		return DefaultPriceTable(), nil
+	}
+	return LoadPriceTable(defaultPath)
+}
+
+// modelPrice converts a file entry to a ModelPrice, deriving missing cache rates
+// from the input price with Anthropic's standard multipliers
+func (e pricingFileEntry) modelPrice() ModelPrice {
+	price := ModelPrice{
+		Input:        e.Input,
+		Output:       e.Output,
+		CacheWrite5m: e.CacheWrite5m,
+		CacheWrite1h: e.CacheWrite1h,
+		CacheRead:    e.CacheRead,
+	}
+	if price.CacheWrite5m == 0 {
+		price.CacheWrite5m = price.Input * 1.25
+	}
+	if price.CacheWrite1h == 0 {
+		price.CacheWrite1h = price.Input * 2
+	}
+	if price.CacheRead == 0 {
+		price.CacheRead = price.Input * 0.1
+	}
+	return price
+}
+
+// set adds or replaces the price of a family from an effective date
+func (t *PriceTable) set(family string, effective time.Time, price ModelPrice) {
+	entries := t.prices[family]
+	for i := range entries {
+		if entries[i].effective.Equal(effective) {
+			entries[i].price = price
+			return
+		}
+	}
+
+	entries = append(entries, datedPrice{effective: effective, price: price})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].effective.Before(entries[j].effective)
+	})
+	t.prices[family] = entries
+}
+
+// lookup returns the price of a family valid at the given time
+func (t *PriceTable) lookup(family string, at time.Time) (ModelPrice, bool) {
+	entries := t.prices[family]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].effective.After(at) {
+			return entries[i].price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// PriceAt returns the prices for a model ID valid at the given time, falling back
+// to the bare family name and then to DefaultModelFamily
+func (t *PriceTable) PriceAt(model string, at time.Time) ModelPrice {
+	family := ModelFamily(model)
+	if price, ok := t.lookup(family, at); ok {
+		return price
+	}
+	if base := modelBaseFamily(family); base != family {
+		if price, ok := t.lookup(base, at); ok {
+			return price
+		}
+	}
+	price, _ := t.lookup(DefaultModelFamily, at)
+	return price
+}
+
+// Cost computes the API cost in USD of a message's usage at the price valid at its timestamp
+func (t *PriceTable) Cost(model string, at time.Time, usage Usage) float64 {
+	return t.PriceAt(model, at).Cost(usage)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPriceTable_EffectiveDates(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "pricing.yaml")
+
+	content := `prices:
+  - model: sonnet
+    effective: 2026-01-01
+    input: 2
+    output: 10
+  - model: claude-opus-4-1-20250805
+    input: 12
+    output: 60
+    cache_read: 1
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write pricing file: %v", err)
+	}
+
+	table, err := LoadPriceTable(path)
+	if err != nil {
+		t.Fatalf("LoadPriceTable failed: %v", err)
+	}
+
+	before := time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC)
+	after := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	million := Usage{InputTokens: 1_000_000}
+
+	tests := []struct {
+		name     string
+		model    string
+		at       time.Time
+		expected float64
+	}{
+		{"sonnet before override uses built-in", "claude-sonnet-4-5-20250929", before, 3},
+		{"sonnet after override", "claude-sonnet-4-5-20250929", after, 2},
+		{"undated override replaces built-in", "claude-opus-4-1-20250805", before, 12},
+		{"unknown model follows default family", "", after, 2},
+		{"untouched family keeps built-in", "claude-haiku-4-5", after, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := table.Cost(tt.model, tt.at, million)
+			if math.Abs(got-tt.expected) > 0.0001 {
+				t.Errorf("Cost(%q, %v) = %v, want %v", tt.model, tt.at, got, tt.expected)
+			}
+		})
+	}
+
+	// Missing cache rates are derived from the input price; explicit ones are kept
+	sonnet := table.PriceAt("sonnet", after)
+	if math.Abs(sonnet.CacheWrite5m-2.5) > 0.0001 || math.Abs(sonnet.CacheWrite1h-4) > 0.0001 || math.Abs(sonnet.CacheRead-0.2) > 0.0001 {
+		t.Errorf("Derived cache rates = %+v, want 2.5/4/0.2", sonnet)
+	}
+	if opus := table.PriceAt("opus-4-1", before); opus.CacheRead != 1 {
+		t.Errorf("Explicit cache_read = %v, want 1", opus.CacheRead)
+	}
+}
+
+func TestLoadPriceTable_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown model", "prices:\n  - model: gpt-4\n    input: 1\n"},
+		{"bad effective date", "prices:\n  - model: sonnet\n    effective: 01/02/2026\n    input: 1\n"},
+		{"invalid yaml", "prices: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, "pricing.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write pricing file: %v", err)
+			}
+			if _, err := LoadPriceTable(path); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := LoadPricing(filepath.Join(tmpDir, "missing.yaml")); err == nil {
+		t.Error("Expected error for explicit missing pricing file")
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Claude Code names a project folder after its directory, with every character other
+// than an ASCII letter or digit replaced by a dash. The encoding can't be reversed:
+// "/Users/me/my-app" and "/Users/me/my/app" share a folder name. Project paths are
+// taken from the cwd of their sessions, else looked up on disk, else decoded.
+
+// encodedChars matches the characters Claude Code replaces in project folder names
+var encodedChars = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// windowsFolder matches the folder name of a Windows path, e.g. "C--Users-me-app"
+var windowsFolder = regexp.MustCompile(`^([A-Za-z])--(.*)$`)
+
+// EncodeProjectPath returns the folder name Claude Code uses for a project path
+// Example: "/Users/me/my.app" -> "-Users-me-my-app"
+func EncodeProjectPath(path string) string {
+	return encodedChars.ReplaceAllString(path, "-")
+}
+
+// resolveProjectPath returns the path of the project in folderName, given the cwd of
+// each of its sessions, newest first. The most common cwd that encodes to folderName
+// wins; sessions started elsewhere only count when none does.
+func resolveProjectPath(folderName string, cwds []string) string {
+	if path := mostCommon(cwds, func(cwd string) bool { return EncodeProjectPath(cwd) == folderName }); path != "" {
+		return path
+	}
+	if path := findProjectPath(folderName); path != "" {
+		return path
+	}
+	if path := mostCommon(cwds, func(string) bool { return true }); path != "" {
+		return path
+	}
+	return DecodeProjectPath(folderName)
+}
+
+// mostCommon returns the most frequent non-empty value accepted by keep, the first one
+// on ties, or ""
+func mostCommon(values []string, keep func(string) bool) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, v := range values {
+		if v == "" || !keep(v) {
+			continue
+		}
+		counts[v]++
+		if best == "" || counts[v] > counts[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// foundProjectPaths remembers what findProjectPath found for each folder name, including
+// "" for none, so the watcher doesn't walk the disk again each time it reloads a project
+var foundProjectPaths = struct {
+	sync.Mutex
+	paths map[string]string
+}{paths: make(map[string]string)}
+
+// findProjectPath looks for the directory named by folderName on disk, following at
+// each level the entries whose encoded name starts the rest of the folder name. It
+// returns "" if there is none. The answer is looked up once per folder name.
+func findProjectPath(folderName string) string {
+	foundProjectPaths.Lock()
+	path, ok := foundProjectPaths.paths[folderName]
+	foundProjectPaths.Unlock()
+	if ok {
+		return path
+	}
+
+	path = lookupProjectPath(folderName)
+	foundProjectPaths.Lock()
+	foundProjectPaths.paths[folderName] = path
+	foundProjectPaths.Unlock()
+	return path
+}
+
+// lookupProjectPath walks the disk for findProjectPath
+func lookupProjectPath(folderName string) string {
+	root, rest := "", ""
+	switch {
+	case strings.HasPrefix(folderName, "-"):
+		root, rest = "/", folderName[1:]
+	case windowsFolder.MatchString(folderName) && runtime.GOOS == "windows":
+		m := windowsFolder.FindStringSubmatch(folderName)
+		root, rest = m[1]+`:\`, m[2]
+	default:
+		return ""
+	}
+	if rest == "" {
+		return root
+	}
+	return findProjectDir(root, rest, 0)
+}
+
+// maxProjectDepth bounds the directories followed when looking for a project on disk
+const maxProjectDepth = 32
+
+// findProjectDir finds the directory under dir whose encoded path below dir is rest
+func findProjectDir(dir, rest string, depth int) string {
+	if depth > maxProjectDepth {
+		return ""
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() {
+			// Symlinked directories count too
+			info, err := os.Stat(path)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		name := EncodeProjectPath(entry.Name())
+		if name == rest {
+			return path
+		}
+		if strings.HasPrefix(rest, name+"-") {
+			if found := findProjectDir(path, rest[len(name)+1:], depth+1); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+// assignProjectSlugs gives every project a slug of its own. Projects whose paths make
+// the same slug, or that share a path, keep it in order of their source and folder
+// names; the others get a suffix made from those, so their output directories and
+// URLs don't overwrite each other and stay the same between runs. Projects that already
+// have a slug, like those kept by the watcher while it reloads another, keep it.
+func assignProjectSlugs(projects []Project) {
+	// Sources rank in the order they were loaded
+	rank := make(map[string]int)
+	for _, p := range projects {
+		if _, ok := rank[p.SourceDir]; !ok {
+			rank[p.SourceDir] = len(rank)
+		}
+	}
+
+	order := make([]int, len(projects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := &projects[order[a]], &projects[order[b]]
+		if rank[pa.SourceDir] != rank[pb.SourceDir] {
+			return rank[pa.SourceDir] < rank[pb.SourceDir]
+		}
+		return pa.FolderName < pb.FolderName
+	})
+
+	taken := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		if p.slug != "" {
+			taken[p.slug] = true
+		}
+	}
+	for _, i := range order {
+		project := &projects[i]
+		if project.slug != "" {
+			continue
+		}
+		slug := ProjectSlug(project.Path)
+		if taken[slug] {
+			key := project.FolderName
+			if project.Source != "" {
+				key = project.Source + "/" + key
+			}
+			sum := sha256.Sum256([]byte(key))
+			hash := hex.EncodeToString(sum[:])
+			base := slug
+			slug = base + "-" + hash[:6]
+			if taken[slug] {
+				slug = base + "-" + hash
+			}
+		}
+		taken[slug] = true
+		project.slug = slug
+	}
+}
+
+// Slug returns the project's output directory and URL name: the one assigned when it
+// was loaded with the other projects, else ProjectSlug of its path
+func (p Project) Slug() string {
+	if p.slug != "" {
+		return p.slug
+	}
+	return ProjectSlug(p.Path)
+}
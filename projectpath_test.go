@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeProjectPath(t *testing.T) {
+	tests := map[string]string{
+		"/Users/me/my-app":      "-Users-me-my-app",
+		"/Users/me/.config/app": "-Users-me--config-app",
+		"/Users/me/my_app v2":   "-Users-me-my-app-v2",
+		`C:\Users\me\app`:       "C--Users-me-app",
+	}
+	for path, want := range tests {
+		if got := EncodeProjectPath(path); got != want {
+			t.Errorf("EncodeProjectPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestResolveProjectPath(t *testing.T) {
+	// Dashes and dots in directory names, which decoding turns into separators
+	root := t.TempDir()
+	for _, dir := range []string{"code/my-app", "code/my/other", ".config/tool.d"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	myApp := filepath.Join(root, "code", "my-app")
+	tool := filepath.Join(root, ".config", "tool.d")
+
+	tests := []struct {
+		name   string
+		folder string
+		cwds   []string
+		want   string
+	}{
+		{
+			name:   "most common matching cwd",
+			folder: EncodeProjectPath("/Users/me/my-app"),
+			cwds:   []string{"/Users/me/my/app", "/Users/me/my-app", "/Users/me/my-app", "", "/Users/me/my/app/sub"},
+			want:   "/Users/me/my-app",
+		},
+		{
+			name:   "found on disk",
+			folder: EncodeProjectPath(myApp),
+			want:   myApp,
+		},
+		{
+			name:   "dotted names found on disk",
+			folder: EncodeProjectPath(tool),
+			want:   tool,
+		},
+		{
+			name:   "cwd started elsewhere",
+			folder: "-nowhere-project",
+			cwds:   []string{"/somewhere/else"},
+			want:   "/somewhere/else",
+		},
+		{
+			name:   "decoded",
+			folder: "-nowhere-project",
+			want:   "/nowhere/project",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveProjectPath(tt.folder, tt.cwds); got != tt.want {
+				t.Errorf("resolveProjectPath(%q) = %q, want %q", tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProjectPath_Cached(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cached-app")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	folder := EncodeProjectPath(dir)
+	if got := findProjectPath(folder); got != dir {
+		t.Fatalf("findProjectPath(%q) = %q, want %q", folder, got, dir)
+	}
+
+	// Later lookups, like the watcher's on every reload, don't walk the disk again
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := findProjectPath(folder); got != dir {
+		t.Errorf("findProjectPath(%q) after removal = %q, want the remembered %q", folder, got, dir)
+	}
+}
+
+func TestAssignProjectSlugs(t *testing.T) {
+	projects := []Project{
+		{FolderName: "-Users-me-my-app", Path: "/Users/me/my/app"},
+		{FolderName: "-Users-me-other", Path: "/Users/me/other"},
+		{FolderName: "-Users-me-my-app-", Path: "/Users/me/my-app"},
+		{FolderName: "-Users-me-my--app", Path: "/Users/me/my-app"},
+	}
+	assignProjectSlugs(projects)
+
+	if got := projects[3].Slug(); got != "users-me-my-app" {
+		t.Errorf("First folder by name got slug %q, want users-me-my-app", got)
+	}
+	if got := projects[1].Slug(); got != "users-me-other" {
+		t.Errorf("Unambiguous project got slug %q, want users-me-other", got)
+	}
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		if seen[p.Slug()] {
+			t.Errorf("Slug %q assigned twice", p.Slug())
+		}
+		seen[p.Slug()] = true
+	}
+
+	// Slugs don't depend on the order projects are listed in
+	reversed := []Project{projects[3], projects[2], projects[1], projects[0]}
+	for i := range reversed {
+		reversed[i].slug = ""
+	}
+	assignProjectSlugs(reversed)
+	for i, p := range reversed {
+		if want := projects[3-i].Slug(); p.Slug() != want {
+			t.Errorf("%s got slug %q after reordering, want %q", p.FolderName, p.Slug(), want)
+		}
+	}
+
+	// Projects that weren't loaded together fall back to the slug of their path
+	if got := (Project{Path: "/Users/me/my-app"}).Slug(); got != "users-me-my-app" {
+		t.Errorf("Slug() = %q, want users-me-my-app", got)
+	}
+}
+
+func TestLoadAllProjects_SlugCollision(t *testing.T) {
+	sourceDir := t.TempDir()
+	outputDir := t.TempDir()
+
+	// Two folders whose sessions ran in paths that make the same slug
+	for folder, cwd := range map[string]string{"-work-my-app": "/work/my-app", "-work-my-app-x": "/work/my/app"} {
+		dir := filepath.Join(sourceDir, folder)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		line := `{"type":"user","uuid":"u1","cwd":"` + cwd + `","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Hello"}}` + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(line), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	projects, err := LoadAllProjectMetas(sourceDir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetas failed: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Slug() == projects[1].Slug() {
+		t.Fatalf("Projects %+v don't have slugs of their own", projects)
+	}
+
+	if _, err := GenerateAllMarkdown(projects, outputDir, []Source{{Path: sourceDir}}, false, nil, nil, OrphanKeep); err != nil {
+		t.Fatalf("GenerateAllMarkdown failed: %v", err)
+	}
+	for _, p := range projects {
+		if _, err := os.Stat(filepath.Join(outputDir, p.Slug(), "session.md")); err != nil {
+			t.Errorf("Session of %s not written to its own directory: %v", p.Path, err)
+		}
+	}
+}
@@ -1,20 +1,37 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
 
-// SearchIndex provides full-text search over chat messages
+// SearchIndex provides full-text search over chat messages. It is safe to search while
+// it is updated.
 type SearchIndex struct {
+	mu       sync.RWMutex // Guards index, messages and removed
+	updating sync.Mutex   // Serializes updates
+
 	// Inverted index: term -> message indices
 	index map[string][]int
 
 	// All indexed messages
 	messages []IndexedMessage
+
+	// Indexed sessions, to replace their messages when they change
+	sessions map[string]indexedSession // "projectSlug|sessionID" -> its messages
+	removed  int                       // Messages of replaced sessions, left out until compacted
+}
+
+// indexedSession locates the messages of a session in the index
+type indexedSession struct {
+	Stamp      string // sessionStamp when indexed
+	Start, End int    // Its messages are messages[Start:End]
 }
 
 // IndexedMessage represents a searchable message
@@ -27,6 +44,12 @@ type IndexedMessage struct {
 	Role         string
 	Content      string
 	Timestamp    time.Time
+	Thinking     bool   // Content comes from the assistant's thinking blocks
+	ToolError    bool   // Content is the output of a failed tool call
+	ToolName     string // Name of the failed tool, when known
+	GitBranch    string // Branch checked out when the message was written
+
+	removed bool // Its session was reindexed or is gone
 }
 
 // SearchResult represents a search result for a session
@@ -45,6 +68,9 @@ type MatchResult struct {
 	Role      string    `json:"role"`
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+	Thinking  bool      `json:"thinking,omitempty"`
+	ToolError bool      `json:"toolError,omitempty"`
+	ToolName  string    `json:"toolName,omitempty"`
 }
 
 // SearchResponse is the API response format
@@ -64,48 +90,234 @@ type SearchRequest struct {
 	Offset  int    `json:"offset,omitempty"`
 	Limit   int    `json:"limit,omitempty"`
 	Sort    string `json:"sort,omitempty"`
+
+	IncludeThinking bool   `json:"includeThinking,omitempty"`
+	Branch          string `json:"branch,omitempty"`     // Only messages written on this git branch
+	ErrorsOnly      bool   `json:"errorsOnly,omitempty"` // Only the output of failed tool calls
 }
 
 // NewSearchIndex creates a new search index from projects
 func NewSearchIndex(projects []Project) *SearchIndex {
+	return NewCachedSearchIndex(projects, nil)
+}
+
+// NewCachedSearchIndex creates a search index from projects, taking the entries of
+// session files that haven't changed from cache instead of loading their messages
+func NewCachedSearchIndex(projects []Project, cache *ParseCache) *SearchIndex {
 	idx := &SearchIndex{
 		index:    make(map[string][]int),
 		messages: []IndexedMessage{},
+		sessions: make(map[string]indexedSession),
 	}
+	idx.Update(projects, cache)
+	return idx
+}
 
-	// Index all messages
+// Update brings the index up to date with reloaded projects: sessions that are new or
+// changed since they were indexed are indexed again, and those that are gone are
+// dropped. It returns the keys ("projectSlug|sessionID") of the sessions it changed.
+func (idx *SearchIndex) Update(projects []Project, cache *ParseCache) []string {
+	idx.updating.Lock()
+	defer idx.updating.Unlock()
+
+	// Entries are loaded without holding the lock, so searches go on meanwhile;
+	// only updates change sessions
+	type update struct {
+		key, stamp string
+		entries    []searchEntry
+	}
+	var updates []update
+	current := make(map[string]bool)
 	for _, project := range projects {
-		projectSlug := ProjectSlug(project.Path)
-		for _, session := range project.Sessions {
-			for _, msg := range session.Messages {
-				// Only index text content
-				content := extractTextContent(msg)
-				if content == "" {
-					continue
-				}
+		projectSlug := project.Slug()
+		for i := range project.Sessions {
+			session := &project.Sessions[i]
+			key := projectSlug + "|" + session.ID
+			stamp := sessionStamp(&project, session)
+			current[key] = true
+			if indexed, ok := idx.sessions[key]; ok && indexed.Stamp == stamp {
+				continue
+			}
+
+			digest, err := cache.digest(session)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to index session %s: %v\n", session.ID, err)
+				continue
+			}
 
-				msgIndex := len(idx.messages)
-				idx.messages = append(idx.messages, IndexedMessage{
-					Project:      project.Path,
-					ProjectSlug:  projectSlug,
-					SessionID:    session.ID,
-					SessionTitle: session.Summary,
-					MessageID:    msg.UUID,
-					Role:         msg.Role,
-					Content:      content,
-					Timestamp:    msg.Timestamp,
-				})
-
-				// Tokenize and index
-				terms := tokenize(content)
-				for _, term := range terms {
-					idx.index[term] = append(idx.index[term], msgIndex)
+			// Subagent messages are found under the session that spawned them
+			entries := make([]searchEntry, len(digest.Search))
+			for j, entry := range digest.Search {
+				msg := entry.Message
+				msg.Project = project.Path
+				msg.ProjectSlug = projectSlug
+				msg.SessionID = session.ID
+				msg.SessionTitle = session.Summary
+				if msg.GitBranch == "" {
+					msg.GitBranch = session.GitBranch
 				}
+				entries[j] = searchEntry{Message: msg, Terms: entry.Terms}
 			}
+			updates = append(updates, update{key: key, stamp: stamp, entries: entries})
 		}
 	}
 
-	return idx
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var changed []string
+	for key := range idx.sessions {
+		if !current[key] {
+			idx.remove(key)
+			changed = append(changed, key)
+		}
+	}
+	for _, u := range updates {
+		idx.remove(u.key)
+		start := len(idx.messages)
+		for _, entry := range u.entries {
+			idx.addTerms(entry.Message, entry.Terms)
+		}
+		idx.sessions[u.key] = indexedSession{Stamp: u.stamp, Start: start, End: len(idx.messages)}
+		changed = append(changed, u.key)
+	}
+	if idx.removed > len(idx.messages)/2 {
+		idx.compact()
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// sessionStamp changes whenever a session's messages, or the fields they are indexed
+// with, may have changed
+func sessionStamp(project *Project, session *Session) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%s|%d|%d", project.Path, session.Summary, session.GitBranch,
+		session.UpdatedAt.UnixNano(), session.MessageCount())
+	for i := range session.Subagents {
+		sub := &session.Subagents[i]
+		fmt.Fprintf(&b, "|%s:%d:%d", sub.ID, sub.UpdatedAt.UnixNano(), sub.MessageCount())
+	}
+	return b.String()
+}
+
+// remove leaves the messages of a session out of searches
+func (idx *SearchIndex) remove(key string) {
+	indexed, ok := idx.sessions[key]
+	if !ok {
+		return
+	}
+	for i := indexed.Start; i < indexed.End; i++ {
+		idx.messages[i] = IndexedMessage{removed: true}
+	}
+	idx.removed += indexed.End - indexed.Start
+	delete(idx.sessions, key)
+}
+
+// compact drops the removed messages from the index, renumbering the others
+func (idx *SearchIndex) compact() {
+	renumber := make([]int, len(idx.messages))
+	messages := make([]IndexedMessage, 0, len(idx.messages)-idx.removed)
+	for i, msg := range idx.messages {
+		if msg.removed {
+			renumber[i] = -1
+			continue
+		}
+		renumber[i] = len(messages)
+		messages = append(messages, msg)
+	}
+
+	for term, postings := range idx.index {
+		kept := postings[:0]
+		for _, i := range postings {
+			if renumber[i] >= 0 {
+				kept = append(kept, renumber[i])
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.index, term)
+		} else {
+			idx.index[term] = kept
+		}
+	}
+
+	// The messages of a session stay together and in order
+	for key, indexed := range idx.sessions {
+		n := indexed.End - indexed.Start
+		if n > 0 {
+			indexed.Start = renumber[indexed.Start]
+		} else {
+			indexed.Start = 0
+		}
+		indexed.End = indexed.Start + n
+		idx.sessions[key] = indexed
+	}
+
+	idx.messages = messages
+	idx.removed = 0
+}
+
+// searchEntry is a message to index, with its terms. Its project and session fields are
+// set when the index is built.
+type searchEntry struct {
+	Message IndexedMessage
+	Terms   []string
+}
+
+// searchEntries returns the entries to index for a message, naming failed tool output
+// after the tool that toolName gives for its tool_use ID
+func searchEntries(msg *Message, toolName func(toolUseID string) string) []searchEntry {
+	var entries []searchEntry
+	add := func(msg IndexedMessage) {
+		entries = append(entries, searchEntry{Message: msg, Terms: tokenize(msg.Content)})
+	}
+
+	// Index text content, and thinking separately so it can be excluded at query time
+	base := IndexedMessage{
+		MessageID: msg.UUID,
+		Role:      msg.Role,
+		Timestamp: msg.Timestamp,
+		GitBranch: msg.GitBranch,
+	}
+
+	if content := extractTextContent(*msg); content != "" {
+		entry := base
+		entry.Content = content
+		add(entry)
+	}
+	if thinking := extractThinkingContent(*msg); thinking != "" {
+		entry := base
+		entry.Content = thinking
+		entry.Thinking = true
+		add(entry)
+	}
+	// Failed tool output is indexed under the tool's name, e.g. "Bash: command not found"
+	for _, block := range msg.Content {
+		if block.Type != "tool_result" || !block.IsError || block.ToolOutput == "" {
+			continue
+		}
+		entry := base
+		entry.Content = block.ToolOutput
+		if name := toolName(block.ToolUseID); name != "" {
+			entry.Content = name + ": " + block.ToolOutput
+			entry.ToolName = name
+		}
+		entry.ToolError = true
+		add(entry)
+	}
+
+	return entries
+}
+
+// addTerms appends a message to the index and indexes its terms
+func (idx *SearchIndex) addTerms(msg IndexedMessage, terms []string) {
+	msgIndex := len(idx.messages)
+	idx.messages = append(idx.messages, msg)
+
+	for _, term := range terms {
+		idx.index[term] = append(idx.index[term], msgIndex)
+	}
 }
 
 // SearchOptions contains optional parameters for search
@@ -113,6 +325,10 @@ type SearchOptions struct {
 	Offset int
 	Limit  int
 	Sort   string // "relevance" (default) or "recent"
+
+	IncludeThinking bool   // Also match the assistant's thinking blocks
+	Branch          string // Only match messages written on this git branch
+	ErrorsOnly      bool   // Only match the output of failed tool calls
 }
 
 // SearchResultWithPagination contains search results with pagination metadata
@@ -131,6 +347,9 @@ func (idx *SearchIndex) Search(query, projectFilter, sessionFilter string) []Sea
 
 // SearchWithOptions executes a search query with pagination and sorting options
 func (idx *SearchIndex) SearchWithOptions(query, projectFilter, sessionFilter string, opts SearchOptions) SearchResultWithPagination {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
 	if query == "" {
 		return SearchResultWithPagination{Results: []SearchResult{}}
 	}
@@ -159,6 +378,19 @@ func (idx *SearchIndex) SearchWithOptions(query, projectFilter, sessionFilter st
 	sessionMatches := make(map[string][]int) // sessionKey -> message indices
 	for _, msgIdx := range matchingIndices {
 		msg := idx.messages[msgIdx]
+		if msg.removed {
+			continue
+		}
+
+		// Thinking is only searched on request
+		if msg.Thinking && !opts.IncludeThinking {
+			continue
+		}
+
+		// Failed tool output is only searched, exclusively, in errors-only mode
+		if msg.ToolError != opts.ErrorsOnly {
+			continue
+		}
 
 		// Apply project filter
 		if projectFilter != "" && msg.Project != projectFilter && msg.ProjectSlug != projectFilter {
@@ -170,6 +402,11 @@ func (idx *SearchIndex) SearchWithOptions(query, projectFilter, sessionFilter st
 			continue
 		}
 
+		// Apply branch filter
+		if opts.Branch != "" && msg.GitBranch != opts.Branch {
+			continue
+		}
+
 		// Apply phrase filter - check that all phrases appear in the content
 		if len(parsed.Phrases) > 0 {
 			lowerContent := strings.ToLower(msg.Content)
@@ -213,6 +450,9 @@ func (idx *SearchIndex) SearchWithOptions(query, projectFilter, sessionFilter st
 				Role:      msg.Role,
 				Content:   highlighted,
 				Timestamp: msg.Timestamp,
+				Thinking:  msg.Thinking,
+				ToolError: msg.ToolError,
+				ToolName:  msg.ToolName,
 			})
 		}
 
@@ -396,6 +636,17 @@ func extractTextContent(msg Message) string {
 	return strings.Join(parts, " ")
 }
 
+// extractThinkingContent extracts the readable reasoning from message thinking blocks
+func extractThinkingContent(msg Message) string {
+	var parts []string
+	for _, block := range msg.Content {
+		if block.Type == "thinking" && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // ParsedQuery represents a parsed search query with terms and phrases
 type ParsedQuery struct {
 	Terms   []string // Individual words (for index lookup)
@@ -548,10 +799,14 @@ func highlightMatchesWithPhrases(content string, terms []string, phrases []strin
 
 // MessageCount returns the number of indexed messages
 func (idx *SearchIndex) MessageCount() int {
-	return len(idx.messages)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.messages) - idx.removed
 }
 
 // TermCount returns the number of unique terms
 func (idx *SearchIndex) TermCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
 	return len(idx.index)
 }
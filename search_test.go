@@ -668,6 +668,146 @@ func TestSearchIndex_SkipsToolBlocks(t *testing.T) {
 	}
 }
 
+func TestSearchWithOptions_IncludeThinking(t *testing.T) {
+	projects := []Project{
+		{
+			Path: "/Users/test/project1",
+			Sessions: []Session{
+				{
+					ID: "session-1",
+					Messages: []Message{
+						{
+							UUID:      "msg-1",
+							Role:      "assistant",
+							Timestamp: time.Now(),
+							Content: []ContentBlock{
+								{Type: "thinking", Text: "The tokenizer drops short words"},
+								{Type: "text", Text: "Fixed the bug"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	idx := NewSearchIndex(projects)
+
+	// Thinking is excluded by default
+	result := idx.SearchWithOptions("tokenizer", "", "", SearchOptions{})
+	if result.Total != 0 {
+		t.Errorf("Expected thinking to be excluded by default, got %d results", result.Total)
+	}
+
+	result = idx.SearchWithOptions("tokenizer", "", "", SearchOptions{IncludeThinking: true})
+	if result.Total != 1 {
+		t.Fatalf("Expected 1 result with thinking included, got %d", result.Total)
+	}
+	if match := result.Results[0].Matches[0]; !match.Thinking || match.MessageID != "msg-1" {
+		t.Errorf("Expected thinking match for msg-1, got %+v", match)
+	}
+
+	// Text content still matches without the option
+	result = idx.SearchWithOptions("bug", "", "", SearchOptions{})
+	if result.Total != 1 {
+		t.Errorf("Expected text match, got %d results", result.Total)
+	}
+}
+
+func TestSearchWithOptions_Branch(t *testing.T) {
+	projects := []Project{
+		{
+			Path: "/Users/test/project1",
+			Sessions: []Session{
+				{
+					ID:        "session-1",
+					GitBranch: "feature/x",
+					Messages: []Message{
+						{UUID: "msg-1", Role: "user", Timestamp: time.Now(), GitBranch: "main",
+							Content: []ContentBlock{{Type: "text", Text: "Refactor the parser"}}},
+						{UUID: "msg-2", Role: "assistant", Timestamp: time.Now(), GitBranch: "feature/x",
+							Content: []ContentBlock{{Type: "text", Text: "Refactored the parser"}}},
+					},
+				},
+				{
+					// Older transcripts without per-message branches fall back to the session branch
+					ID:        "session-2",
+					GitBranch: "feature/x",
+					Messages: []Message{
+						{UUID: "msg-3", Role: "user", Timestamp: time.Now(),
+							Content: []ContentBlock{{Type: "text", Text: "Test the parser"}}},
+					},
+				},
+			},
+		},
+	}
+
+	idx := NewSearchIndex(projects)
+
+	result := idx.SearchWithOptions("parser", "", "", SearchOptions{})
+	if result.Total != 2 {
+		t.Fatalf("Expected 2 sessions without a branch filter, got %d", result.Total)
+	}
+
+	result = idx.SearchWithOptions("parser", "", "", SearchOptions{Branch: "feature/x"})
+	matches := 0
+	for _, r := range result.Results {
+		for _, m := range r.Matches {
+			matches++
+			if m.MessageID == "msg-1" {
+				t.Errorf("Message written on main matched the feature/x filter")
+			}
+		}
+	}
+	if matches != 2 {
+		t.Errorf("Expected 2 matches on feature/x, got %d", matches)
+	}
+
+	result = idx.SearchWithOptions("parser", "", "", SearchOptions{Branch: "release"})
+	if result.Total != 0 {
+		t.Errorf("Expected no results on an unknown branch, got %d", result.Total)
+	}
+}
+
+func TestSearchWithOptions_ErrorsOnly(t *testing.T) {
+	projects := []Project{
+		{
+			Path: "/Users/test/project1",
+			Sessions: []Session{
+				{
+					ID: "session-1",
+					Messages: []Message{
+						{UUID: "msg-1", Role: "assistant", Timestamp: time.Now(), Content: []ContentBlock{
+							{Type: "text", Text: "Running the build command"},
+							{Type: "tool_use", ToolUseID: "t1", ToolName: "Bash", ToolInput: `{"command":"make build"}`},
+						}},
+						{UUID: "msg-2", Role: "user", Timestamp: time.Now(), Content: []ContentBlock{
+							{Type: "tool_result", ToolUseID: "t1", IsError: true, ToolOutput: "make: command not found"},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	idx := NewSearchIndex(projects)
+
+	// Failed tool output is left out of regular searches
+	result := idx.SearchWithOptions("command", "", "", SearchOptions{})
+	if result.Total != 1 || len(result.Results[0].Matches) != 1 || result.Results[0].Matches[0].MessageID != "msg-1" {
+		t.Fatalf("Expected only the text match without errors-only, got %+v", result.Results)
+	}
+
+	result = idx.SearchWithOptions("bash command", "", "", SearchOptions{ErrorsOnly: true})
+	if result.Total != 1 {
+		t.Fatalf("Expected 1 result in errors-only mode, got %d", result.Total)
+	}
+	match := result.Results[0].Matches[0]
+	if !match.ToolError || match.MessageID != "msg-2" {
+		t.Errorf("Expected failed Bash output from msg-2, got %+v", match)
+	}
+}
+
 func TestSearchWithOptions_Pagination(t *testing.T) {
 	now := time.Now()
 	// Create multiple sessions to test pagination
@@ -936,3 +1076,62 @@ func TestSearchWithOptions_PhraseScoring(t *testing.T) {
 		t.Errorf("Phrase match should have score >= 2.0 (phrase bonus), got %f", result.Results[0].Score)
 	}
 }
+
+func TestSearchIndex_Update(t *testing.T) {
+	now := time.Now()
+	message := func(id, text string) Message {
+		return Message{UUID: id, Role: "user", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: text}}}
+	}
+	projects := []Project{
+		{
+			Path:       "/Users/test/project1",
+			FolderName: "-Users-test-project1",
+			Sessions: []Session{
+				{ID: "kept", Summary: "Kept", UpdatedAt: now, Messages: []Message{message("k1", "unchanged apple")}},
+				{ID: "grown", Summary: "Grown", UpdatedAt: now, Messages: []Message{message("g1", "first banana")}},
+				{ID: "gone", Summary: "Gone", UpdatedAt: now, Messages: []Message{message("x1", "deleted cherry")}},
+			},
+		},
+	}
+	idx := NewSearchIndex(projects)
+
+	// One session grew, one went away and one is new
+	next := []Project{projects[0]}
+	next[0].Sessions = []Session{
+		projects[0].Sessions[0],
+		{ID: "grown", Summary: "Grown", UpdatedAt: now.Add(time.Minute), Messages: []Message{
+			message("g1", "first banana"), message("g2", "second banana"),
+		}},
+		{ID: "new", Summary: "New", UpdatedAt: now, Messages: []Message{message("n1", "fresh apple")}},
+	}
+	changed := idx.Update(next, nil)
+
+	want := []string{"users-test-project1|gone", "users-test-project1|grown", "users-test-project1|new"}
+	if fmt.Sprint(changed) != fmt.Sprint(want) {
+		t.Errorf("Update changed %v, want %v", changed, want)
+	}
+	if got := idx.MessageCount(); got != 4 {
+		t.Errorf("MessageCount() = %d, want 4", got)
+	}
+	if results := idx.Search("cherry", "", ""); len(results) != 0 {
+		t.Errorf("Search(cherry) found %d sessions after its session went away", len(results))
+	}
+	if results := idx.Search("banana", "", ""); len(results) != 1 || len(results[0].Matches) != 2 {
+		t.Errorf("Search(banana) = %+v, want both messages of the grown session", results)
+	}
+	if results := idx.Search("apple", "", ""); len(results) != 2 {
+		t.Errorf("Search(apple) found %d sessions, want 2", len(results))
+	}
+
+	// Removed messages are compacted away once they outnumber the others
+	if changed := idx.Update(nil, nil); len(changed) != 3 {
+		t.Errorf("Update(nil) changed %v, want every session", changed)
+	}
+	idx.Update(next, nil)
+	if len(idx.messages) != 4 || idx.removed != 0 {
+		t.Errorf("Index holds %d messages (%d removed) after compaction, want 4 (0)", len(idx.messages), idx.removed)
+	}
+	if results := idx.Search("banana", "", ""); len(results) != 1 || len(results[0].Matches) != 2 {
+		t.Errorf("Search(banana) after compaction = %+v", results)
+	}
+}
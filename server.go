@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -23,28 +24,47 @@ type cacheEntry struct {
 	timestamp time.Time
 }
 
+// serverState is the project data pages are rendered from. Reload replaces it as a
+// whole; it isn't changed once served.
+type serverState struct {
+	projects    []Project
+	stats       *StatsData        // Precomputed stats for the stats API
+	diagnostics DiagnosticsReport // Parse problems of the loaded session files
+}
+
 // Server represents the HTTP server for serving HTML and search API
 type Server struct {
-	port         int
-	outputDir    string
-	index        *SearchIndex
-	projects     []Project
-	server       *http.Server
-	shellTmpl    *template.Template
-	indexTmpl    *template.Template
-	projectTmpl  *template.Template
-	statsTmpl    *template.Template
-	searchTmpl   *template.Template
+	port            int
+	outputDir       string
+	index           *SearchIndex
+	server          *http.Server
+	shellTmpl       *template.Template
+	indexTmpl       *template.Template
+	projectTmpl     *template.Template
+	statsTmpl       *template.Template
+	searchTmpl      *template.Template
+	diagnosticsTmpl *template.Template
 	// Cache for rendered HTML pages
-	cache      map[string]*cacheEntry
-	cacheMu    sync.RWMutex
-	cacheTTL   time.Duration
-	// Precomputed stats for the stats API
-	stats      *StatsData
+	cache    map[string]*cacheEntry
+	cacheMu  sync.RWMutex
+	cacheTTL time.Duration
+	// Served projects, replaced by Reload
+	mu      sync.RWMutex
+	state   *serverState
+	pricing *PriceTable
+	parses  *ParseCache
+	// Open /api/events streams, told what each reload changed
+	events *broadcaster
 }
 
 // NewServer creates a new server instance
-func NewServer(port int, outputDir string, projects []Project) (*Server, error) {
+func NewServer(port int, outputDir string, projects []Project, pricing *PriceTable) (*Server, error) {
+	return NewCachedServer(port, outputDir, projects, pricing, nil)
+}
+
+// NewCachedServer creates a new server instance, building its search index and stats
+// from cache for the session files that haven't changed
+func NewCachedServer(port int, outputDir string, projects []Project, pricing *PriceTable, cache *ParseCache) (*Server, error) {
 	funcMap := template.FuncMap{
 		"ProjectSlug": ProjectSlug,
 	}
@@ -74,22 +94,102 @@ func NewServer(port int, outputDir string, projects []Project) (*Server, error)
 		return nil, fmt.Errorf("parsing search template: %w", err)
 	}
 
+	diagnosticsTmpl, err := template.New("diagnostics").Funcs(funcMap).Parse(diagnosticsTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing diagnostics template: %w", err)
+	}
+
 	return &Server{
-		port:        port,
-		outputDir:   outputDir,
-		projects:    projects,
-		index:       NewSearchIndex(projects),
-		shellTmpl:   shellTmpl,
-		indexTmpl:   indexTmpl,
-		projectTmpl: projectTmpl,
-		statsTmpl:   statsTmpl,
-		searchTmpl:  searchTmpl,
-		cache:       make(map[string]*cacheEntry),
-		cacheTTL:    30 * time.Second, // Cache HTML for 30 seconds
-		stats:       ComputeStats(projects),
+		port:            port,
+		outputDir:       outputDir,
+		index:           NewCachedSearchIndex(projects, cache),
+		shellTmpl:       shellTmpl,
+		indexTmpl:       indexTmpl,
+		projectTmpl:     projectTmpl,
+		statsTmpl:       statsTmpl,
+		searchTmpl:      searchTmpl,
+		diagnosticsTmpl: diagnosticsTmpl,
+		cache:           make(map[string]*cacheEntry),
+		cacheTTL:        30 * time.Second, // Cache HTML for 30 seconds
+		state: &serverState{
+			projects:    projects,
+			stats:       ComputeCachedStats(projects, pricing, cache),
+			diagnostics: CollectDiagnostics(projects),
+		},
+		pricing: pricing,
+		parses:  cache,
+		events:  newBroadcaster(),
 	}, nil
 }
 
+// current returns the state pages are rendered from
+func (s *Server) current() *serverState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// Reload serves reloaded projects: the search index is updated for the sessions that
+// changed, stats and diagnostics are recomputed, the cached pages showing what changed
+// are dropped and open pages are told over /api/events. It is safe to call while
+// requests are served.
+func (s *Server) Reload(projects []Project) {
+	changed := s.index.Update(projects, s.parses)
+	next := &serverState{
+		projects:    projects,
+		stats:       ComputeCachedStats(projects, s.pricing, s.parses),
+		diagnostics: CollectDiagnostics(projects),
+	}
+
+	s.mu.Lock()
+	prev := s.state
+	s.state = next
+	s.mu.Unlock()
+
+	s.dropCached(prev.projects, projects, changed)
+	s.events.publish(changeEvents(prev.projects, projects, changed)...)
+}
+
+// dropCached removes the cached pages that show the sessions with the given keys, or
+// every page if the sidebar tree changed
+func (s *Server) dropCached(prev, projects []Project, changed []string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if !sameTree(prev, projects) {
+		// Every page lists the projects and sessions in its sidebar
+		s.cache = make(map[string]*cacheEntry)
+		return
+	}
+	for _, key := range []string{"index", "stats", "search", "diagnostics"} {
+		delete(s.cache, key)
+	}
+	for _, key := range changed {
+		projectSlug, sessionID, _ := strings.Cut(key, "|")
+		delete(s.cache, "project:"+projectSlug)
+		delete(s.cache, "session:"+projectSlug+"/"+sessionID)
+	}
+}
+
+// sameTree reports whether two loads list the same projects and sessions, with the
+// same titles and dates, as the sidebar shows them
+func sameTree(a, b []Project) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Slug() != b[i].Slug() || a[i].Path != b[i].Path || len(a[i].Sessions) != len(b[i].Sessions) {
+			return false
+		}
+		for j := range a[i].Sessions {
+			sa, sb := &a[i].Sessions[j], &b[i].Sessions[j]
+			if sa.ID != sb.ID || sa.Summary != sb.Summary || !sa.CreatedAt.Equal(sb.CreatedAt) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Start starts the HTTP server and blocks until shutdown
 func (s *Server) Start() error {
 	mux := http.NewServeMux()
@@ -97,6 +197,8 @@ func (s *Server) Start() error {
 	// API routes
 	mux.HandleFunc("/api/search", s.handleSearch)
 	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/diagnostics", s.handleDiagnostics)
+	mux.HandleFunc("/api/events", s.handleEvents)
 
 	// Static file serving
 	fileServer := http.FileServer(http.Dir(s.outputDir))
@@ -111,6 +213,7 @@ func (s *Server) Start() error {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	s.server.RegisterOnShutdown(s.events.close)
 
 	// Check if port is available
 	listener, err := net.Listen("tcp", addr)
@@ -174,6 +277,12 @@ func (s *Server) handleStatic(fileServer http.Handler) http.HandlerFunc {
 		// Clean the path
 		path := filepath.Clean(r.URL.Path)
 
+		// Hidden files, like the parse cache, aren't served
+		if strings.Contains(path, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+
 		// Serve embedded logo
 		if path == "/claude-code-icon.png" {
 			w.Header().Set("Content-Type", "image/png")
@@ -200,15 +309,22 @@ func (s *Server) handleStatic(fileServer http.Handler) http.HandlerFunc {
 			return
 		}
 
+		// Handle diagnostics page
+		if path == "/diagnostics" || path == "/diagnostics.html" {
+			s.renderDiagnosticsPage(w, r)
+			return
+		}
+
 		// Handle project and session paths without .html extension
+		st := s.current()
 		trimmedPath := strings.TrimPrefix(path, "/")
 		trimmedPath = strings.TrimSuffix(trimmedPath, "/")
 		pathParts := strings.Split(trimmedPath, "/")
 
 		// Single path segment without extension - could be a project slug
 		if len(pathParts) == 1 && !strings.Contains(trimmedPath, ".") {
-			for i := range s.projects {
-				if ProjectSlug(s.projects[i].Path) == trimmedPath {
+			for i := range st.projects {
+				if st.projects[i].Slug() == trimmedPath {
 					s.renderProjectIndex(w, r, trimmedPath)
 					return
 				}
@@ -220,13 +336,11 @@ func (s *Server) handleStatic(fileServer http.Handler) http.HandlerFunc {
 			projectSlug := pathParts[0]
 			sessionID := pathParts[1]
 			// Verify this is a valid project/session combination
-			for i := range s.projects {
-				if ProjectSlug(s.projects[i].Path) == projectSlug {
-					for j := range s.projects[i].Sessions {
-						if s.projects[i].Sessions[j].ID == sessionID {
-							s.renderSessionShell(w, r, projectSlug, sessionID)
-							return
-						}
+			for i := range st.projects {
+				if st.projects[i].Slug() == projectSlug {
+					if st.projects[i].findSession(sessionID) != nil {
+						s.renderSessionShell(w, r, projectSlug, sessionID)
+						return
 					}
 					break
 				}
@@ -298,10 +412,15 @@ func (s *Server) getFromCache(key string) ([]byte, bool) {
 }
 
 // setInCache stores content in the cache
-func (s *Server) setInCache(key string, content []byte) {
+func (s *Server) setInCache(key string, content []byte, st *serverState) {
 	s.cacheMu.Lock()
 	defer s.cacheMu.Unlock()
 
+	// A page rendered before a reload is already out of date
+	if st != s.current() {
+		return
+	}
+
 	s.cache[key] = &cacheEntry{
 		content:   content,
 		timestamp: time.Now(),
@@ -310,6 +429,7 @@ func (s *Server) setInCache(key string, content []byte) {
 
 // renderMainIndex renders the main index page dynamically
 func (s *Server) renderMainIndex(w http.ResponseWriter, r *http.Request) {
+	st := s.current()
 	cacheKey := "index"
 
 	// Check cache first
@@ -320,9 +440,13 @@ func (s *Server) renderMainIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		Projects []Project
+		Projects     []Project
+		MultiSource  bool
+		ProblemFiles int
 	}{
-		Projects: s.projects,
+		Projects:     st.projects,
+		MultiSource:  multipleSources(st.projects),
+		ProblemFiles: len(st.diagnostics.ProblemFiles),
 	}
 
 	// Render to buffer for caching
@@ -334,7 +458,7 @@ func (s *Server) renderMainIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	content := buf.Bytes()
-	s.setInCache(cacheKey, content)
+	s.setInCache(cacheKey, content, st)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(content)
@@ -342,6 +466,7 @@ func (s *Server) renderMainIndex(w http.ResponseWriter, r *http.Request) {
 
 // renderProjectIndex renders a project index page dynamically
 func (s *Server) renderProjectIndex(w http.ResponseWriter, r *http.Request, projectSlug string) {
+	st := s.current()
 	cacheKey := "project:" + projectSlug
 
 	// Check cache first
@@ -353,9 +478,9 @@ func (s *Server) renderProjectIndex(w http.ResponseWriter, r *http.Request, proj
 
 	// Find the project
 	var project *Project
-	for i := range s.projects {
-		if ProjectSlug(s.projects[i].Path) == projectSlug {
-			project = &s.projects[i]
+	for i := range st.projects {
+		if st.projects[i].Slug() == projectSlug {
+			project = &st.projects[i]
 			break
 		}
 	}
@@ -366,11 +491,15 @@ func (s *Server) renderProjectIndex(w http.ResponseWriter, r *http.Request, proj
 	}
 
 	data := struct {
-		Project     *Project
-		AllProjects []Project
+		Project       *Project
+		AllProjects   []Project
+		Conversations []Conversation
+		MultiSource   bool
 	}{
-		Project:     project,
-		AllProjects: s.projects,
+		Project:       project,
+		AllProjects:   st.projects,
+		Conversations: GroupConversations(project.Sessions),
+		MultiSource:   multipleSources(st.projects),
 	}
 
 	// Render to buffer for caching
@@ -382,7 +511,7 @@ func (s *Server) renderProjectIndex(w http.ResponseWriter, r *http.Request, proj
 	}
 
 	content := buf.Bytes()
-	s.setInCache(cacheKey, content)
+	s.setInCache(cacheKey, content, st)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(content)
@@ -390,6 +519,7 @@ func (s *Server) renderProjectIndex(w http.ResponseWriter, r *http.Request, proj
 
 // renderSessionShell renders a session HTML shell dynamically
 func (s *Server) renderSessionShell(w http.ResponseWriter, r *http.Request, projectSlug, sessionID string) {
+	st := s.current()
 	cacheKey := "session:" + projectSlug + "/" + sessionID
 
 	// Check cache first
@@ -403,15 +533,10 @@ func (s *Server) renderSessionShell(w http.ResponseWriter, r *http.Request, proj
 	var project *Project
 	var session *Session
 
-	for i := range s.projects {
-		if ProjectSlug(s.projects[i].Path) == projectSlug {
-			project = &s.projects[i]
-			for j := range project.Sessions {
-				if project.Sessions[j].ID == sessionID {
-					session = &project.Sessions[j]
-					break
-				}
-			}
+	for i := range st.projects {
+		if st.projects[i].Slug() == projectSlug {
+			project = &st.projects[i]
+			session = project.findSession(sessionID)
 			break
 		}
 	}
@@ -428,7 +553,7 @@ func (s *Server) renderSessionShell(w http.ResponseWriter, r *http.Request, proj
 	}{
 		Session:     session,
 		Project:     project,
-		AllProjects: s.projects,
+		AllProjects: st.projects,
 	}
 
 	// Render to buffer for caching
@@ -440,7 +565,7 @@ func (s *Server) renderSessionShell(w http.ResponseWriter, r *http.Request, proj
 	}
 
 	content := buf.Bytes()
-	s.setInCache(cacheKey, content)
+	s.setInCache(cacheKey, content, st)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(content)
@@ -448,6 +573,7 @@ func (s *Server) renderSessionShell(w http.ResponseWriter, r *http.Request, proj
 
 // renderStatsPage renders the stats dashboard page
 func (s *Server) renderStatsPage(w http.ResponseWriter, r *http.Request) {
+	st := s.current()
 	cacheKey := "stats"
 
 	// Check cache first
@@ -460,7 +586,7 @@ func (s *Server) renderStatsPage(w http.ResponseWriter, r *http.Request) {
 	data := struct {
 		Projects []Project
 	}{
-		Projects: s.projects,
+		Projects: st.projects,
 	}
 
 	// Render to buffer for caching
@@ -472,7 +598,7 @@ func (s *Server) renderStatsPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	content := buf.Bytes()
-	s.setInCache(cacheKey, content)
+	s.setInCache(cacheKey, content, st)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Write(content)
@@ -480,6 +606,7 @@ func (s *Server) renderStatsPage(w http.ResponseWriter, r *http.Request) {
 
 // renderSearchPage renders the search page
 func (s *Server) renderSearchPage(w http.ResponseWriter, r *http.Request) {
+	st := s.current()
 	cacheKey := "search"
 
 	// Check cache first
@@ -489,10 +616,25 @@ func (s *Server) renderSearchPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Branches across all projects for the branch filter
+	seen := make(map[string]bool)
+	var branches []string
+	for i := range st.projects {
+		for _, b := range st.projects[i].GitBranches() {
+			if !seen[b] {
+				seen[b] = true
+				branches = append(branches, b)
+			}
+		}
+	}
+	sort.Strings(branches)
+
 	data := struct {
 		Projects []Project
+		Branches []string
 	}{
-		Projects: s.projects,
+		Projects: st.projects,
+		Branches: branches,
 	}
 
 	// Render to buffer for caching
@@ -504,7 +646,42 @@ func (s *Server) renderSearchPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	content := buf.Bytes()
-	s.setInCache(cacheKey, content)
+	s.setInCache(cacheKey, content, st)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(content) // Error ignored: client may have disconnected
+}
+
+// renderDiagnosticsPage renders the list of session files with parse problems
+func (s *Server) renderDiagnosticsPage(w http.ResponseWriter, r *http.Request) {
+	st := s.current()
+	cacheKey := "diagnostics"
+
+	// Check cache first
+	if content, ok := s.getFromCache(cacheKey); ok {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(content) // Error ignored: client may have disconnected
+		return
+	}
+
+	data := struct {
+		Projects    []Project
+		Diagnostics DiagnosticsReport
+	}{
+		Projects:    st.projects,
+		Diagnostics: st.diagnostics,
+	}
+
+	// Render to buffer for caching
+	var buf bytes.Buffer
+	if err := s.diagnosticsTmpl.Execute(&buf, data); err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		fmt.Fprintf(os.Stderr, "Diagnostics template error: %v\n", err)
+		return
+	}
+
+	content := buf.Bytes()
+	s.setInCache(cacheKey, content, st)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	_, _ = w.Write(content) // Error ignored: client may have disconnected
@@ -537,6 +714,10 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		Offset: req.Offset,
 		Limit:  req.Limit,
 		Sort:   req.Sort,
+
+		IncludeThinking: req.IncludeThinking,
+		Branch:          req.Branch,
+		ErrorsOnly:      req.ErrorsOnly,
 	}
 	searchResult := s.index.SearchWithOptions(req.Query, req.Project, req.Session, opts)
 	duration := time.Since(start)
@@ -576,15 +757,26 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 
 	// Check for time range filter
 	rangeType := r.URL.Query().Get("range")
-	stats := s.stats
+	stats := s.current().stats
 	if rangeType != "" && rangeType != "all" {
-		stats = FilterStatsByTimeRange(s.stats, rangeType)
+		stats = FilterStatsByTimeRange(stats, rangeType)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleDiagnostics returns the session files with parse problems
+func (s *Server) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.current().diagnostics)
+}
+
 // corsMiddleware adds CORS headers for local development
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -604,8 +796,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 }
 
 // StartServer is a convenience function to start a server
-func StartServer(port int, outputDir string, projects []Project) error {
-	server, err := NewServer(port, outputDir, projects)
+func StartServer(port int, outputDir string, projects []Project, pricing *PriceTable) error {
+	server, err := NewServer(port, outputDir, projects, pricing)
 	if err != nil {
 		return fmt.Errorf("creating server: %w", err)
 	}
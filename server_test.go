@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,7 +18,7 @@ func TestNewServer(t *testing.T) {
 		{Path: "/test", FolderName: "-test", Sessions: []Session{}},
 	}
 
-	server, err := NewServer(8080, "/tmp/output", projects)
+	server, err := NewServer(8080, "/tmp/output", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -58,7 +61,7 @@ func TestHandleSearch_ValidRequest(t *testing.T) {
 		},
 	}
 
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -95,7 +98,7 @@ func TestHandleSearch_ValidRequest(t *testing.T) {
 
 func TestHandleSearch_EmptyQuery(t *testing.T) {
 	projects := []Project{}
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -122,7 +125,7 @@ func TestHandleSearch_EmptyQuery(t *testing.T) {
 
 func TestHandleSearch_InvalidMethod(t *testing.T) {
 	projects := []Project{}
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -138,7 +141,7 @@ func TestHandleSearch_InvalidMethod(t *testing.T) {
 
 func TestHandleSearch_InvalidJSON(t *testing.T) {
 	projects := []Project{}
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -187,7 +190,7 @@ func TestHandleSearch_WithFilters(t *testing.T) {
 		},
 	}
 
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -215,7 +218,7 @@ func TestHandleSearch_WithFilters(t *testing.T) {
 
 func TestHandleSearch_LongQuery(t *testing.T) {
 	projects := []Project{}
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -261,7 +264,7 @@ func TestHandleStats(t *testing.T) {
 		},
 	}
 
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -302,7 +305,7 @@ func TestHandleStats(t *testing.T) {
 
 func TestHandleStats_InvalidMethod(t *testing.T) {
 	projects := []Project{}
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -325,7 +328,7 @@ func TestHandleStatic(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	projects := []Project{}
-	server, err := NewServer(8080, tmpDir, projects)
+	server, err := NewServer(8080, tmpDir, projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -356,7 +359,7 @@ func TestHandleStatic_NotFound(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	projects := []Project{}
-	server, err := NewServer(8080, tmpDir, projects)
+	server, err := NewServer(8080, tmpDir, projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -381,7 +384,7 @@ func TestHandleStatic_InvalidMethod(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	projects := []Project{}
-	server, err := NewServer(8080, tmpDir, projects)
+	server, err := NewServer(8080, tmpDir, projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -447,7 +450,7 @@ func TestSearchResponseFormat(t *testing.T) {
 		},
 	}
 
-	server, err := NewServer(8080, "/tmp", projects)
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
 	if err != nil {
 		t.Fatalf("NewServer failed: %v", err)
 	}
@@ -500,3 +503,152 @@ func TestSearchResponseFormat(t *testing.T) {
 		t.Error("Match content should contain highlighted terms")
 	}
 }
+
+func TestHandleDiagnostics(t *testing.T) {
+	projects := []Project{
+		{
+			Path:       "/Users/test/project1",
+			FolderName: "-Users-test-project1",
+			Sessions: []Session{
+				{ID: "clean", SourcePath: "/logs/clean.jsonl"},
+				{
+					ID:          "broken",
+					SourcePath:  "/logs/broken.jsonl",
+					Diagnostics: &ParseDiagnostics{MalformedLines: 2, UnknownEntryTypes: map[string]int{"new-type": 1}},
+				},
+			},
+		},
+	}
+
+	server, err := NewServer(8080, "/tmp", projects, DefaultPriceTable())
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diagnostics", nil)
+	rr := httptest.NewRecorder()
+	server.handleDiagnostics(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Diagnostics should return OK, got %v", rr.Code)
+	}
+	var report DiagnosticsReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse diagnostics: %v", err)
+	}
+	if report.Files != 2 || len(report.ProblemFiles) != 1 {
+		t.Fatalf("Report = %+v, want 1 of 2 files with problems", report)
+	}
+	if f := report.ProblemFiles[0]; f.SessionID != "broken" || f.MalformedLines != 2 || f.UnknownEntryTypes["new-type"] != 1 {
+		t.Errorf("Problem file = %+v", f)
+	}
+
+	// The page lists them too
+	req = httptest.NewRequest(http.MethodGet, "/diagnostics", nil)
+	rr = httptest.NewRecorder()
+	server.renderDiagnosticsPage(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/logs/broken.jsonl") {
+		t.Errorf("Diagnostics page (%d) doesn't list the broken file", rr.Code)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	now := time.Now()
+	session := func(id, text string) Session {
+		return Session{ID: id, Summary: "Session " + id, CreatedAt: now, UpdatedAt: now, Messages: []Message{
+			{UUID: id + "-1", Role: "user", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: text}}},
+		}}
+	}
+	projects := []Project{{Path: "/Users/test/project1", FolderName: "-Users-test-project1", Sessions: []Session{session("old", "hello")}}}
+
+	server, err := NewServer(8080, t.TempDir(), projects, DefaultPriceTable())
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.handleStatic(http.NotFoundHandler())(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	// Cache the index page, then reload with a new session
+	if rr := get("/"); strings.Contains(rr.Body.String(), "Session new") {
+		t.Fatal("Index lists a session that doesn't exist yet")
+	}
+	if rr := get("/users-test-project1/new"); rr.Code != http.StatusNotFound {
+		t.Fatalf("New session URL returned %d before reload, want 404", rr.Code)
+	}
+	reloaded := []Project{{Path: "/Users/test/project1", FolderName: "-Users-test-project1", Sessions: []Session{session("new", "world"), session("old", "hello")}}}
+	server.Reload(reloaded)
+
+	if rr := get("/"); !strings.Contains(rr.Body.String(), "Session new") {
+		t.Error("Index page doesn't list the new session after reload")
+	}
+	if rr := get("/users-test-project1/new"); rr.Code != http.StatusOK {
+		t.Errorf("New session URL returned %d after reload, want 200", rr.Code)
+	}
+	if results := server.index.Search("world", "", ""); len(results) != 1 || results[0].SessionID != "new" {
+		t.Errorf("Search(world) = %+v, want the new session", results)
+	}
+	if got := server.current().stats.TotalSessions; got != 2 {
+		t.Errorf("Stats TotalSessions = %d after reload, want 2", got)
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	now := time.Now()
+	session := func(id string, messages int) Session {
+		s := Session{ID: id, Summary: "Session " + id, CreatedAt: now, UpdatedAt: now.Add(time.Duration(messages) * time.Minute)}
+		for i := 0; i < messages; i++ {
+			s.Messages = append(s.Messages, Message{UUID: fmt.Sprintf("%s-%d", id, i), Role: "user", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: "hello"}}})
+		}
+		return s
+	}
+	project := func(sessions ...Session) []Project {
+		return []Project{{Path: "/Users/test/project1", FolderName: "-Users-test-project1", Sessions: sessions}}
+	}
+
+	server, err := NewServer(8080, t.TempDir(), project(session("grown", 1), session("gone", 1)), DefaultPriceTable())
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(server.handleEvents))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET /api/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("Stream starts with %q, want the connected comment", lines.Text())
+	}
+
+	server.Reload(project(session("new", 1), session("grown", 2)))
+
+	want := []string{
+		`event: session-created`, `"sessionId":"new"`,
+		`event: session-deleted`, `"sessionId":"gone"`,
+		`event: session-updated`, `"sessionId":"grown"`, `"messageCount":2`,
+		`event: stats-changed`,
+	}
+	var stream strings.Builder
+	for lines.Scan() && !strings.Contains(stream.String(), "stats-changed") {
+		stream.WriteString(lines.Text() + "\n")
+	}
+	got := stream.String()
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("Event stream doesn't contain %s:\n%s", w, got)
+		}
+	}
+
+	// Closing the broadcaster, as server shutdown does, ends the stream
+	server.events.close()
+	for lines.Scan() {
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source is a directory of Claude Code project folders, like ~/.claude/projects, such
+// as another config dir, a synced backup or an archive exported by a teammate
+type Source struct {
+	Name string // Origin shown on the projects found in it
+	Path string
+}
+
+// configFile is the YAML layout of the config file
+type configFile struct {
+	Sources []sourceEntry `yaml:"sources"`
+}
+
+// sourceEntry is a source in the config file: a path, or a mapping with a name and path
+type sourceEntry struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// UnmarshalYAML accepts a plain path as well as a mapping
+func (e *sourceEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&e.Path)
+	}
+	type plain sourceEntry
+	return node.Decode((*plain)(e))
+}
+
+// DefaultConfigPath returns the default location of the config file
+func DefaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "claude-code-logs", "config.yaml"), nil
+}
+
+// ResolveSources returns the sources to load: those given with --source as "path" or
+// "name=path" if any, else those listed in the config file at configPath, else the
+// projects directory of the Claude config dir
+func ResolveSources(flags []string, configPath string) ([]Source, error) {
+	var entries []sourceEntry
+	for _, flag := range flags {
+		entries = append(entries, parseSourceFlag(flag))
+	}
+
+	if len(entries) == 0 && configPath != "" {
+		data, err := os.ReadFile(configPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("reading config file: %w", err)
+		default:
+			var file configFile
+			if err := yaml.Unmarshal(data, &file); err != nil {
+				return nil, fmt.Errorf("parsing config file %s: %w", configPath, err)
+			}
+			entries = file.Sources
+		}
+	}
+
+	if len(entries) == 0 {
+		path, err := DefaultClaudeProjectsPath()
+		if err != nil {
+			return nil, err
+		}
+		entries = []sourceEntry{{Path: path}}
+	}
+
+	var sources []Source
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Path == "" {
+			return nil, fmt.Errorf("source %q has no path", entry.Name)
+		}
+		path, err := sourcePath(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if paths[path] {
+			continue // Listed twice
+		}
+		paths[path] = true
+
+		name := entry.Name
+		if name == "" {
+			name = sourceName(path)
+		}
+		for base, n := name, 2; names[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		names[name] = true
+
+		sources = append(sources, Source{Name: name, Path: path})
+	}
+	return sources, nil
+}
+
+// sourceNamePattern matches the names a --source flag can give before "=", so that a
+// path holding "=", like "/data/a=b/projects", isn't split
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// parseSourceFlag reads a --source flag given as "path" or "name=path"
+func parseSourceFlag(flag string) sourceEntry {
+	if name, path, ok := strings.Cut(flag, "="); ok && sourceNamePattern.MatchString(name) {
+		return sourceEntry{Name: name, Path: path}
+	}
+	return sourceEntry{Path: flag}
+}
+
+// sourcePath makes a source path absolute, expanding a leading ~
+func sourcePath(path string) (string, error) {
+	expanded, err := expandPath(path)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("resolving source %s: %w", path, err)
+	}
+	return abs, nil
+}
+
+// sourceName names a source after its directory, or the config dir holding it
+// Example: "/Users/me/.claude/projects" -> "claude"
+func sourceName(path string) string {
+	base := filepath.Base(path)
+	if base == "projects" {
+		base = filepath.Base(filepath.Dir(path))
+	}
+	base = strings.TrimPrefix(base, ".")
+	if base == "" || base == string(filepath.Separator) {
+		return "source"
+	}
+	return base
+}
+
+// sourcePaths returns the paths of the sources
+func sourcePaths(sources []Source) []string {
+	paths := make([]string, len(sources))
+	for i, source := range sources {
+		paths[i] = source.Path
+	}
+	return paths
+}
+
+// multipleSources reports whether projects were found in more than one source, so
+// their origins are worth showing
+func multipleSources(projects []Project) bool {
+	for _, p := range projects {
+		if p.Source != projects[0].Source {
+			return true
+		}
+	}
+	return false
+}
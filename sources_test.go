@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveSources(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("UserHomeDir failed: %v", err)
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	data := "sources:\n  - ~/.claude/projects\n  - name: laptop\n    path: /backups/laptop/.claude/projects\n"
+	if err := os.WriteFile(config, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	t.Run("flags", func(t *testing.T) {
+		got, err := ResolveSources([]string{"/a/.claude/projects", "work=/b/projects", "/a/.claude/projects", "/c/claude"}, config)
+		if err != nil {
+			t.Fatalf("ResolveSources failed: %v", err)
+		}
+		want := []Source{
+			{Name: "claude", Path: "/a/.claude/projects"},
+			{Name: "work", Path: "/b/projects"},
+			{Name: "claude-2", Path: "/c/claude"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("paths holding =", func(t *testing.T) {
+		got, err := ResolveSources([]string{"/data/a=b/projects", "~/x=y/projects", "laptop=/data/c=d"}, config)
+		if err != nil {
+			t.Fatalf("ResolveSources failed: %v", err)
+		}
+		want := []Source{
+			{Name: "a=b", Path: "/data/a=b/projects"},
+			{Name: "x=y", Path: filepath.Join(home, "x=y", "projects")},
+			{Name: "laptop", Path: "/data/c=d"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		got, err := ResolveSources(nil, config)
+		if err != nil {
+			t.Fatalf("ResolveSources failed: %v", err)
+		}
+		want := []Source{
+			{Name: "claude", Path: filepath.Join(home, ".claude", "projects")},
+			{Name: "laptop", Path: "/backups/laptop/.claude/projects"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("CLAUDE_CONFIG_DIR", func(t *testing.T) {
+		t.Setenv("CLAUDE_CONFIG_DIR", "/opt/claude-work")
+		got, err := ResolveSources(nil, filepath.Join(dir, "missing.yaml"))
+		if err != nil {
+			t.Fatalf("ResolveSources failed: %v", err)
+		}
+		want := []Source{{Name: "claude-work", Path: "/opt/claude-work/projects"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.yaml")
+		if err := os.WriteFile(bad, []byte("sources: [{name: x}]\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if _, err := ResolveSources(nil, bad); err == nil {
+			t.Error("Expected an error for a source without a path")
+		}
+	})
+}
+
+func TestLoadAllProjectsFrom(t *testing.T) {
+	main, backup, missing := t.TempDir(), t.TempDir(), filepath.Join(t.TempDir(), "missing")
+
+	// The same project in two sources, as in a synced backup
+	for _, root := range []string{main, backup} {
+		dir := filepath.Join(root, "-work-app")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		line := `{"type":"user","uuid":"u1","cwd":"/work/app","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Hello"}}` + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(line), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	sources := []Source{{Name: "main", Path: main}, {Name: "gone", Path: missing}, {Name: "backup", Path: backup}}
+	projects, err := LoadAllProjectMetasFrom(sources, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadAllProjectMetasFrom failed: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("Loaded %d projects, want 2", len(projects))
+	}
+	origins := map[string]Project{}
+	for _, p := range projects {
+		origins[p.Source] = p
+	}
+	if p := origins["main"]; p.SourceDir != main || len(p.Sessions) != 1 || p.Slug() != "work-app" {
+		t.Errorf("Project from main = %+v, want its session and the plain slug", p)
+	}
+	if p := origins["backup"]; p.SourceDir != backup || len(p.Sessions) != 1 || p.Slug() == "work-app" {
+		t.Errorf("Project from backup = %+v, want its session and a slug of its own", p)
+	}
+	if !multipleSources(projects) {
+		t.Error("multipleSources = false, want true")
+	}
+
+	if _, err := LoadAllProjectMetasFrom([]Source{{Name: "gone", Path: missing}}, LoadOptions{}); err == nil {
+		t.Error("Expected an error when no source can be read")
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"sort"
 	"time"
 )
@@ -14,6 +16,10 @@ type StatsData struct {
 	TotalTokens   int     `json:"totalTokens"`
 	TotalCost     float64 `json:"totalCost"`
 
+	// EstimatedTokens is the part of TotalTokens guessed from content length
+	// because the session carried no API usage data
+	EstimatedTokens int `json:"estimatedTokens"`
+
 	// Time series for charts (last 30 days)
 	MessagesPerDay []TimePoint `json:"messagesPerDay"`
 	TokensPerDay   []TimePoint `json:"tokensPerDay"`
@@ -21,19 +27,29 @@ type StatsData struct {
 	// Project breakdown
 	ProjectStats []ProjectStat `json:"projectStats"`
 
+	// Model breakdown (sorted by cost, highest first)
+	ModelStats []ModelStat `json:"modelStats"`
+
+	// Tool call breakdown (sorted by calls, most first)
+	ToolStats []ToolStat `json:"toolStats"`
+
 	// Session statistics
 	AvgSessionLengthMins  float64 `json:"avgSessionLengthMins"`
 	AvgMessagesPerSession float64 `json:"avgMessagesPerSession"`
 
+	// Context compactions across all sessions (automatic and /compact)
+	TotalCompactions int `json:"totalCompactions"`
+
 	// Computed timestamp
 	ComputedAt time.Time `json:"computedAt"`
 }
 
 // TimePoint represents a single data point in a time series
 type TimePoint struct {
-	Date  string  `json:"date"`  // YYYY-MM-DD format
-	Value int     `json:"value"`
-	Cost  float64 `json:"cost"`
+	Date      string  `json:"date"` // YYYY-MM-DD format
+	Value     int     `json:"value"`
+	Cost      float64 `json:"cost"`
+	Estimated int     `json:"estimated,omitempty"` // Part of Value that is estimated (token series only)
 }
 
 // ProjectStat holds statistics for a single project
@@ -43,21 +59,55 @@ type ProjectStat struct {
 	Sessions              int         `json:"sessions"`
 	Messages              int         `json:"messages"`
 	Tokens                int         `json:"tokens"`
+	EstimatedTokens       int         `json:"estimatedTokens"`
 	Cost                  float64     `json:"cost"`
 	LastUsed              time.Time   `json:"lastUsed"`
 	MessagesPerDay        []TimePoint `json:"messagesPerDay"`
 	TokensPerDay          []TimePoint `json:"tokensPerDay"`
 	AvgSessionLengthMins  float64     `json:"avgSessionLengthMins"`
 	AvgMessagesPerSession float64     `json:"avgMessagesPerSession"`
+	ModelStats            []ModelStat `json:"modelStats"`
+	ToolStats             []ToolStat  `json:"toolStats"`
+	Compactions           int         `json:"compactions"`
+}
+
+// ModelStat holds token and cost totals for one model family
+type ModelStat struct {
+	Model            string  `json:"model"`    // Model family (see ModelFamily), "unknown" if not recorded
+	Messages         int     `json:"messages"` // Messages that contributed tokens
+	InputTokens      int64   `json:"inputTokens"`
+	OutputTokens     int64   `json:"outputTokens"`
+	CacheWriteTokens int64   `json:"cacheWriteTokens"`
+	CacheReadTokens  int64   `json:"cacheReadTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// ToolStat holds call counts and timings for one tool
+type ToolStat struct {
+	Tool            string `json:"tool"`
+	Calls           int    `json:"calls"`
+	Errors          int    `json:"errors"`          // Calls whose result reported a failure
+	Pending         int    `json:"pending"`         // Calls without a recorded result
+	TimedCalls      int    `json:"timedCalls"`      // Calls with a known duration
+	TotalDurationMs int64  `json:"totalDurationMs"` // Sum of known durations
 }
 
-// ComputeStats calculates analytics from loaded projects
-func ComputeStats(projects []Project) *StatsData {
+// ComputeStats calculates analytics from loaded projects, costing each message
+// at the price of its model valid at the message timestamp
+func ComputeStats(projects []Project, pricing *PriceTable) *StatsData {
+	return ComputeCachedStats(projects, pricing, nil)
+}
+
+// ComputeCachedStats calculates analytics like ComputeStats, taking what it needs from
+// session files that haven't changed from cache instead of loading their messages
+func ComputeCachedStats(projects []Project, pricing *PriceTable, cache *ParseCache) *StatsData {
 	stats := &StatsData{
 		ComputedAt:     time.Now(),
 		MessagesPerDay: []TimePoint{},
 		TokensPerDay:   []TimePoint{},
 		ProjectStats:   []ProjectStat{},
+		ModelStats:     []ModelStat{},
+		ToolStats:      []ToolStat{},
 	}
 
 	if len(projects) == 0 {
@@ -67,21 +117,25 @@ func ComputeStats(projects []Project) *StatsData {
 	// Maps for daily aggregation (global)
 	messagesByDay := make(map[string]int)
 	tokensByDay := make(map[string]int)
-	inputTokensByDay := make(map[string]int64)
-	outputTokensByDay := make(map[string]int64)
+	estimatedByDay := make(map[string]int)
+	costByDay := make(map[string]float64)
+	modelTotals := make(map[string]*ModelStat)
+	toolTotals := make(map[string]*ToolStat)
 
 	// Maps for per-project daily aggregation
 	projectMessagesByDay := make(map[string]map[string]int)
 	projectTokensByDay := make(map[string]map[string]int)
-	projectInputTokensByDay := make(map[string]map[string]int64)
-	projectOutputTokensByDay := make(map[string]map[string]int64)
+	projectEstimatedByDay := make(map[string]map[string]int)
+	projectCostByDay := make(map[string]map[string]float64)
+	projectModelTotals := make(map[string]map[string]*ModelStat)
+	projectToolTotals := make(map[string]map[string]*ToolStat)
 
 	// Track session lengths for averaging
 	var totalSessionMins float64
 	var sessionCount int
 
 	for _, project := range projects {
-		slug := ProjectSlug(project.Path)
+		slug := project.Slug()
 		projectStat := ProjectStat{
 			Path:     project.Path,
 			Slug:     slug,
@@ -92,21 +146,30 @@ func ComputeStats(projects []Project) *StatsData {
 		if projectMessagesByDay[slug] == nil {
 			projectMessagesByDay[slug] = make(map[string]int)
 			projectTokensByDay[slug] = make(map[string]int)
-			projectInputTokensByDay[slug] = make(map[string]int64)
-			projectOutputTokensByDay[slug] = make(map[string]int64)
+			projectEstimatedByDay[slug] = make(map[string]int)
+			projectCostByDay[slug] = make(map[string]float64)
+			projectModelTotals[slug] = make(map[string]*ModelStat)
+			projectToolTotals[slug] = make(map[string]*ToolStat)
 		}
 
 		// Track per-project session stats
 		var projectSessionMins float64
 		var projectSessionCount int
 
-		for _, session := range project.Sessions {
+		for i := range project.Sessions {
+			session := &project.Sessions[i]
+			digest, err := cache.digest(session)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to load session %s: %v\n", session.ID, err)
+				digest = &sessionDigest{}
+			}
+
 			sessionCount++
 			projectSessionCount++
 			stats.TotalSessions++
 
 			// Calculate session length (first to last message)
-			if len(session.Messages) > 0 {
+			if part := digest.Parts[session.ID]; part != nil && part.Messages > 0 {
 				sessionLength := session.UpdatedAt.Sub(session.CreatedAt).Minutes()
 				if sessionLength > 0 {
 					totalSessionMins += sessionLength
@@ -119,41 +182,70 @@ func ComputeStats(projects []Project) *StatsData {
 				projectStat.LastUsed = session.UpdatedAt
 			}
 
-			for _, msg := range session.Messages {
-				stats.TotalMessages++
-				projectStat.Messages++
-
-				// Estimate tokens from content
-				tokens := estimateTokens(msg)
-				stats.TotalTokens += tokens
-				projectStat.Tokens += tokens
-
-				// Track input vs output tokens based on role
-				// user messages = input tokens, assistant messages = output tokens
-				var inputTokens, outputTokens int64
-				if msg.Role == "user" {
-					inputTokens = int64(tokens)
-				} else {
-					outputTokens = int64(tokens)
+			// Subagent transcripts are attributed to the session that spawned them
+			parts := append([]Session{*session}, session.Subagents...)
+			for _, transcript := range parts {
+				part := digest.Parts[transcript.ID]
+				if part == nil {
+					continue
 				}
 
-				// Calculate cost for this message
-				msgCost := CalculateCost(inputTokens, outputTokens)
-				stats.TotalCost += msgCost
-				projectStat.Cost += msgCost
-
-				// Aggregate by day (global)
-				day := msg.Timestamp.Format("2006-01-02")
-				messagesByDay[day]++
-				tokensByDay[day] += tokens
-				inputTokensByDay[day] += inputTokens
-				outputTokensByDay[day] += outputTokens
-
-				// Aggregate by day (per-project)
-				projectMessagesByDay[slug][day]++
-				projectTokensByDay[slug][day] += tokens
-				projectInputTokensByDay[slug][day] += inputTokens
-				projectOutputTokensByDay[slug][day] += outputTokens
+				for _, call := range part.ToolCalls {
+					addToolCall(toolTotals, call)
+					addToolCall(projectToolTotals[slug], call)
+				}
+
+				for _, msg := range part.Usage {
+					// System events aren't messages; only compactions are counted
+					if msg.Compaction {
+						stats.TotalCompactions++
+						projectStat.Compactions++
+						continue
+					}
+
+					stats.TotalMessages++
+					projectStat.Messages++
+
+					usage, estimated := msg.usage(part.Measured)
+					tokens := int(usage.Total())
+					stats.TotalTokens += tokens
+					projectStat.Tokens += tokens
+					if estimated {
+						stats.EstimatedTokens += tokens
+						projectStat.EstimatedTokens += tokens
+					}
+
+					// Calculate cost for this message at its own model's prices
+					msgCost := pricing.Cost(msg.Model, msg.Timestamp, usage)
+					stats.TotalCost += msgCost
+					projectStat.Cost += msgCost
+
+					if tokens > 0 {
+						family := ModelFamily(msg.Model)
+						if family == "" {
+							family = "unknown"
+						}
+						addModelUsage(modelTotals, family, usage, msgCost)
+						addModelUsage(projectModelTotals[slug], family, usage, msgCost)
+					}
+
+					// Aggregate by day (global)
+					day := msg.Timestamp.Format("2006-01-02")
+					messagesByDay[day]++
+					tokensByDay[day] += tokens
+					if estimated {
+						estimatedByDay[day] += tokens
+					}
+					costByDay[day] += msgCost
+
+					// Aggregate by day (per-project)
+					projectMessagesByDay[slug][day]++
+					projectTokensByDay[slug][day] += tokens
+					if estimated {
+						projectEstimatedByDay[slug][day] += tokens
+					}
+					projectCostByDay[slug][day] += msgCost
+				}
 			}
 		}
 
@@ -161,10 +253,12 @@ func ComputeStats(projects []Project) *StatsData {
 		projectStat.MessagesPerDay = buildTimeSeries(projectMessagesByDay[slug], 30)
 		projectStat.TokensPerDay = buildTimeSeriesWithCost(
 			projectTokensByDay[slug],
-			projectInputTokensByDay[slug],
-			projectOutputTokensByDay[slug],
+			projectEstimatedByDay[slug],
+			projectCostByDay[slug],
 			30,
 		)
+		projectStat.ModelStats = sortedModelStats(projectModelTotals[slug])
+		projectStat.ToolStats = sortedToolStats(projectToolTotals[slug])
 
 		// Calculate per-project averages
 		if projectSessionCount > 0 {
@@ -185,7 +279,9 @@ func ComputeStats(projects []Project) *StatsData {
 
 	// Convert daily maps to sorted time series (last 30 days)
 	stats.MessagesPerDay = buildTimeSeries(messagesByDay, 30)
-	stats.TokensPerDay = buildTimeSeriesWithCost(tokensByDay, inputTokensByDay, outputTokensByDay, 30)
+	stats.TokensPerDay = buildTimeSeriesWithCost(tokensByDay, estimatedByDay, costByDay, 30)
+	stats.ModelStats = sortedModelStats(modelTotals)
+	stats.ToolStats = sortedToolStats(toolTotals)
 
 	// Sort projects by last used (most recent first)
 	sort.Slice(stats.ProjectStats, func(i, j int) bool {
@@ -195,13 +291,168 @@ func ComputeStats(projects []Project) *StatsData {
 	return stats
 }
 
+// statsPart is what the stats need from one transcript: the usage of its messages and
+// its tool calls
+type statsPart struct {
+	Messages  int           // Messages, including system events
+	Measured  bool          // A message reports API usage, so none is estimated
+	Usage     []usageRecord // Messages and compactions, in order; other events are left out
+	ToolCalls []ToolCall    // Tool calls without their input and output
+}
+
+// usageRecord is the usage of one message, or a compaction
+type usageRecord struct {
+	Timestamp  time.Time
+	Model      string
+	Usage      *Usage // Reported by the API, nil if the message has none
+	Estimate   Usage  // Estimated from content length, for transcripts without reported usage
+	Compaction bool   // A compaction event rather than a message
+}
+
+// usage returns the usage attributed to the message and whether it is estimated, as
+// messageUsage does
+func (r usageRecord) usage(measured bool) (Usage, bool) {
+	if measured {
+		if r.Usage == nil {
+			return Usage{}, false
+		}
+		return *r.Usage, false
+	}
+	return r.Estimate, true
+}
+
+// merge adds the messages and tool calls of another part
+func (p *statsPart) merge(other *statsPart) {
+	if other == nil {
+		return
+	}
+	p.Messages += other.Messages
+	p.Measured = p.Measured || other.Measured
+	p.Usage = append(p.Usage, other.Usage...)
+	p.ToolCalls = append(p.ToolCalls, other.ToolCalls...)
+}
+
+// SessionTotals returns the tokens and cost of a session's own messages,
+// excluding its subagents
+func SessionTotals(session Session, pricing *PriceTable) (int, float64) {
+	measured := sessionHasUsage(session)
+	var tokens int
+	var cost float64
+	for _, msg := range session.Messages {
+		usage, _ := messageUsage(msg, measured)
+		tokens += int(usage.Total())
+		cost += pricing.Cost(msg.Model, msg.Timestamp, usage)
+	}
+	return tokens, cost
+}
+
+// sessionHasUsage reports whether any message in the session carries API usage data
+func sessionHasUsage(session Session) bool {
+	for _, msg := range session.Messages {
+		if msg.Usage != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// messageUsage returns the token usage attributed to a message and whether it is estimated.
+// In sessions with API usage data only measured counts are used, since an assistant's
+// input tokens already cover the user turns before it. Otherwise tokens are estimated
+// from content length: user messages as input, assistant messages as output.
+func messageUsage(msg Message, measured bool) (Usage, bool) {
+	if measured {
+		if msg.Usage == nil {
+			return Usage{}, false
+		}
+		return *msg.Usage, false
+	}
+
+	return estimatedUsage(msg), true
+}
+
+// estimatedUsage estimates a message's usage from its content length: user messages
+// as input, assistant messages as output
+func estimatedUsage(msg Message) Usage {
+	tokens := int64(estimateTokens(msg))
+	if msg.Role == "user" {
+		return Usage{InputTokens: tokens}
+	}
+	return Usage{OutputTokens: tokens}
+}
+
+// addModelUsage adds a message's usage and cost to the per-model totals
+func addModelUsage(totals map[string]*ModelStat, family string, usage Usage, cost float64) {
+	ms := totals[family]
+	if ms == nil {
+		ms = &ModelStat{Model: family}
+		totals[family] = ms
+	}
+	ms.Messages++
+	ms.InputTokens += usage.InputTokens
+	ms.OutputTokens += usage.OutputTokens
+	ms.CacheWriteTokens += usage.CacheCreationInputTokens
+	ms.CacheReadTokens += usage.CacheReadInputTokens
+	ms.Cost += cost
+}
+
+// sortedModelStats flattens per-model totals, sorted by cost (highest first)
+func sortedModelStats(totals map[string]*ModelStat) []ModelStat {
+	result := make([]ModelStat, 0, len(totals))
+	for _, ms := range totals {
+		result = append(result, *ms)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost > result[j].Cost
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
+
+// addToolCall adds a tool call to the per-tool totals
+func addToolCall(totals map[string]*ToolStat, call ToolCall) {
+	ts := totals[call.Name]
+	if ts == nil {
+		ts = &ToolStat{Tool: call.Name}
+		totals[call.Name] = ts
+	}
+	ts.Calls++
+	switch call.Status() {
+	case ToolCallError:
+		ts.Errors++
+	case ToolCallPending:
+		ts.Pending++
+	}
+	if d := call.Duration(); d > 0 {
+		ts.TimedCalls++
+		ts.TotalDurationMs += d.Milliseconds()
+	}
+}
+
+// sortedToolStats flattens per-tool totals, sorted by calls (most first)
+func sortedToolStats(totals map[string]*ToolStat) []ToolStat {
+	result := make([]ToolStat, 0, len(totals))
+	for _, ts := range totals {
+		result = append(result, *ts)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Tool < result[j].Tool
+	})
+	return result
+}
+
 // estimateTokens estimates token count from message content
 // Uses rough approximation: 1 token ≈ 4 characters
 func estimateTokens(msg Message) int {
 	var totalChars int
 	for _, block := range msg.Content {
 		switch block.Type {
-		case "text":
+		case "text", "thinking":
 			totalChars += len(block.Text)
 		case "tool_use":
 			totalChars += len(block.ToolInput)
@@ -240,7 +491,7 @@ func buildTimeSeries(data map[string]int, days int) []TimePoint {
 
 // buildTimeSeriesWithCost creates a sorted time series with cost data
 // Returns data for the last N days, filling in zeros for missing days
-func buildTimeSeriesWithCost(tokenData map[string]int, inputTokens, outputTokens map[string]int64, days int) []TimePoint {
+func buildTimeSeriesWithCost(tokenData, estimatedData map[string]int, costData map[string]float64, days int) []TimePoint {
 	if len(tokenData) == 0 {
 		return []TimePoint{}
 	}
@@ -254,13 +505,11 @@ func buildTimeSeriesWithCost(tokenData map[string]int, inputTokens, outputTokens
 		date := startDate.AddDate(0, 0, i)
 		dateStr := date.Format("2006-01-02")
 		value := tokenData[dateStr]
-		inputTok := inputTokens[dateStr]
-		outputTok := outputTokens[dateStr]
-		cost := CalculateCost(inputTok, outputTok)
 		result = append(result, TimePoint{
-			Date:  dateStr,
-			Value: value,
-			Cost:  cost,
+			Date:      dateStr,
+			Value:     value,
+			Cost:      costData[dateStr],
+			Estimated: estimatedData[dateStr],
 		})
 	}
 
@@ -289,13 +538,14 @@ func FilterStatsByTimeRange(stats *StatsData, rangeType string) *StatsData {
 	filteredTokens := filterTimePoints(stats.TokensPerDay, cutoffStr)
 
 	// Recalculate totals from filtered data
-	var totalMessages, totalTokens int
+	var totalMessages, totalTokens, estimatedTokens int
 	var totalCost float64
 	for _, tp := range filteredMessages {
 		totalMessages += tp.Value
 	}
 	for _, tp := range filteredTokens {
 		totalTokens += tp.Value
+		estimatedTokens += tp.Estimated
 		totalCost += tp.Cost
 	}
 
@@ -305,9 +555,11 @@ func FilterStatsByTimeRange(stats *StatsData, rangeType string) *StatsData {
 		TotalMessages:         totalMessages,
 		TotalTokens:           totalTokens,
 		TotalCost:             totalCost,
+		EstimatedTokens:       estimatedTokens,
 		MessagesPerDay:        filteredMessages,
 		TokensPerDay:          filteredTokens,
 		ProjectStats:          stats.ProjectStats,
+		ModelStats:            stats.ModelStats,
 		AvgSessionLengthMins:  stats.AvgSessionLengthMins,
 		AvgMessagesPerSession: stats.AvgMessagesPerSession,
 		ComputedAt:            stats.ComputedAt,
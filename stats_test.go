@@ -1,12 +1,13 @@
 package main
 
 import (
+	"math"
 	"testing"
 	"time"
 )
 
 func TestComputeStats_EmptyProjects(t *testing.T) {
-	stats := ComputeStats([]Project{})
+	stats := ComputeStats([]Project{}, DefaultPriceTable())
 
 	if stats.TotalProjects != 0 {
 		t.Errorf("Expected TotalProjects=0, got %d", stats.TotalProjects)
@@ -57,7 +58,7 @@ func TestComputeStats_SingleProject(t *testing.T) {
 		},
 	}
 
-	stats := ComputeStats(projects)
+	stats := ComputeStats(projects, DefaultPriceTable())
 
 	if stats.TotalProjects != 1 {
 		t.Errorf("Expected TotalProjects=1, got %d", stats.TotalProjects)
@@ -113,7 +114,7 @@ func TestComputeStats_MultipleProjects(t *testing.T) {
 		},
 	}
 
-	stats := ComputeStats(projects)
+	stats := ComputeStats(projects, DefaultPriceTable())
 
 	if stats.TotalProjects != 2 {
 		t.Errorf("Expected TotalProjects=2, got %d", stats.TotalProjects)
@@ -131,6 +132,244 @@ func TestComputeStats_MultipleProjects(t *testing.T) {
 	}
 }
 
+func TestComputeStats_MeasuredUsage(t *testing.T) {
+	now := time.Now()
+	projects := []Project{
+		{
+			Path: "/test/measured",
+			Sessions: []Session{
+				{
+					ID:        "measured",
+					CreatedAt: now.Add(-1 * time.Minute),
+					UpdatedAt: now,
+					Messages: []Message{
+						{UUID: "m1", Role: "user", Timestamp: now.Add(-1 * time.Minute), Content: []ContentBlock{{Type: "text", Text: "a long prompt that would otherwise be estimated"}}},
+						{UUID: "m2", Role: "assistant", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: "ok"}},
+							Usage: &Usage{InputTokens: 100, OutputTokens: 50, CacheCreationInputTokens: 20, CacheReadInputTokens: 30}},
+					},
+				},
+			},
+		},
+		{
+			Path: "/test/estimated",
+			Sessions: []Session{
+				{
+					ID:        "estimated",
+					CreatedAt: now.Add(-1 * time.Minute),
+					UpdatedAt: now,
+					Messages: []Message{
+						{UUID: "m3", Role: "user", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: "12345678"}}}, // 2 tokens
+					},
+				},
+			},
+		},
+	}
+
+	stats := ComputeStats(projects, DefaultPriceTable())
+
+	// Measured session counts only API usage (200), user prompt is not estimated on top
+	if stats.TotalTokens != 202 {
+		t.Errorf("Expected TotalTokens=202, got %d", stats.TotalTokens)
+	}
+	if stats.EstimatedTokens != 2 {
+		t.Errorf("Expected EstimatedTokens=2, got %d", stats.EstimatedTokens)
+	}
+
+	for _, ps := range stats.ProjectStats {
+		switch ps.Path {
+		case "/test/measured":
+			if ps.Tokens != 200 || ps.EstimatedTokens != 0 {
+				t.Errorf("measured project: Tokens=%d EstimatedTokens=%d, want 200/0", ps.Tokens, ps.EstimatedTokens)
+			}
+		case "/test/estimated":
+			if ps.Tokens != 2 || ps.EstimatedTokens != 2 {
+				t.Errorf("estimated project: Tokens=%d EstimatedTokens=%d, want 2/2", ps.Tokens, ps.EstimatedTokens)
+			}
+		}
+	}
+
+	today := now.Format("2006-01-02")
+	for _, tp := range stats.TokensPerDay {
+		if tp.Date == today && (tp.Value != 202 || tp.Estimated != 2) {
+			t.Errorf("Today's TimePoint = %+v, want Value=202 Estimated=2", tp)
+		}
+	}
+}
+
+func TestComputeStats_ModelBreakdown(t *testing.T) {
+	now := time.Now()
+	projects := []Project{
+		{
+			Path: "/test/models",
+			Sessions: []Session{
+				{
+					ID:        "s1",
+					CreatedAt: now,
+					UpdatedAt: now,
+					Messages: []Message{
+						{UUID: "m1", Role: "user", Timestamp: now},
+						{UUID: "m2", Role: "assistant", Timestamp: now, Model: "claude-opus-4-1-20250805",
+							Usage: &Usage{InputTokens: 1_000_000}},
+						{UUID: "m3", Role: "assistant", Timestamp: now, Model: "claude-sonnet-4-5-20250929",
+							Usage: &Usage{InputTokens: 1_000_000}},
+						{UUID: "m4", Role: "assistant", Timestamp: now, Model: "claude-sonnet-4-5-20250929",
+							Usage: &Usage{CacheReadInputTokens: 1_000_000}},
+					},
+				},
+			},
+		},
+	}
+
+	stats := ComputeStats(projects, DefaultPriceTable())
+
+	// $15 (opus 4.1 input) + $3 (sonnet input) + $0.30 (sonnet cache read)
+	if math.Abs(stats.TotalCost-18.30) > 0.0001 {
+		t.Errorf("Expected TotalCost=18.30, got %v", stats.TotalCost)
+	}
+
+	if len(stats.ModelStats) != 2 {
+		t.Fatalf("Expected 2 ModelStats, got %d", len(stats.ModelStats))
+	}
+	// Sorted by cost, highest first
+	if stats.ModelStats[0].Model != "opus-4-1" {
+		t.Errorf("Expected opus-4-1 first, got %s", stats.ModelStats[0].Model)
+	}
+	sonnet := stats.ModelStats[1]
+	if sonnet.Model != "sonnet-4-5" || sonnet.Messages != 2 || sonnet.CacheReadTokens != 1_000_000 {
+		t.Errorf("Unexpected sonnet stats: %+v", sonnet)
+	}
+
+	if len(stats.ProjectStats[0].ModelStats) != 2 {
+		t.Errorf("Expected per-project ModelStats, got %d", len(stats.ProjectStats[0].ModelStats))
+	}
+}
+
+func TestComputeStats_SubagentUsage(t *testing.T) {
+	now := time.Now()
+	projects := []Project{
+		{
+			Path: "/test/project",
+			Sessions: []Session{
+				{
+					ID:        "parent",
+					CreatedAt: now,
+					UpdatedAt: now,
+					Messages: []Message{
+						{UUID: "m1", Role: "assistant", Timestamp: now, Model: "claude-sonnet-4-5", Usage: &Usage{OutputTokens: 1_000_000}},
+					},
+					Subagents: []Session{
+						{
+							ID: "agent-1",
+							Messages: []Message{
+								{UUID: "s1", Role: "assistant", Timestamp: now, Model: "claude-haiku-4-5", Usage: &Usage{OutputTokens: 1_000_000}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	stats := ComputeStats(projects, DefaultPriceTable())
+
+	if stats.TotalSessions != 1 {
+		t.Errorf("Subagents should not count as sessions, got %d sessions", stats.TotalSessions)
+	}
+	if stats.TotalTokens != 2_000_000 {
+		t.Errorf("Expected subagent tokens attributed to parent, got %d", stats.TotalTokens)
+	}
+	// $15 (sonnet output) + $5 (haiku output)
+	if math.Abs(stats.TotalCost-20.0) > 0.0001 {
+		t.Errorf("Expected TotalCost=20, got %v", stats.TotalCost)
+	}
+	if len(stats.ModelStats) != 2 {
+		t.Errorf("Expected sonnet and haiku in model breakdown, got %+v", stats.ModelStats)
+	}
+
+	tokens, cost := SessionTotals(projects[0].Sessions[0].Subagents[0], DefaultPriceTable())
+	if tokens != 1_000_000 || math.Abs(cost-5.0) > 0.0001 {
+		t.Errorf("SessionTotals = %d, %v; want 1000000, 5", tokens, cost)
+	}
+}
+
+func TestComputeStats_Compactions(t *testing.T) {
+	now := time.Now()
+	compact := &SystemEvent{Kind: EventCompact, Trigger: "auto"}
+	projects := []Project{
+		{
+			Path: "/test/project",
+			Sessions: []Session{
+				{
+					ID:        "session-1",
+					CreatedAt: now,
+					UpdatedAt: now,
+					Messages: []Message{
+						{UUID: "m1", Role: "user", Timestamp: now, Content: []ContentBlock{{Type: "text", Text: "Hello"}}},
+						{UUID: "e1", Role: "system", Timestamp: now, Event: compact},
+						{UUID: "e2", Role: "system", Timestamp: now, Event: &SystemEvent{Kind: EventCommand, Text: "/model"}},
+						{UUID: "e3", Role: "system", Timestamp: now, Event: compact},
+					},
+				},
+			},
+		},
+	}
+
+	stats := ComputeStats(projects, DefaultPriceTable())
+
+	if stats.TotalMessages != 1 {
+		t.Errorf("System events should not count as messages, got %d", stats.TotalMessages)
+	}
+	if stats.TotalCompactions != 2 || stats.ProjectStats[0].Compactions != 2 {
+		t.Errorf("Expected 2 compactions, got total=%d project=%d", stats.TotalCompactions, stats.ProjectStats[0].Compactions)
+	}
+	if got := projects[0].Sessions[0].Compactions(); got != 2 {
+		t.Errorf("Session.Compactions() = %d, want 2", got)
+	}
+}
+
+func TestComputeStats_ToolStats(t *testing.T) {
+	now := time.Now()
+	projects := []Project{
+		{
+			Path: "/test/project",
+			Sessions: []Session{
+				{
+					ID: "session-1",
+					ToolCalls: []ToolCall{
+						{ID: "t1", Name: "Bash", HasResult: true, StartedAt: now, EndedAt: now.Add(2 * time.Second)},
+						{ID: "t2", Name: "Bash", HasResult: true, IsError: true, StartedAt: now, EndedAt: now.Add(1 * time.Second)},
+						{ID: "t3", Name: "Read", HasResult: true, StartedAt: now, EndedAt: now.Add(100 * time.Millisecond)},
+						{ID: "t4", Name: "Read", StartedAt: now},
+						{ID: "t5", Name: "Read", HasResult: true},
+					},
+					Subagents: []Session{
+						{ID: "agent-1", ToolCalls: []ToolCall{{ID: "t6", Name: "Grep", HasResult: true}}},
+					},
+				},
+			},
+		},
+	}
+
+	stats := ComputeStats(projects, DefaultPriceTable())
+
+	if len(stats.ToolStats) != 3 {
+		t.Fatalf("Expected 3 tools, got %+v", stats.ToolStats)
+	}
+	read, bash, grep := stats.ToolStats[0], stats.ToolStats[1], stats.ToolStats[2]
+	if read.Tool != "Read" || read.Calls != 3 || read.Pending != 1 || read.TimedCalls != 1 || read.TotalDurationMs != 100 {
+		t.Errorf("Unexpected Read stats: %+v", read)
+	}
+	if bash.Tool != "Bash" || bash.Calls != 2 || bash.Errors != 1 || bash.TotalDurationMs != 3000 {
+		t.Errorf("Unexpected Bash stats: %+v", bash)
+	}
+	if grep.Tool != "Grep" || grep.Calls != 1 {
+		t.Errorf("Subagent tool calls should be counted, got %+v", grep)
+	}
+	if len(stats.ProjectStats[0].ToolStats) != 3 {
+		t.Errorf("Expected per-project tool stats, got %+v", stats.ProjectStats[0].ToolStats)
+	}
+}
+
 func TestEstimateTokens(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -301,7 +540,7 @@ func TestComputeStats_PerProjectTimeSeries(t *testing.T) {
 		},
 	}
 
-	stats := ComputeStats(projects)
+	stats := ComputeStats(projects, DefaultPriceTable())
 
 	// Verify we have 2 projects
 	if len(stats.ProjectStats) != 2 {
@@ -401,7 +640,7 @@ func TestAvgSessionLength(t *testing.T) {
 		},
 	}
 
-	stats := ComputeStats(projects)
+	stats := ComputeStats(projects, DefaultPriceTable())
 
 	// Average should be (30 + 10) / 2 = 20 minutes
 	if stats.AvgSessionLengthMins != 20 {
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+)
+
+// SessionMeta summarizes a session's messages, so sessions loaded with LoadSessionMeta
+// can be listed, counted and linked without keeping their messages in memory
+type SessionMeta struct {
+	MessageCount int    // Conversation messages, excluding system events
+	Compactions  int    // Context compactions
+	ToolErrors   int    // Failed tool calls
+	FirstPrompt  string // Text of the first user message
+	Streamed     bool   // Loaded with LoadSessionMeta: Messages and ToolCalls aren't set
+
+	// Used to link resumed sessions and subagents while a project is loaded, then dropped
+	UUIDs         []string          // Message UUIDs in file order
+	RootParent    string            // Parent UUID of the first message
+	Tasks         []taskCall        // Task calls that may have spawned subagents
+	AgentToolUses map[string]string // Subagent ID -> Task tool_use ID, from Task results
+}
+
+// taskCall is a Task tool_use, kept to link the subagent transcript it spawned
+type taskCall struct {
+	ToolUseID string
+	Input     taskInput
+}
+
+// dropLinks releases the data only needed to link sessions while loading a project
+func (m *SessionMeta) dropLinks() {
+	m.UUIDs = nil
+	m.Tasks = nil
+	m.AgentToolUses = nil
+}
+
+// StreamSession reads a session file one line at a time and calls fn with each message,
+// in file order, without keeping earlier messages in memory. Memory use is bounded by the
+// longest line and the entries of one response. Returning an error from fn stops reading.
+func StreamSession(filePath, sessionID string, fn func(*Message) error) error {
+	file, err := openSessionFile(filePath, 0)
+	if err != nil {
+		return fmt.Errorf("opening session file: %w", err)
+	}
+	defer file.Close()
+
+	sc := &sessionScanner{SessionID: sessionID}
+	tail, err := sc.scan(file, fn)
+	if err != nil {
+		return err
+	}
+	if tail != nil {
+		sc.Line++
+		if err := sc.handleLine(tail, fn); err != nil {
+			return err
+		}
+	}
+	return sc.flush(fn)
+}
+
+// LoadSessionMeta loads a session's metadata, counts and subagent transcripts without
+// its messages: Messages is nil on the returned session and its subagents. Use
+// LoadSessionMessages to load them when the session is rendered or indexed.
+func LoadSessionMeta(filePath, sessionID string) (*Session, error) {
+	return parseSessionFile(filePath, sessionID, false)
+}
+
+// LoadSessionMessages returns a session loaded with LoadSessionMeta together with its
+// messages and those of its subagents, keeping the links made when its project was
+// loaded. Sessions that already have their messages are returned as is.
+func LoadSessionMessages(session *Session) (*Session, error) {
+	return loadSessionMessages(session, ParseSession)
+}
+
+// loadSessionMessages is LoadSessionMessages, parsing the session files with parse
+func loadSessionMessages(session *Session, parse sessionLoader) (*Session, error) {
+	if !session.Meta.Streamed {
+		return session, nil
+	}
+
+	full, err := parse(session.SourcePath, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	full.Summary = session.Summary
+	full.PredecessorID = session.PredecessorID
+	full.SuccessorIDs = session.SuccessorIDs
+	full.ConversationID = session.ConversationID
+	full.ParentSessionID = session.ParentSessionID
+	full.ParentToolUseID = session.ParentToolUseID
+	full.Archived = session.Archived
+
+	// Sidechains split from the session file come back with the same IDs; transcripts
+	// in their own files are loaded from there
+	sidechains := make(map[string]int, len(full.Subagents))
+	for i, sub := range full.Subagents {
+		sidechains[sub.ID] = i
+	}
+	claimed := make(map[int]bool)
+	subagents := make([]Session, 0, len(session.Subagents))
+	for _, sub := range session.Subagents {
+		var loaded *Session
+		if i, ok := sidechains[sub.ID]; ok {
+			claimed[i] = true
+			loaded = &full.Subagents[i]
+		} else if sub.SourcePath != session.SourcePath {
+			if loaded, err = parse(sub.SourcePath, sub.ID); err != nil {
+				return nil, fmt.Errorf("loading subagent %s: %w", sub.ID, err)
+			}
+		} else {
+			continue // The session file changed since it was listed
+		}
+		loaded.Summary = sub.Summary
+		loaded.ParentSessionID = sub.ParentSessionID
+		loaded.ParentToolUseID = sub.ParentToolUseID
+		loaded.Archived = sub.Archived
+		subagents = append(subagents, *loaded)
+	}
+	for i, sub := range full.Subagents {
+		if !claimed[i] {
+			subagents = append(subagents, sub)
+		}
+	}
+	full.Subagents = subagents
+
+	return full, nil
+}
+
+// sessionScanner reads the entries of a session file in order, turning user, assistant
+// and system entries into messages and remembering what the other entries say. Its
+// fields are exported so the parse cache can keep it and resume reading later.
+type sessionScanner struct {
+	SessionID string
+	Offset    int64  // End of the last complete line read
+	Line      int    // Lines read
+	Prefix    string // SHA-256 of the file up to Offset, to tell appends from rewrites
+	HashState []byte // State of the hash giving Prefix, to extend it with the next lines
+
+	Summary         string   // Last summary entry
+	LeafUUIDs       []string // leafUuids of summary entries, in file order
+	CWD             string   // First working directory reported
+	AgentID         string   // Sidechain entries: subagent ID
+	ParentSessionID string   // Sidechain entries: session that spawned the subagent
+
+	// Entries that don't become messages (snapshots, progress, ...) can still be
+	// parents; remember their own parent so message threading can skip over them
+	Bridges map[string]string // skipped entry UUID -> its parent UUID
+
+	// Claude Code writes one entry per content block of a response, each repeating
+	// the response usage; only the last entry of a response keeps it. Messages are
+	// held back until the next response starts so earlier entries can be cleared.
+	Held      []*Message
+	Responses map[string]int // open response ID -> index in Held of its entry with usage, or -1
+
+	Diagnostics ParseDiagnostics // Problems met so far
+}
+
+// scan reads the complete lines of file, which is read from Offset, calling emit with
+// each message once its response is complete. A last line without its newline is
+// returned instead: Claude Code may still be writing it, so it is read again on the
+// next scan.
+func (sc *sessionScanner) scan(file io.Reader, emit func(*Message) error) ([]byte, error) {
+	// Empty maps don't survive the parse cache
+	if sc.Bridges == nil {
+		sc.Bridges = make(map[string]string)
+	}
+	if sc.Responses == nil {
+		sc.Responses = make(map[string]int)
+	}
+
+	prefix := sha256.New()
+	if sc.HashState != nil {
+		if err := prefix.(encoding.BinaryUnmarshaler).UnmarshalBinary(sc.HashState); err != nil {
+			return nil, fmt.Errorf("resuming session file hash: %w", err)
+		}
+	}
+	scanner := bufio.NewScanner(file)
+	// Increase buffer size for very long lines (some Claude sessions have 20MB+ lines)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 50*1024*1024) // 50MB max line size
+
+	var advance int
+	var complete bool
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		n, token, err := bufio.ScanLines(data, atEOF)
+		advance, complete = n, n > 0 && data[n-1] == '\n'
+		if complete {
+			prefix.Write(data[:n])
+		}
+		return n, token, err
+	})
+
+	var tail []byte
+	for scanner.Scan() {
+		if !complete {
+			tail = bytes.Clone(scanner.Bytes())
+			break
+		}
+		sc.Offset += int64(advance)
+		sc.Line++
+		if err := sc.handleLine(scanner.Bytes(), emit); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading session file: %w", err)
+	}
+
+	sc.Prefix = hex.EncodeToString(prefix.Sum(nil))
+	state, err := prefix.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("saving session file hash: %w", err)
+	}
+	sc.HashState = state
+	return tail, nil
+}
+
+// handleLine processes one line of the session file
+func (sc *sessionScanner) handleLine(line []byte, emit func(*Message) error) error {
+	if len(line) == 0 {
+		return nil
+	}
+
+	// Parse the JSONL entry
+	var entry jsonlEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		sc.diagnostics().malformed(err) // Skip the line
+		return nil
+	}
+	return sc.handle(&entry, emit)
+}
+
+// diagnostics records the problems of the current line
+func (sc *sessionScanner) diagnostics() lineDiagnostics {
+	return lineDiagnostics{file: &sc.Diagnostics, line: sc.Line}
+}
+
+// handle processes one entry of the session file
+func (sc *sessionScanner) handle(entry *jsonlEntry, emit func(*Message) error) error {
+	switch entry.Type {
+	case "summary":
+		sc.Summary = entry.Summary
+		if entry.LeafUUID != "" {
+			sc.LeafUUIDs = append(sc.LeafUUIDs, entry.LeafUUID)
+		}
+
+	case "user", "assistant":
+		msg, err := parseMessage(entry, sc.diagnostics())
+		if err != nil {
+			sc.diagnostics().malformed(err)
+			bridgeEntry(sc.Bridges, entry)
+			return nil
+		}
+		msg.ParentUUID = resolveParent(sc.Bridges, msg.ParentUUID)
+		if msg.Role == "user" && len(msg.Content) == 1 && msg.Content[0].Type == "text" {
+			// Slash commands and their output are recorded as user messages
+			if event := commandEvent(msg.Content[0].Text); event != nil {
+				msg.Role = "system"
+				msg.Content = nil
+				msg.Event = event
+			}
+		}
+		if entry.IsSidechain {
+			if sc.AgentID == "" {
+				sc.AgentID = entry.AgentID
+			}
+			if sc.ParentSessionID == "" {
+				sc.ParentSessionID = entry.SessionID
+			}
+		}
+		// Capture CWD from first entry that has it (actual project path)
+		if sc.CWD == "" && entry.CWD != "" {
+			sc.CWD = entry.CWD
+		}
+		return sc.push(msg, emit)
+
+	case "system":
+		msg := parseSystemEntry(entry, sc.diagnostics())
+		msg.ParentUUID = resolveParent(sc.Bridges, msg.ParentUUID)
+		return sc.push(msg, emit)
+
+	case "file-history-snapshot":
+		// Skip these entries
+
+	default:
+		// Unknown type, skip it
+		sc.diagnostics().unknownEntry(entry.Type)
+		bridgeEntry(sc.Bridges, entry)
+	}
+	return nil
+}
+
+// push holds a message until the response it belongs to is complete
+func (sc *sessionScanner) push(msg *Message, emit func(*Message) error) error {
+	if msg.ResponseID != "" {
+		prev, open := sc.Responses[msg.ResponseID]
+		if !open {
+			// A new response: the earlier ones are complete
+			if err := sc.flush(emit); err != nil {
+				return err
+			}
+			prev = -1
+		}
+		if msg.Usage != nil {
+			if prev >= 0 {
+				sc.Held[prev].Usage = nil
+			}
+			prev = len(sc.Held)
+		}
+		sc.Responses[msg.ResponseID] = prev
+	}
+	sc.Held = append(sc.Held, msg)
+	return nil
+}
+
+// flush emits the held messages
+func (sc *sessionScanner) flush(emit func(*Message) error) error {
+	for _, msg := range sc.Held {
+		if err := emit(msg); err != nil {
+			return err
+		}
+	}
+	sc.Held = sc.Held[:0]
+	clear(sc.Responses)
+	return nil
+}
+
+// clone copies the scanner so that reading on with the copy leaves it unchanged
+func (sc *sessionScanner) clone() sessionScanner {
+	c := *sc
+	c.LeafUUIDs = slices.Clip(sc.LeafUUIDs)
+	c.Bridges = maps.Clone(sc.Bridges)
+	c.Responses = maps.Clone(sc.Responses)
+	c.Diagnostics = sc.Diagnostics.clone()
+	c.Held = make([]*Message, len(sc.Held))
+	for i, msg := range sc.Held {
+		held := *msg // Its usage may still be cleared
+		c.Held[i] = &held
+	}
+	return c
+}
+
+// metaBuilder accumulates a session's environment and SessionMeta from its messages
+type metaBuilder struct {
+	Meta     SessionMeta
+	Count    int  // Messages added, including system events
+	Prompted bool // The first user message was seen
+
+	CreatedAt time.Time // Earliest message timestamp
+	UpdatedAt time.Time // Latest message timestamp
+
+	GitBranch      string
+	GitBranches    []string
+	Version        string
+	PermissionMode string
+	UserType       string
+}
+
+// add records one message
+func (b *metaBuilder) add(msg *Message) {
+	b.Count++
+	if b.Count == 1 {
+		b.Meta.RootParent = msg.ParentUUID
+	}
+	if msg.UUID != "" {
+		b.Meta.UUIDs = append(b.Meta.UUIDs, msg.UUID)
+	}
+	observeTime(&b.CreatedAt, &b.UpdatedAt, msg.Timestamp)
+
+	// Only user entries carry the permission mode, so each field keeps the last value
+	// reported rather than that of the last message
+	if msg.GitBranch != "" {
+		b.GitBranch = msg.GitBranch
+		if !containsString(b.GitBranches, msg.GitBranch) {
+			b.GitBranches = append(b.GitBranches, msg.GitBranch)
+		}
+	}
+	if msg.Version != "" {
+		b.Version = msg.Version
+	}
+	if msg.PermissionMode != "" {
+		b.PermissionMode = msg.PermissionMode
+	}
+	if msg.UserType != "" {
+		b.UserType = msg.UserType
+	}
+
+	if msg.Event != nil {
+		if msg.Event.Kind == EventCompact {
+			b.Meta.Compactions++
+		}
+		return
+	}
+	b.Meta.MessageCount++
+
+	if msg.Role == "user" && !b.Prompted {
+		b.Prompted = true
+		for _, block := range msg.Content {
+			if block.Type == "text" && block.Text != "" {
+				b.Meta.FirstPrompt = block.Text
+				break
+			}
+		}
+	}
+
+	for _, block := range msg.Content {
+		switch {
+		case block.Type == "tool_result":
+			if block.IsError {
+				b.Meta.ToolErrors++
+			}
+			if block.AgentID != "" {
+				if b.Meta.AgentToolUses == nil {
+					b.Meta.AgentToolUses = make(map[string]string)
+				}
+				b.Meta.AgentToolUses[block.AgentID] = block.ToolUseID
+			}
+		case block.Type == "tool_use" && isTaskTool(block.ToolName):
+			task := taskCall{ToolUseID: block.ToolUseID}
+			json.Unmarshal([]byte(block.ToolInput), &task.Input)
+			b.Meta.Tasks = append(b.Meta.Tasks, task)
+		}
+	}
+}
+
+// finish sets the session's time span, environment and metadata
+func (b *metaBuilder) finish(session *Session) {
+	session.CreatedAt = b.CreatedAt
+	session.UpdatedAt = b.UpdatedAt
+	session.GitBranch = b.GitBranch
+	session.GitBranches = b.GitBranches
+	session.Version = b.Version
+	session.PermissionMode = b.PermissionMode
+	session.UserType = b.UserType
+	session.Meta = b.Meta
+}
+
+// clone copies the builder so that adding to the copy leaves it unchanged
+func (b *metaBuilder) clone() metaBuilder {
+	c := *b
+	c.GitBranches = slices.Clip(b.GitBranches)
+	c.Meta.UUIDs = slices.Clip(b.Meta.UUIDs)
+	c.Meta.Tasks = slices.Clip(b.Meta.Tasks)
+	c.Meta.AgentToolUses = maps.Clone(b.Meta.AgentToolUses)
+	return c
+}
+
+// observeTime extends a time span to a message timestamp
+func observeTime(created, updated *time.Time, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if created.IsZero() || t.Before(*created) {
+		*created = t
+	}
+	if t.After(*updated) {
+		*updated = t
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sidechain is a subagent transcript interleaved in a session file
+type sidechain struct {
+	ID       string
+	Messages []Message
+	Meta     metaBuilder
+}
+
+// sessionParse builds a session from its file, moving subagent (sidechain) messages
+// interleaved by older Claude Code versions into one transcript per parentUuid chain.
+// Files holding only sidechain entries are subagent transcripts themselves. A parse
+// can be resumed to read the lines appended to its file since, as long as the lines
+// it read are unchanged; its fields are exported so the parse cache can keep it.
+type sessionParse struct {
+	Path      string
+	SessionID string
+	Keep      bool // Message bodies are kept
+	Scan      sessionScanner
+
+	Messages []Message      // Keep: messages of the session itself
+	All      []Message      // Keep: every message, in case the file is a subagent transcript
+	Whole    metaBuilder    // Metadata of the whole file, for the same case
+	Main     metaBuilder    // Metadata of the session itself
+	Chains   []sidechain    // Sidechains, in order of their first message
+	ChainOf  map[string]int // sidechain message UUID -> index in Chains
+	Digest   *digestBuilder // Search entries and stats inputs, when asked for
+
+	tail []byte // Unterminated last line, read but not part of the parse yet
+}
+
+// newSessionParse starts a parse of a session file. Message bodies are kept when keep
+// is set, and the search entries and stats inputs of the messages collected when
+// digest is set.
+func newSessionParse(filePath, sessionID string, keep, digest bool) *sessionParse {
+	p := &sessionParse{
+		Path:      filePath,
+		SessionID: sessionID,
+		Keep:      keep,
+		Scan:      sessionScanner{SessionID: sessionID},
+	}
+	if digest {
+		p.Digest = &digestBuilder{}
+	}
+	return p
+}
+
+// parseSessionFile reads a whole session file
+func parseSessionFile(filePath, sessionID string, keep bool) (*Session, error) {
+	p := newSessionParse(filePath, sessionID, keep, false)
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	session, _ := p.finish()
+	return session, nil
+}
+
+// read parses the lines written to the file since the last read
+func (p *sessionParse) read() error {
+	file, err := openSessionFile(p.Path, p.Scan.Offset)
+	if err != nil {
+		return fmt.Errorf("opening session file: %w", err)
+	}
+	defer file.Close()
+
+	p.tail, err = p.Scan.scan(file, p.add)
+	return err
+}
+
+// add places a message in the session or in its sidechain
+func (p *sessionParse) add(msg *Message) error {
+	p.Whole.add(msg)
+	if p.Keep {
+		p.All = append(p.All, *msg)
+	}
+
+	if !msg.Sidechain {
+		p.Main.add(msg)
+		if p.Keep {
+			p.Messages = append(p.Messages, *msg)
+		}
+		p.Digest.add(msg, p.SessionID)
+		return nil
+	}
+
+	if p.ChainOf == nil {
+		p.ChainOf = make(map[string]int)
+	}
+	idx, ok := p.ChainOf[msg.ParentUUID]
+	if !ok {
+		idx = len(p.Chains)
+		p.Chains = append(p.Chains, sidechain{ID: fmt.Sprintf("%s-sidechain-%d", p.SessionID, idx+1)})
+	}
+	p.ChainOf[msg.UUID] = idx
+	chain := &p.Chains[idx]
+	chain.Meta.add(msg)
+	if p.Keep {
+		chain.Messages = append(chain.Messages, *msg)
+	}
+	p.Digest.add(msg, chain.ID)
+	return nil
+}
+
+// clone copies the parse so that reading on with the copy leaves it unchanged
+func (p *sessionParse) clone() *sessionParse {
+	c := *p
+	c.Scan = p.Scan.clone()
+	c.Messages = slices.Clip(p.Messages)
+	c.All = slices.Clip(p.All)
+	c.Whole = p.Whole.clone()
+	c.Main = p.Main.clone()
+	c.Chains = make([]sidechain, len(p.Chains))
+	for i, chain := range p.Chains {
+		chain.Messages = slices.Clip(chain.Messages)
+		chain.Meta = chain.Meta.clone()
+		c.Chains[i] = chain
+	}
+	c.ChainOf = maps.Clone(p.ChainOf)
+	c.Digest = p.Digest.clone()
+	return &c
+}
+
+// session returns the session and its digest as read so far, leaving p to be resumed
+func (p *sessionParse) session() (*Session, *sessionDigest) {
+	return p.clone().finish()
+}
+
+// finish builds the session and its digest, taking in the held messages and the
+// unterminated last line. The parse can't be resumed afterwards.
+func (p *sessionParse) finish() (*Session, *sessionDigest) {
+	if p.tail != nil {
+		p.Scan.Line++
+		p.Scan.handleLine(p.tail, p.add) // add doesn't fail
+		p.tail = nil
+	}
+	p.Scan.flush(p.add)
+
+	session := &Session{
+		ID:         p.SessionID,
+		SourcePath: p.Path,
+		Summary:    p.Scan.Summary,
+		CWD:        p.Scan.CWD,
+	}
+	if p.Scan.Diagnostics.Count() > 0 {
+		diagnostics := p.Scan.Diagnostics.clone()
+		session.Diagnostics = &diagnostics
+	}
+	if p.Keep {
+		session.Messages = p.Messages
+		if session.Messages == nil {
+			session.Messages = []Message{}
+		}
+	}
+
+	var digest *sessionDigest
+	if p.Digest != nil {
+		digest = &p.Digest.Digest
+	}
+
+	if p.Main.Count == 0 && p.Whole.Count > 0 {
+		// Only sidechain entries: a subagent transcript
+		session.AgentID = p.Scan.AgentID
+		session.ParentSessionID = p.Scan.ParentSessionID
+		p.Whole.finish(session)
+		if p.Keep {
+			session.Messages = p.All
+		}
+		if digest != nil {
+			merged := &statsPart{}
+			for _, chain := range p.Chains {
+				merged.merge(digest.Parts[chain.ID])
+			}
+			digest.Parts = map[string]*statsPart{p.SessionID: merged}
+		}
+	} else {
+		p.Main.finish(session)
+		for _, chain := range p.Chains {
+			sub := Session{
+				ID:              chain.ID,
+				SourcePath:      p.Path,
+				ParentSessionID: p.SessionID,
+				CWD:             session.CWD,
+				Messages:        chain.Messages,
+			}
+			chain.Meta.finish(&sub)
+			sub.Summary = fallbackSummary(sub.Meta.FirstPrompt)
+			if p.Keep {
+				sub.ToolCalls = joinToolCalls(sub.Messages)
+			}
+			sub.Meta.Streamed = !p.Keep
+			session.Subagents = append(session.Subagents, sub)
+		}
+	}
+	// The session spans its sidechains too
+	session.CreatedAt, session.UpdatedAt = p.Whole.CreatedAt, p.Whole.UpdatedAt
+	if p.Keep {
+		session.ToolCalls = joinToolCalls(session.Messages)
+	}
+	session.Meta.Streamed = !p.Keep
+
+	// Summaries name the leaf of the conversation they describe; the last one
+	// pointing into this session marks its current branch
+	known := make(map[string]bool, len(session.Meta.UUIDs))
+	for _, uuid := range session.Meta.UUIDs {
+		known[uuid] = true
+	}
+	for _, leaf := range p.Scan.LeafUUIDs {
+		if known[leaf] {
+			session.ActiveLeaf = leaf
+		} else {
+			session.LeafRefs = append(session.LeafRefs, leaf)
+		}
+	}
+
+	// If no summary, use first user message as fallback
+	if session.Summary == "" {
+		session.Summary = fallbackSummary(session.Meta.FirstPrompt)
+	}
+
+	return session, digest
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStreamSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	// One response split into two entries, then a second response
+	jsonlContent := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Read the file"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"text","text":"Reading it"}],"usage":{"input_tokens":10,"output_tokens":1}}}
+{"type":"file-history-snapshot","messageId":"x"}
+{"type":"assistant","uuid":"a2","parentUuid":"a1","timestamp":"2025-12-29T10:00:02.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Read","input":{}}],"usage":{"input_tokens":10,"output_tokens":42}}}
+{"type":"assistant","uuid":"a3","parentUuid":"a2","timestamp":"2025-12-29T10:00:03.000Z","message":{"id":"resp_2","role":"assistant","content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":20,"output_tokens":5}}}
+`
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	var uuids []string
+	var usage []int64
+	err := StreamSession(sessionPath, "test-session", func(msg *Message) error {
+		uuids = append(uuids, msg.UUID)
+		var n int64
+		if msg.Usage != nil {
+			n = msg.Usage.OutputTokens
+		}
+		usage = append(usage, n)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamSession failed: %v", err)
+	}
+
+	if want := []string{"u1", "a1", "a2", "a3"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("Streamed %v, want %v", uuids, want)
+	}
+	// Only the last entry of a response keeps its usage
+	if want := []int64{0, 0, 42, 5}; !reflect.DeepEqual(usage, want) {
+		t.Errorf("Output tokens %v, want %v", usage, want)
+	}
+
+	// An error from the callback stops reading
+	stop := errors.New("stop")
+	count := 0
+	err = StreamSession(sessionPath, "test-session", func(msg *Message) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("StreamSession returned %v after %d messages, want stop after 1", err, count)
+	}
+}
+
+func TestLoadSessionMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	jsonlContent := `{"type":"user","uuid":"u1","gitBranch":"main","version":"2.0.1","cwd":"/Users/test/project","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Fix the build"}}
+{"type":"user","uuid":"s1","isSidechain":true,"timestamp":"2025-12-29T10:00:01.000Z","message":{"role":"user","content":"Sub prompt"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","gitBranch":"fix","timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Bash","input":{"command":"make"}}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-12-29T10:00:03.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool1","content":"exit 2","is_error":true}]}}
+{"type":"system","subtype":"compact_boundary","uuid":"c1","parentUuid":null,"logicalParentUuid":"u2","timestamp":"2025-12-29T10:00:04.000Z","content":"Conversation compacted"}
+`
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	full, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+	meta, err := LoadSessionMeta(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("LoadSessionMeta failed: %v", err)
+	}
+
+	if meta.Messages != nil || meta.ToolCalls != nil {
+		t.Errorf("Expected no messages or tool calls, got %d and %d", len(meta.Messages), len(meta.ToolCalls))
+	}
+	if meta.Summary != full.Summary || !meta.CreatedAt.Equal(full.CreatedAt) || !meta.UpdatedAt.Equal(full.UpdatedAt) {
+		t.Errorf("Meta = {%q %v %v}, want {%q %v %v}",
+			meta.Summary, meta.CreatedAt, meta.UpdatedAt, full.Summary, full.CreatedAt, full.UpdatedAt)
+	}
+	if meta.CWD != full.CWD || meta.GitBranch != full.GitBranch || !reflect.DeepEqual(meta.GitBranches, full.GitBranches) {
+		t.Errorf("Environment = {%s %s %v}, want {%s %s %v}",
+			meta.CWD, meta.GitBranch, meta.GitBranches, full.CWD, full.GitBranch, full.GitBranches)
+	}
+	counts := func(s *Session) [3]int { return [3]int{s.MessageCount(), s.Compactions(), s.ToolErrors()} }
+	if got, want := counts(meta), counts(full); got != want || want != [3]int{3, 1, 1} {
+		t.Errorf("Counts (messages, compactions, tool errors) = %v, parsed %v, want [3 1 1]", got, want)
+	}
+	if len(meta.Subagents) != 1 || meta.Subagents[0].Summary != "Sub prompt" || meta.Subagents[0].MessageCount() != 1 {
+		t.Errorf("Expected 1 sidechain with 1 message, got %+v", meta.Subagents)
+	}
+}
+
+func TestListSessionMetas(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-test-project")
+	subagentDir := filepath.Join(projectDir, "resumed", "subagents")
+	if err := os.MkdirAll(subagentDir, 0755); err != nil {
+		t.Fatalf("Failed to create subagent directory: %v", err)
+	}
+
+	original := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Start"}}
+`
+	resumed := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Start"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-30T09:00:00.000Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"task1","name":"Task","input":{"description":"Find the parser","prompt":"Locate parser.go"}}]}}
+{"type":"user","uuid":"u2","parentUuid":"a1","timestamp":"2025-12-30T09:01:00.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"task1","content":"Found it"}]},"toolUseResult":{"status":"completed","agentId":"a1b2"}}
+`
+	agent := `{"type":"user","uuid":"s1","isSidechain":true,"agentId":"a1b2","sessionId":"resumed","timestamp":"2025-12-30T09:00:01.000Z","message":{"role":"user","content":"Locate parser.go"}}
+`
+	files := map[string]string{
+		filepath.Join(projectDir, "original.jsonl"):    original,
+		filepath.Join(projectDir, "resumed.jsonl"):     resumed,
+		filepath.Join(subagentDir, "agent-a1b2.jsonl"): agent,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	project := &Project{FolderName: "-Users-test-project", Path: "/Users/test/project"}
+	sessions, err := ListSessionMetas(tmpDir, project)
+	if err != nil {
+		t.Fatalf("ListSessionMetas failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	session := &sessions[0]
+	if session.ID != "resumed" {
+		session = &sessions[1]
+	}
+	if session.PredecessorID != "original" || session.ConversationID != "original" {
+		t.Errorf("Lineage = %q %q, want original original", session.PredecessorID, session.ConversationID)
+	}
+	if len(session.Subagents) != 1 || session.Subagents[0].ParentToolUseID != "task1" {
+		t.Fatalf("Expected subagent linked to task1, got %+v", session.Subagents)
+	}
+	if session.Messages != nil || session.Meta.UUIDs != nil {
+		t.Error("Expected messages and link data not to be kept")
+	}
+
+	loaded, err := LoadSessionMessages(session)
+	if err != nil {
+		t.Fatalf("LoadSessionMessages failed: %v", err)
+	}
+	if len(loaded.Messages) != 3 || len(loaded.ToolCalls) != 1 {
+		t.Errorf("Expected 3 messages and 1 tool call, got %d and %d", len(loaded.Messages), len(loaded.ToolCalls))
+	}
+	if loaded.PredecessorID != "original" || loaded.Summary != session.Summary {
+		t.Errorf("Loaded session lost its links: predecessor %q, summary %q", loaded.PredecessorID, loaded.Summary)
+	}
+	if len(loaded.Subagents) != 1 || len(loaded.Subagents[0].Messages) != 1 ||
+		loaded.Subagents[0].ParentToolUseID != "task1" || loaded.Subagents[0].Summary != "Find the parser" {
+		t.Errorf("Loaded subagents = %+v", loaded.Subagents)
+	}
+}
+
+func TestSessionParse_Resume(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	// A response split over two entries, a tool result for an earlier call, a skipped
+	// entry bridged over, a sidechain and a summary naming the leaf
+	content := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","cwd":"/work","message":{"role":"user","content":"Fix the build"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"text","text":"Running it"}],"usage":{"input_tokens":10,"output_tokens":1}}}
+{"type":"assistant","uuid":"a2","parentUuid":"a1","timestamp":"2025-12-29T10:00:02.000Z","message":{"id":"resp_1","role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Bash","input":{"command":"go build"}}],"usage":{"input_tokens":10,"output_tokens":42}}}
+{"type":"progress","uuid":"p1","parentUuid":"a2"}
+{"type":"user","uuid":"u2","parentUuid":"p1","timestamp":"2025-12-29T10:00:03.000Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool1","content":"undefined: foo","is_error":true}]}}
+{"type":"user","uuid":"s1","isSidechain":true,"timestamp":"2025-12-29T10:00:04.000Z","message":{"role":"user","content":"Look for foo"}}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"timestamp":"2025-12-29T10:00:05.000Z","message":{"id":"resp_2","role":"assistant","content":[{"type":"text","text":"Found foo"}],"usage":{"input_tokens":5,"output_tokens":3}}}
+{"type":"assistant","uuid":"a3","parentUuid":"u2","timestamp":"2025-12-29T10:00:06.000Z","message":{"id":"resp_3","role":"assistant","content":[{"type":"text","text":"Fixed"}],"usage":{"input_tokens":20,"output_tokens":5}}}
+{"type":"summary","summary":"Build fix","leafUuid":"a3"}
+`
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(sessionPath, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+	}
+	write(content)
+	full := newSessionParse(sessionPath, "test-session", true, true)
+	if err := full.read(); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	wantSession, wantDigest := full.finish()
+
+	// Cut anywhere, even mid-line, then resume a copy kept in the cache after the rest is
+	// appended: the result is that of a single read
+	for cut := 0; cut <= len(content); cut++ {
+		write(content[:cut])
+		p := newSessionParse(sessionPath, "test-session", true, true)
+		if err := p.read(); err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		p.session()
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(p); err != nil {
+			t.Fatalf("Encoding the parse failed: %v", err)
+		}
+		var cached sessionParse
+		if err := gob.NewDecoder(&buf).Decode(&cached); err != nil {
+			t.Fatalf("Decoding the parse failed: %v", err)
+		}
+
+		write(content)
+		if err := cached.read(); err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		session, digest := cached.session()
+		if !reflect.DeepEqual(session, wantSession) {
+			t.Fatalf("Resumed after %d bytes: session %+v, want %+v", cut, session, wantSession)
+		}
+		if !reflect.DeepEqual(digest, wantDigest) {
+			t.Fatalf("Resumed after %d bytes: digest %+v, want %+v", cut, digest, wantDigest)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// taskInput is the part of a Task tool_use input used to link subagent transcripts
+type taskInput struct {
+	Description string `json:"description"`
+	Prompt      string `json:"prompt"`
+}
+
+// isTaskTool reports whether a tool spawns a subagent
+func isTaskTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+// toolUseResultAgentID extracts the subagent ID from a Task tool result's metadata
+func toolUseResultAgentID(raw json.RawMessage) string {
+	if len(raw) == 0 || raw[0] != '{' {
+		return "" // Absent, or a plain error string
+	}
+	var result struct {
+		AgentID string `json:"agentId"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return ""
+	}
+	return result.AgentID
+}
+
+// subagentFiles lists the subagent transcripts in dir, spawned by parentID
+func subagentFiles(dir, parentID string) []sessionFile {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil // Most sessions spawn no subagents
+	}
+
+	var files []sessionFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		files = append(files, sessionFile{
+			Path:     filepath.Join(dir, entry.Name()),
+			ID:       strings.TrimSuffix(entry.Name(), ".jsonl"),
+			ParentID: parentID,
+		})
+	}
+
+	return files
+}
+
+// attachSubagents groups subagent transcripts under the sessions that spawned them.
+// Transcripts whose parent session is missing are kept as standalone sessions.
+func attachSubagents(sessions []Session, subagents []Session) []Session {
+	byID := make(map[string]int, len(sessions))
+	for i := range sessions {
+		byID[sessions[i].ID] = i
+	}
+
+	var orphans []Session
+	for _, sub := range subagents {
+		i, ok := byID[sub.ParentSessionID]
+		if !ok {
+			orphans = append(orphans, sub)
+			continue
+		}
+		sessions[i].Subagents = append(sessions[i].Subagents, sub)
+	}
+
+	for i := range sessions {
+		linkSubagents(&sessions[i])
+	}
+
+	return append(sessions, orphans...)
+}
+
+// linkSubagents matches each subagent transcript to the Task tool_use that spawned it,
+// by the agent ID in the Task result or else by the prompt, and titles it with the
+// Task description
+func linkSubagents(session *Session) {
+	if len(session.Subagents) == 0 {
+		return
+	}
+
+	sort.Slice(session.Subagents, func(i, j int) bool {
+		return session.Subagents[i].CreatedAt.Before(session.Subagents[j].CreatedAt)
+	})
+
+	toolUseByAgent := session.Meta.AgentToolUses // agent ID -> tool_use ID
+	tasks := make(map[string]taskInput)          // tool_use ID -> Task input
+	for _, task := range session.Meta.Tasks {
+		tasks[task.ToolUseID] = task.Input
+	}
+
+	claimed := make(map[string]bool)
+	for i := range session.Subagents {
+		sub := &session.Subagents[i]
+
+		toolUseID := ""
+		if sub.AgentID != "" {
+			toolUseID = toolUseByAgent[sub.AgentID]
+		}
+		if toolUseID == "" {
+			prompt := sub.Meta.FirstPrompt
+			for _, task := range session.Meta.Tasks {
+				if !claimed[task.ToolUseID] && prompt != "" && task.Input.Prompt == prompt {
+					toolUseID = task.ToolUseID
+					break
+				}
+			}
+		}
+		if toolUseID == "" {
+			continue
+		}
+
+		claimed[toolUseID] = true
+		sub.ParentToolUseID = toolUseID
+		if desc := tasks[toolUseID].Description; desc != "" {
+			sub.Summary = desc
+		}
+	}
+}
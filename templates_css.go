@@ -684,6 +684,29 @@ body {
     margin-left: 12px;
 }
 
+.diagnostics-notice {
+    display: block;
+    background: var(--bg-tertiary);
+    border: 1px solid var(--border-medium);
+    border-radius: 12px;
+    padding: 12px 16px;
+    margin-bottom: 24px;
+    font-size: 0.85rem;
+    color: var(--text-secondary);
+    text-decoration: none;
+}
+
+.diagnostics-notice:hover {
+    color: var(--accent-primary);
+    border-color: var(--accent-primary);
+}
+
+.project-card-source {
+    color: var(--text-muted);
+    font-size: 0.75rem;
+    margin-right: auto;
+}
+
 .project-card-latest {
     font-size: 0.85rem;
     color: var(--text-secondary);
@@ -777,6 +800,123 @@ body {
     font-size: 0.75rem;
 }
 
+/* Claude Code environment on session cards */
+.session-card-env {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 6px;
+    margin-top: 8px;
+    font-size: 0.72rem;
+    color: var(--text-muted);
+}
+
+.session-card-env span {
+    padding: 1px 8px;
+    border: 1px solid var(--border-subtle);
+    border-radius: 10px;
+}
+
+.session-card-branch {
+    font-family: var(--font-mono);
+    max-width: 100%;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    white-space: nowrap;
+}
+
+.branch-filter {
+    display: flex;
+    align-items: center;
+    gap: 8px;
+    margin-top: 12px;
+    font-size: 0.85rem;
+    color: var(--text-muted);
+}
+
+.branch-filter select {
+    padding: 6px 12px;
+    border: 1px solid var(--border-medium);
+    border-radius: 6px;
+    background: var(--bg-secondary);
+    color: var(--text-primary);
+    font-family: var(--font-body);
+    font-size: 0.85rem;
+}
+
+.branch-filter .errors-filter {
+    display: flex;
+    align-items: center;
+    gap: 6px;
+    margin-left: 12px;
+    cursor: pointer;
+}
+
+.session-card-env .session-card-errors {
+    color: #B91C1C;
+    border-color: #FCA5A5;
+}
+
+.branch-search-link[hidden],
+.session-grid [hidden] {
+    display: none;
+}
+
+/* Resumed sessions grouped into one conversation */
+.conversation-group {
+    grid-column: 1 / -1;
+    display: grid;
+    grid-template-columns: repeat(auto-fill, minmax(300px, 1fr));
+    gap: 12px;
+    padding: 12px;
+    border: 1px dashed var(--border-medium);
+    border-radius: 14px;
+    counter-reset: conversation-part;
+}
+
+.conversation-header {
+    grid-column: 1 / -1;
+    font-size: 0.8rem;
+    font-weight: 500;
+    color: var(--text-secondary);
+}
+
+.conversation-part::before {
+    counter-increment: conversation-part;
+    content: 'Part ' counter(conversation-part);
+    display: block;
+    font-size: 0.7rem;
+    text-transform: uppercase;
+    letter-spacing: 0.05em;
+    color: var(--text-muted);
+    margin-bottom: 4px;
+}
+
+/* Sessions whose file is gone, kept from their Markdown */
+.archived-sessions {
+    margin-top: 40px;
+}
+
+.archived-sessions-heading {
+    font-size: 0.8rem;
+    font-weight: 500;
+    text-transform: uppercase;
+    letter-spacing: 0.05em;
+    color: var(--text-muted);
+    margin-bottom: 12px;
+}
+
+.session-card-archived {
+    opacity: 0.85;
+}
+
+.session-archived-badge {
+    padding: 1px 8px;
+    border: 1px solid #FCD34D;
+    border-radius: 10px;
+    color: #B45309;
+    font-size: 0.72rem;
+}
+
 /* Empty state - Elegant placeholder */
 .empty-state {
     text-align: center;
@@ -1301,9 +1441,10 @@ const shellCSS = `
     background: #f97316;
 }
 
-/* Hide tools mode */
-.hide-tools details,
-.hide-tools .tool-block {
+/* Hide tools / hide thinking modes (failed calls stay visible in errors-only mode) */
+.hide-tools:not(.errors-only) .tool-block,
+.hide-tools.errors-only .tool-block:not(.tool-error),
+.hide-thinking .thinking-block {
     display: none !important;
     margin: 0 !important;
     padding: 0 !important;
@@ -1311,8 +1452,13 @@ const shellCSS = `
     overflow: hidden !important;
 }
 
-/* Hide messages that only contain tool calls */
-.hide-tools .tools-only {
+/* Errors only: system events between messages are hidden too */
+#content-area.errors-only > .system-event {
+    display: none;
+}
+
+/* Hide messages that only contain hidden tool calls or thinking */
+#content-area .message.filtered-out {
     display: none !important;
 }
 
@@ -1410,6 +1556,113 @@ const shellCSS = `
     line-height: 1.7;
 }
 
+/* System events: compactions, slash commands, hooks, notices */
+.system-event {
+    margin: 12px 0;
+    font-size: 0.8rem;
+    color: var(--text-muted);
+    text-align: center;
+}
+
+#content-area details.system-event summary {
+    cursor: pointer;
+}
+
+#content-area details.system-event pre {
+    margin: 8px 0 0;
+    text-align: left;
+    font-size: 0.75rem;
+    max-height: 300px;
+    overflow: auto;
+}
+
+#content-area .system-event.compact {
+    font-weight: 500;
+    color: var(--text-secondary);
+}
+
+#content-area .system-event.command {
+    font-family: var(--font-mono);
+}
+
+#content-area .system-event.warning {
+    color: #B45309;
+}
+
+#content-area .system-event.error {
+    color: #B91C1C;
+}
+
+/* Navigation between resumed sessions */
+#content-area .session-nav {
+    margin: 16px 0;
+    padding: 10px 16px;
+    border-radius: 8px;
+    background: var(--bg-tertiary);
+    font-size: 0.85rem;
+    color: var(--text-secondary);
+}
+
+#content-area .session-nav p {
+    margin: 0;
+}
+
+/* Conversation branches */
+#content-area .branches,
+#content-area .branches-heading,
+#content-area .fork-hidden {
+    display: none !important;
+}
+
+#content-area .fork-point {
+    display: flex;
+    align-items: center;
+    gap: 8px;
+    margin: -8px 0 16px 48px;
+    font-size: 0.8rem;
+}
+
+#content-area .fork-label {
+    color: var(--text-muted);
+}
+
+#content-area .fork-btn {
+    padding: 3px 10px;
+    border: 1px solid var(--border-medium);
+    border-radius: 999px;
+    background: var(--bg-secondary);
+    color: var(--text-secondary);
+    font-family: var(--font-body);
+    font-size: 0.8rem;
+    cursor: pointer;
+}
+
+#content-area .fork-btn.active {
+    border-color: var(--accent-primary);
+    background: var(--accent-primary);
+    color: white;
+}
+
+#content-area .branch.active {
+    padding-left: 16px;
+    border-left: 2px dashed var(--border-medium);
+}
+
+#content-area .branch.active > h3 {
+    margin-top: 0;
+    font-size: 0.85rem;
+    color: var(--text-muted);
+}
+
+/* Images pasted into sessions */
+#content-area img {
+    display: block;
+    max-width: 100%;
+    max-height: 480px;
+    border: 1px solid var(--border-medium);
+    border-radius: 8px;
+}
+
 #content-area pre {
     background: var(--bg-code);
     border-radius: 12px;
@@ -1487,9 +1740,44 @@ const shellCSS = `
     background: #059669;
 }
 
+#content-area details.tool-block summary .tool-icon.subagent {
+    background: #7c3aed;
+}
+
+/* Failed tool calls */
+#content-area details.tool-block.tool-error {
+    border-color: #FCA5A5;
+}
+
+#content-area details.tool-block.tool-error summary {
+    background: #FEF2F2;
+}
+
+#content-area details.tool-block summary .tool-icon.error {
+    background: #DC2626;
+}
+
+/* Subagent transcripts nested in the parent session */
+#content-area details.tool-block.subagent-block > *:not(summary) {
+    padding: 0 16px;
+}
+
+#content-area details.subagent-block h4 {
+    margin: 20px 0 8px;
+    font-size: 0.75rem;
+    font-weight: 600;
+    letter-spacing: 0.05em;
+    text-transform: uppercase;
+    color: var(--text-muted);
+}
+
 #content-area details.tool-block summary .tool-name {
     font-family: var(--font-mono);
     color: var(--text-secondary);
+    min-width: 0;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    white-space: nowrap;
 }
 
 #content-area details.tool-block summary .tool-toggle {
@@ -1513,6 +1801,74 @@ const shellCSS = `
     overflow: auto;
 }
 
+/* Result label and todo checklists inside a tool call */
+#content-area details.tool-block > p,
+#content-area details.tool-block > ul {
+    padding: 8px 16px;
+    margin: 0;
+    font-size: 0.85rem;
+}
+
+#content-area details.tool-block > ul {
+    list-style: none;
+}
+
+#content-area details.tool-block > ul input[type="checkbox"] {
+    margin-right: 8px;
+}
+
+/* Thinking blocks (details.thinking elements) */
+#content-area details.thinking-block {
+    margin: 16px 0;
+    border: 1px dashed var(--border-medium);
+    border-radius: 12px;
+    overflow: hidden;
+}
+
+#content-area details.thinking-block summary {
+    padding: 10px 16px;
+    font-size: 0.85rem;
+    cursor: pointer;
+    display: flex;
+    align-items: center;
+    gap: 10px;
+    list-style: none;
+    user-select: none;
+    color: var(--text-muted);
+}
+
+#content-area details.thinking-block summary::-webkit-details-marker {
+    display: none;
+}
+
+#content-area details.thinking-block summary .tool-icon {
+    width: 18px;
+    height: 18px;
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    background: var(--border-medium);
+    color: var(--text-secondary);
+    border-radius: 4px;
+    font-size: 10px;
+    font-weight: 600;
+}
+
+#content-area details.thinking-block summary .tool-toggle {
+    margin-left: auto;
+    transition: transform var(--transition-fast);
+}
+
+#content-area details.thinking-block[open] summary .tool-toggle {
+    transform: rotate(180deg);
+}
+
+#content-area details.thinking-block > *:not(summary) {
+    padding: 0 16px;
+    color: var(--text-secondary);
+    font-style: italic;
+}
+
 /* Message blocks created by JavaScript */
 #content-area .message {
     display: flex;
@@ -1538,6 +1894,16 @@ const shellCSS = `
     background: linear-gradient(135deg, var(--accent-primary) 0%, #EA580C 100%);
 }
 
+/* Messages appended by a live update */
+#content-area .message.message-new .message-body {
+    animation: messageNew 2s ease-out;
+}
+
+@keyframes messageNew {
+    from { box-shadow: -4px 0 0 var(--accent-subtle); }
+    to { box-shadow: -4px 0 0 transparent; }
+}
+
 #content-area .message-body {
     flex: 1;
     min-width: 0;
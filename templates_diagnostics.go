@@ -0,0 +1,216 @@
+package main
+
+// diagnosticsTemplate is the page listing session files with parse problems
+const diagnosticsTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Diagnostics - Claude Code Logs</title>
+    <style>` + baseCSS + diagnosticsCSS + `</style>
+</head>
+<body>
+    <button class="mobile-menu-btn" aria-label="Open navigation" aria-expanded="false">
+        <svg class="hamburger-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
+            <path d="M3 6h18M3 12h18M3 18h18"/>
+        </svg>
+    </button>
+    <div class="sidebar-backdrop" aria-hidden="true"></div>
+    <div class="container">
+        <aside class="sidebar">
+            <div class="sidebar-resize-handle" role="separator" aria-orientation="vertical"></div>
+            <div class="sidebar-header">
+                <a href="/" class="sidebar-title">
+                    <img src="/claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
+                    Claude Code Logs
+                </a>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .Projects}} projects</div>
+                <a href="/search" class="stats-nav-link">
+                    <svg viewBox="0 0 20 20" fill="currentColor">
+                        <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
+                    </svg>
+                    Search
+                </a>
+                <a href="/stats" class="stats-nav-link">
+                    <svg viewBox="0 0 20 20" fill="currentColor"><path d="M2 11a1 1 0 011-1h2a1 1 0 011 1v5a1 1 0 01-1 1H3a1 1 0 01-1-1v-5zm6-4a1 1 0 011-1h2a1 1 0 011 1v9a1 1 0 01-1 1H9a1 1 0 01-1-1V7zm6-3a1 1 0 011-1h2a1 1 0 011 1v12a1 1 0 01-1 1h-2a1 1 0 01-1-1V4z"/></svg>
+                    Stats
+                </a>
+            </div>
+            <div class="tree-controls">
+                <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
+                <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
+            </div>
+            <ul class="project-list" data-live="tree">
+                {{range $project := .Projects}}
+                <li class="tree-node collapsed" data-project="{{$project.Slug}}">
+                    <div class="tree-node-header">
+                        <button type="button" class="tree-toggle{{if eq (len $project.Sessions) 0}} hidden{{end}}" aria-expanded="false" aria-label="Toggle {{$project.Path}}">
+                            <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
+                                <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
+                            </svg>
+                        </button>
+                        <a href="/{{$project.Slug}}/" class="tree-node-link">
+                            <span class="tree-node-content">
+                                <span class="tree-node-name">{{$project.Path}}</span>
+                                <span class="tree-node-meta">{{len $project.Sessions}} sessions</span>
+                            </span>
+                            {{if gt (len $project.Sessions) 0}}<span class="session-count">{{len $project.Sessions}}</span>{{end}}
+                        </a>
+                    </div>
+                    {{if gt (len $project.Sessions) 0}}
+                    <ul class="tree-children session-list">
+                        {{range $project.Sessions}}
+                        <li class="session-item">
+                            <a href="/{{$project.Slug}}/{{.ID}}" class="session-link">
+                                <span class="session-title">{{.Summary}}</span>
+                                <span class="session-date">{{.CreatedAt.Format "Jan 2, 2006"}}</span>
+                            </a>
+                        </li>
+                        {{end}}
+                    </ul>
+                    {{end}}
+                </li>
+                {{end}}
+            </ul>
+        </aside>
+        <main class="main diagnostics-main">
+            <header class="page-header">
+                <div class="page-breadcrumb">
+                    <a href="/">Home</a>
+                    <span class="page-breadcrumb-separator">/</span>
+                    <span>Diagnostics</span>
+                </div>
+                <h1 class="page-title">Parse Diagnostics</h1>
+                <p class="page-subtitle" data-live="diagnostics-summary">{{len .Diagnostics.ProblemFiles}} of {{.Diagnostics.Files}} session files had lines the parser skipped or worked around</p>
+            </header>
+            {{with .Diagnostics.Totals}}
+            <div class="diagnostics-totals" data-live="diagnostics-totals">
+                <div class="diagnostics-total"><span class="diagnostics-total-value">{{.MalformedLines}}</span> malformed lines</div>
+                <div class="diagnostics-total"><span class="diagnostics-total-value">{{len .UnknownEntryTypes}}</span> unknown entry types</div>
+                <div class="diagnostics-total"><span class="diagnostics-total-value">{{len .UnknownBlockTypes}}</span> unknown block types</div>
+                <div class="diagnostics-total"><span class="diagnostics-total-value">{{.TimestampFallbacks}}</span> unreadable timestamps</div>
+            </div>
+            {{end}}
+            {{if .Diagnostics.ProblemFiles}}
+            <div class="diagnostics-list" data-live="diagnostics-files">
+                {{range .Diagnostics.ProblemFiles}}
+                <div class="diagnostics-file">
+                    <div class="diagnostics-file-header">
+                        <a href="/{{.ProjectSlug}}/{{.SessionID}}" class="diagnostics-file-session">{{.Project}} / {{.SessionID}}</a>
+                        <span class="diagnostics-file-count">{{.Count}} problems</span>
+                    </div>
+                    <div class="diagnostics-file-path">{{.Path}}</div>
+                    <div class="diagnostics-file-summary">{{.ParseDiagnostics.String}}</div>
+                    {{if .Samples}}
+                    <ul class="diagnostics-samples">
+                        {{range .Samples}}<li>{{.}}</li>{{end}}
+                    </ul>
+                    {{end}}
+                </div>
+                {{end}}
+            </div>
+            {{else}}
+            <div class="empty-state">
+                <div class="empty-state-icon">✓</div>
+                <h2 class="empty-state-title">No problems found</h2>
+                <p class="empty-state-text">Every line of every session file was understood.</p>
+            </div>
+            {{end}}
+            <footer class="footer">
+                <a href="https://github.com/fabriqaai/claude-code-logs">claude-code-logs</a> by <a href="https://fabriqa.ai">fabriqa.ai</a>
+            </footer>
+        </main>
+    </div>
+    <script>` + sidebarJS + `</script>
+    <script>` + liveJS + `</script>
+</body>
+</html>`
+
+// diagnosticsCSS contains styles specific to the diagnostics page
+const diagnosticsCSS = `
+.diagnostics-totals {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 12px;
+    margin-bottom: 32px;
+}
+
+.diagnostics-total {
+    background: var(--bg-secondary);
+    border: 1px solid var(--border-subtle);
+    border-radius: 12px;
+    padding: 12px 16px;
+    font-size: 0.85rem;
+    color: var(--text-secondary);
+}
+
+.diagnostics-total-value {
+    font-family: var(--font-display);
+    font-size: 1.1rem;
+    font-weight: 600;
+    color: var(--text-primary);
+    margin-right: 4px;
+}
+
+.diagnostics-list {
+    display: flex;
+    flex-direction: column;
+    gap: 16px;
+}
+
+.diagnostics-file {
+    background: var(--bg-secondary);
+    border: 1px solid var(--border-subtle);
+    border-radius: 12px;
+    padding: 16px 20px;
+}
+
+.diagnostics-file-header {
+    display: flex;
+    justify-content: space-between;
+    align-items: baseline;
+    gap: 12px;
+}
+
+.diagnostics-file-session {
+    color: var(--text-primary);
+    font-weight: 500;
+    text-decoration: none;
+    word-break: break-word;
+}
+
+.diagnostics-file-session:hover {
+    color: var(--accent-primary);
+}
+
+.diagnostics-file-count {
+    background: var(--accent-subtle);
+    color: var(--accent-primary);
+    font-size: 0.7rem;
+    font-weight: 500;
+    padding: 4px 8px;
+    border-radius: 12px;
+    white-space: nowrap;
+}
+
+.diagnostics-file-path {
+    font-family: var(--font-mono);
+    font-size: 0.75rem;
+    color: var(--text-muted);
+    margin: 4px 0 8px;
+    word-break: break-all;
+}
+
+.diagnostics-file-summary {
+    font-size: 0.85rem;
+    color: var(--text-secondary);
+}
+
+.diagnostics-samples {
+    margin: 8px 0 0;
+    padding-left: 20px;
+    font-family: var(--font-mono);
+    font-size: 0.75rem;
+    color: var(--text-secondary);
+}
+`
@@ -24,7 +24,7 @@ const indexTemplate = `<!DOCTYPE html>
                     <img src="claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
                     Claude Code Logs
                 </a>
-                <div class="sidebar-subtitle">{{len .Projects}} projects</div>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .Projects}} projects</div>
                 <a href="/search" class="stats-nav-link">
                     <svg viewBox="0 0 20 20" fill="currentColor">
                         <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
@@ -40,16 +40,16 @@ const indexTemplate = `<!DOCTYPE html>
                 <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
                 <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
             </div>
-            <ul class="project-list">
+            <ul class="project-list" data-live="tree">
                 {{range $project := .Projects}}
-                <li class="tree-node" data-project="{{ProjectSlug $project.Path}}">
+                <li class="tree-node" data-project="{{$project.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len $project.Sessions) 0}} hidden{{end}}" aria-expanded="true" aria-label="Toggle {{$project.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="{{ProjectSlug $project.Path}}/index.html" class="tree-node-link">
+                        <a href="{{$project.Slug}}/index.html" class="tree-node-link">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{$project.Path}}</span>
                                 <span class="tree-node-meta">{{len $project.Sessions}} sessions</span>
@@ -61,7 +61,7 @@ const indexTemplate = `<!DOCTYPE html>
                     <ul class="tree-children session-list">
                         {{range $project.Sessions}}
                         <li class="session-item">
-                            <a href="{{ProjectSlug $project.Path}}/{{.ID}}.html" class="session-link">
+                            <a href="{{$project.Slug}}/{{.ID}}.html" class="session-link">
                                 <span class="session-title">{{.Summary}}</span>
                                 <span class="session-date">{{.CreatedAt.Format "Jan 2, 2006"}}</span>
                             </a>
@@ -78,10 +78,13 @@ const indexTemplate = `<!DOCTYPE html>
                 <h1 class="page-title">Claude Code Logs</h1>
                 <p class="page-subtitle">Browse your Claude Code chat sessions</p>
             </header>
+            {{if .ProblemFiles}}
+            <a href="/diagnostics" class="diagnostics-notice" data-live="diagnostics-notice">{{.ProblemFiles}} session files had lines that couldn't be parsed. See diagnostics</a>
+            {{end}}
             {{if .Projects}}
-            <div class="project-grid">
+            <div class="project-grid" data-live="projects">
                 {{range .Projects}}
-                <a href="{{ProjectSlug .Path}}/index.html" class="project-card">
+                <a href="{{.Slug}}/index.html" class="project-card">
                     <div class="project-card-header">
                         <span class="project-card-title">{{.Path}}</span>
                         <span class="project-card-badge">{{len .Sessions}} sessions</span>
@@ -92,6 +95,7 @@ const indexTemplate = `<!DOCTYPE html>
                     </div>
                     {{end}}
                     <div class="project-card-footer">
+                        {{if $.MultiSource}}<span class="project-card-source" title="{{.SourceDir}}">{{.Source}}</span>{{end}}
                         <span class="project-card-link">View sessions</span>
                     </div>
                 </a>
@@ -283,5 +287,6 @@ const indexTemplate = `<!DOCTYPE html>
         });
     })();
     </script>
+    <script>` + liveJS + `</script>
 </body>
 </html>`
@@ -0,0 +1,91 @@
+package main
+
+// liveJS follows /api/events while a page is open. Each change event is passed on as a
+// "live-<type>" DOM event on document (e.g. "live-session-updated", with the event data
+// as detail) for the page's own script, and session changes refetch the page to swap in
+// its [data-live] regions, such as the sidebar tree and the project and session lists.
+// Once swapped, a "live-refreshed" event is dispatched. Static exports opened from disk
+// have no server to follow.
+const liveJS = `
+(function() {
+    if (location.protocol === 'file:' || !window.EventSource) return;
+
+    var source = new EventSource('/api/events');
+    var refreshTimer = null;
+
+    ['session-created', 'session-updated', 'session-deleted', 'stats-changed'].forEach(function(type) {
+        source.addEventListener(type, function(e) {
+            var detail = {};
+            try { detail = JSON.parse(e.data); } catch (err) {}
+            document.dispatchEvent(new CustomEvent('live-' + type, { detail: detail }));
+            if (type !== 'stats-changed') scheduleRefresh();
+        });
+    });
+
+    // Events come in bursts while Claude Code writes, so refresh once they settle
+    function scheduleRefresh() {
+        clearTimeout(refreshTimer);
+        refreshTimer = setTimeout(refresh, 300);
+    }
+
+    function refresh() {
+        fetch(location.href, { cache: 'no-store' })
+            .then(function(r) {
+                if (!r.ok) throw new Error('HTTP ' + r.status);
+                return r.text();
+            })
+            .then(function(html) {
+                var fresh = new DOMParser().parseFromString(html, 'text/html');
+                var regions = document.querySelectorAll('[data-live]');
+                var freshRegions = fresh.querySelectorAll('[data-live]');
+                if (regions.length !== freshRegions.length) {
+                    // Lists appeared or emptied: the page layout itself changed
+                    location.reload();
+                    return;
+                }
+                var collapsed = {};
+                document.querySelectorAll('.tree-node.collapsed').forEach(function(n) {
+                    collapsed[n.dataset.project] = true;
+                });
+                regions.forEach(function(region) {
+                    var match = fresh.querySelector('[data-live="' + region.dataset.live + '"]');
+                    if (match && match.innerHTML !== region.innerHTML) region.innerHTML = match.innerHTML;
+                });
+                restoreCollapsed(collapsed);
+                document.dispatchEvent(new CustomEvent('live-refreshed'));
+            })
+            .catch(function(err) {
+                console.warn('Failed to refresh page:', err);
+            });
+    }
+
+    // Keep the sidebar projects that were collapsed collapsed
+    function restoreCollapsed(collapsed) {
+        document.querySelectorAll('.tree-node').forEach(function(n) {
+            if (!collapsed[n.dataset.project]) return;
+            n.classList.add('collapsed');
+            var toggle = n.querySelector('.tree-toggle');
+            if (toggle) toggle.setAttribute('aria-expanded', 'false');
+        });
+    }
+
+    // Toggles of swapped-in tree nodes; the page's own handlers cover the original ones
+    // and stop the click there
+    document.addEventListener('click', function(e) {
+        var btn = e.target.closest('.tree-toggle');
+        if (!btn) return;
+        e.preventDefault();
+        var node = btn.closest('.tree-node');
+        var isCollapsed = node.classList.toggle('collapsed');
+        btn.setAttribute('aria-expanded', !isCollapsed);
+        try {
+            var key = 'claude-code-logs-sidebar';
+            var data = JSON.parse(localStorage.getItem(key) || '{}');
+            data.collapsed = Array.from(document.querySelectorAll('.tree-node.collapsed'))
+                .map(function(n) { return n.dataset.project; })
+                .filter(Boolean);
+            localStorage.setItem(key, JSON.stringify(data));
+        } catch (err) { console.warn('Failed to save sidebar state:', err); }
+    });
+})();
+`
@@ -24,7 +24,7 @@ const projectIndexTemplate = `<!DOCTYPE html>
                     <img src="../claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
                     Claude Code Logs
                 </a>
-                <div class="sidebar-subtitle">{{len .AllProjects}} projects</div>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .AllProjects}} projects</div>
                 <a href="/search" class="stats-nav-link">
                     <svg viewBox="0 0 20 20" fill="currentColor">
                         <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
@@ -40,16 +40,16 @@ const projectIndexTemplate = `<!DOCTYPE html>
                 <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
                 <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
             </div>
-            <ul class="project-list">
+            <ul class="project-list" data-live="tree">
                 {{range .AllProjects}}
-                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{ProjectSlug .Path}}">
+                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len .Sessions) 0}} hidden{{end}}" aria-expanded="{{if eq .Path $.Project.Path}}true{{else}}false{{end}}" aria-label="Toggle {{.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="../{{ProjectSlug .Path}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
+                        <a href="../{{.Slug}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{.Path}}</span>
                                 <span class="tree-node-meta">{{len .Sessions}} sessions</span>
@@ -76,27 +76,68 @@ const projectIndexTemplate = `<!DOCTYPE html>
         <main class="main">
             <header class="page-header">
                 <h1 class="page-title">{{.Project.Path}}</h1>
-                <p class="page-subtitle">{{len .Project.Sessions}} sessions</p>
+                <p class="page-subtitle" data-live="project-summary">{{len .Project.Sessions}} sessions{{if .MultiSource}} from <span title="{{.Project.SourceDir}}">{{.Project.Source}}</span>{{end}}</p>
+                {{if or .Project.GitBranches .Project.ToolErrorSessions}}
+                <div class="branch-filter">
+                    {{with .Project.GitBranches}}
+                    <label for="branchFilter">Branch:</label>
+                    <select id="branchFilter">
+                        <option value="">All branches</option>
+                        {{range .}}
+                        <option value="{{.}}">{{.}}</option>
+                        {{end}}
+                    </select>
+                    <a id="branchSearch" class="branch-search-link" href="/search" hidden>Search this branch</a>
+                    {{end}}
+                    {{with .Project.ToolErrorSessions}}
+                    <label class="errors-filter">
+                        <input type="checkbox" id="errorsFilter">
+                        With failed tool calls ({{.}})
+                    </label>
+                    {{end}}
+                </div>
+                {{end}}
             </header>
             {{if .Project.Sessions}}
-            <div class="session-grid">
-                {{range .Project.Sessions}}
-                <a href="{{.ID}}.html" class="session-card">
-                    <div class="session-card-title">{{.Summary}}</div>
-                    <div class="session-card-meta">
-                        <span class="session-card-date">{{.CreatedAt.Format "Jan 2, 2006 3:04 PM"}}</span>
-                        <span class="session-card-messages">{{len .Messages}} messages</span>
-                    </div>
+            <div class="session-grid" data-live="sessions">
+                {{range .Conversations}}
+                {{if gt (len .Sessions) 1}}
+                <div class="conversation-group">
+                    <div class="conversation-header">Conversation · {{len .Sessions}} sessions</div>
+                    {{range .Sessions}}
+                    <a href="{{.ID}}.html" class="session-card conversation-part" data-branches="{{.BranchList}}" data-errors="{{.ToolErrors}}">
+                        {{template "session-card-body" .}}
+                    </a>
+                    {{end}}
+                </div>
+                {{else}}
+                {{range .Sessions}}
+                <a href="{{.ID}}.html" class="session-card" data-branches="{{.BranchList}}" data-errors="{{.ToolErrors}}">
+                    {{template "session-card-body" .}}
                 </a>
                 {{end}}
+                {{end}}
+                {{end}}
             </div>
-            {{else}}
+            {{else if not .Project.ArchivedSessions}}
             <div class="empty-state">
                 <div class="empty-state-icon">💬</div>
                 <h2 class="empty-state-title">No sessions found</h2>
                 <p class="empty-state-text">Chat sessions for this project will appear here.</p>
             </div>
             {{end}}
+            {{if .Project.ArchivedSessions}}
+            <section class="archived-sessions">
+                <h2 class="archived-sessions-heading">Archived · {{len .Project.ArchivedSessions}} sessions</h2>
+                <div class="session-grid" data-live="archived-sessions">
+                    {{range .Project.ArchivedSessions}}
+                    <a href="{{.ID}}.html" class="session-card session-card-archived" data-branches="{{.BranchList}}" data-errors="{{.ToolErrors}}">
+                        {{template "session-card-body" .}}
+                    </a>
+                    {{end}}
+                </div>
+            </section>
+            {{end}}
             <footer class="footer">
                 <a href="https://github.com/fabriqaai/claude-code-logs">claude-code-logs</a> by <a href="https://fabriqa.ai">fabriqa.ai</a>
             </footer>
@@ -266,6 +307,32 @@ const projectIndexTemplate = `<!DOCTYPE html>
             });
         }
 
+        // Session filters: hide sessions that never ran on the selected branch,
+        // or had no failed tool calls
+        var branchFilter = document.getElementById('branchFilter');
+        var branchSearch = document.getElementById('branchSearch');
+        var errorsFilter = document.getElementById('errorsFilter');
+        function applySessionFilters() {
+            var branch = branchFilter ? branchFilter.value : '';
+            var errors = errorsFilter !== null && errorsFilter.checked;
+            document.querySelectorAll('.session-grid .session-card').forEach(function(card) {
+                var branches = (card.getAttribute('data-branches') || '').split(' ');
+                card.hidden = (branch !== '' && branches.indexOf(branch) === -1) ||
+                    (errors && card.getAttribute('data-errors') === '0');
+            });
+            document.querySelectorAll('.conversation-group').forEach(function(group) {
+                group.hidden = !group.querySelector('.session-card:not([hidden])');
+            });
+            if (branchSearch) {
+                branchSearch.hidden = branch === '';
+                branchSearch.href = '/search?branch=' + encodeURIComponent(branch);
+            }
+        }
+        if (branchFilter) branchFilter.addEventListener('change', applySessionFilters);
+        if (errorsFilter) errorsFilter.addEventListener('change', applySessionFilters);
+        applySessionFilters();
+        document.addEventListener('live-refreshed', applySessionFilters);
+
         // Global keyboard shortcut: / to search
         document.addEventListener('keydown', function(e) {
             if (e.target.matches('input, textarea, [contenteditable]')) return;
@@ -276,5 +343,23 @@ const projectIndexTemplate = `<!DOCTYPE html>
         });
     })();
     </script>
+    <script>` + liveJS + `</script>
 </body>
-</html>`
+</html>
+{{define "session-card-body"}}
+<div class="session-card-title">{{.Summary}}</div>
+<div class="session-card-meta">
+    <span class="session-card-date">{{.CreatedAt.Format "Jan 2, 2006 3:04 PM"}}</span>
+    <span class="session-card-messages">{{.MessageCount}} messages</span>
+</div>
+{{if or .Archived .GitBranch .Version .Compactions .ToolErrors}}
+<div class="session-card-env">
+    {{if .Archived}}<span class="session-archived-badge" title="The session file is gone; this is the archived Markdown">archived</span>{{end}}
+    {{if .GitBranch}}<span class="session-card-branch" title="Git branch">{{.GitBranch}}{{if gt (len .GitBranches) 1}} +{{len (slice .GitBranches 1)}}{{end}}</span>{{end}}
+    {{if and .PermissionMode (ne .PermissionMode "default")}}<span class="session-card-mode" title="Permission mode">{{.PermissionMode}}</span>{{end}}
+    {{if .Version}}<span class="session-card-version" title="Claude Code version">v{{.Version}}</span>{{end}}
+    {{with .Compactions}}<span class="session-card-compactions" title="Context compactions">{{.}} compacted</span>{{end}}
+    {{with .ToolErrors}}<span class="session-card-errors" title="Failed tool calls">{{.}} failed</span>{{end}}
+</div>
+{{end}}
+{{end}}`
@@ -24,7 +24,7 @@ const searchTemplate = `<!DOCTYPE html>
                     <img src="/claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
                     Claude Code Logs
                 </a>
-                <div class="sidebar-subtitle">{{len .Projects}} projects</div>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .Projects}} projects</div>
                 <a href="/search" class="stats-nav-link active">
                     <svg viewBox="0 0 20 20" fill="currentColor">
                         <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
@@ -40,16 +40,16 @@ const searchTemplate = `<!DOCTYPE html>
                 <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
                 <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
             </div>
-            <ul class="project-list">
+            <ul class="project-list" data-live="tree">
                 {{range $project := .Projects}}
-                <li class="tree-node collapsed" data-project="{{ProjectSlug $project.Path}}">
+                <li class="tree-node collapsed" data-project="{{$project.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len $project.Sessions) 0}} hidden{{end}}" aria-expanded="false" aria-label="Toggle {{$project.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="/{{ProjectSlug $project.Path}}/" class="tree-node-link">
+                        <a href="/{{$project.Slug}}/" class="tree-node-link">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{$project.Path}}</span>
                                 <span class="tree-node-meta">{{len $project.Sessions}} sessions</span>
@@ -61,7 +61,7 @@ const searchTemplate = `<!DOCTYPE html>
                     <ul class="tree-children session-list">
                         {{range $project.Sessions}}
                         <li class="session-item">
-                            <a href="/{{ProjectSlug $project.Path}}/{{.ID}}" class="session-link">
+                            <a href="/{{$project.Slug}}/{{.ID}}" class="session-link">
                                 <span class="session-title">{{.Summary}}</span>
                                 <span class="session-date">{{.CreatedAt.Format "Jan 2, 2006"}}</span>
                             </a>
@@ -104,6 +104,23 @@ const searchTemplate = `<!DOCTYPE html>
             <div class="search-meta" id="searchMeta" style="display: none;">
                 <span id="searchMetaText"></span>
                 <div class="search-sort">
+                    <label class="search-toggle">
+                        <input type="checkbox" id="searchThinking">
+                        Include thinking
+                    </label>
+                    <label class="search-toggle">
+                        <input type="checkbox" id="searchErrors">
+                        Errors only
+                    </label>
+                    {{if .Branches}}
+                    <label for="searchBranch">Branch:</label>
+                    <select id="searchBranch" class="search-branch">
+                        <option value="">All branches</option>
+                        {{range .Branches}}
+                        <option value="{{.}}">{{.}}</option>
+                        {{end}}
+                    </select>
+                    {{end}}
                     <label>Sort by:</label>
                     <select id="searchSort">
                         <option value="relevance">Relevance</option>
@@ -162,6 +179,9 @@ const searchTemplate = `<!DOCTYPE html>
         var searchMeta = document.getElementById('searchMeta');
         var searchMetaText = document.getElementById('searchMetaText');
         var searchSort = document.getElementById('searchSort');
+        var searchThinking = document.getElementById('searchThinking');
+        var searchBranch = document.getElementById('searchBranch');
+        var searchErrors = document.getElementById('searchErrors');
         var searchInitial = document.getElementById('searchInitial');
         var searchLoading = document.getElementById('searchLoading');
         var searchEmpty = document.getElementById('searchEmpty');
@@ -182,6 +202,9 @@ const searchTemplate = `<!DOCTYPE html>
         // Read initial query from URL
         var urlParams = new URLSearchParams(window.location.search);
         var initialQuery = urlParams.get('q') || '';
+        if (searchBranch && urlParams.get('branch')) {
+            searchBranch.value = urlParams.get('branch');
+        }
         if (initialQuery) {
             searchInput.value = initialQuery;
             performSearch(initialQuery, false);
@@ -210,6 +233,30 @@ const searchTemplate = `<!DOCTYPE html>
             }
         });
 
+        // Include thinking change
+        searchThinking.addEventListener('change', function() {
+            if (currentQuery) {
+                performSearch(currentQuery, false);
+            }
+        });
+
+        // Errors only change
+        searchErrors.addEventListener('change', function() {
+            if (currentQuery) {
+                performSearch(currentQuery, false);
+            }
+        });
+
+        // Branch change
+        if (searchBranch) {
+            searchBranch.addEventListener('change', function() {
+                updateURL(currentQuery);
+                if (currentQuery) {
+                    performSearch(currentQuery, false);
+                }
+            });
+        }
+
         // Load more
         loadMoreBtn.addEventListener('click', function() {
             if (!isLoading && hasMore) {
@@ -224,6 +271,11 @@ const searchTemplate = `<!DOCTYPE html>
             } else {
                 url.searchParams.delete('q');
             }
+            if (searchBranch && searchBranch.value) {
+                url.searchParams.set('branch', searchBranch.value);
+            } else {
+                url.searchParams.delete('branch');
+            }
             window.history.replaceState({}, '', url);
         }
 
@@ -320,7 +372,7 @@ const searchTemplate = `<!DOCTYPE html>
                     '<div class="search-result-matches">' +
                         result.matches.map(function(m, i) {
                             return '<div class="search-match-item">' +
-                                '<div class="search-match-role">' + m.role + '</div>' +
+                                '<div class="search-match-role">' + m.role + (m.thinking ? ' · thinking' : '') + (m.toolError ? ' · failed ' + (m.toolName ? escapeHtml(m.toolName) + ' call' : 'tool call') : '') + '</div>' +
                                 '<div class="search-match-content">' + m.content + '</div>' +
                             '</div>';
                         }).join('') +
@@ -370,7 +422,10 @@ const searchTemplate = `<!DOCTYPE html>
                     query: query,
                     offset: offset,
                     limit: 20,
-                    sort: sort
+                    sort: sort,
+                    includeThinking: searchThinking.checked,
+                    branch: searchBranch ? searchBranch.value : '',
+                    errorsOnly: searchErrors.checked
                 })
             })
             .then(function(r) { return r.json(); })
@@ -554,6 +609,7 @@ const searchTemplate = `<!DOCTYPE html>
         }
     })();
     </script>
+    <script>` + liveJS + `</script>
 </body>
 </html>`
 
@@ -639,6 +695,14 @@ const searchCSS = `
     color: var(--text-muted);
 }
 
+.search-sort .search-toggle {
+    display: flex;
+    align-items: center;
+    gap: 6px;
+    margin-right: 12px;
+    cursor: pointer;
+}
+
 .search-sort select {
     padding: 6px 12px;
     border: 1px solid var(--border-medium);
@@ -50,14 +50,14 @@ const sessionTemplate = `<!DOCTYPE html>
             </div>
             <ul class="project-list">
                 {{range .AllProjects}}
-                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{ProjectSlug .Path}}">
+                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len .Sessions) 0}} hidden{{end}}" aria-expanded="{{if eq .Path $.Project.Path}}true{{else}}false{{end}}" aria-label="Toggle {{.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="../../{{ProjectSlug .Path}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
+                        <a href="../../{{.Slug}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{.Path}}</span>
                                 <span class="tree-node-meta">{{len .Sessions}} sessions</span>
@@ -89,7 +89,7 @@ const sessionTemplate = `<!DOCTYPE html>
                     <a href="index.html">{{.Project.Path}}</a>
                 </div>
                 <h1 class="page-title">{{.Session.Summary}}</h1>
-                <p class="page-subtitle">{{.Session.CreatedAt.Format "January 2, 2006 at 3:04 PM"}} · {{len .Session.Messages}} messages</p>
+                <p class="page-subtitle">{{.Session.CreatedAt.Format "January 2, 2006 at 3:04 PM"}} · {{.Session.MessageCount}} messages</p>
                 <div class="page-actions">
                     <a href="file://{{.Session.SourcePath}}" class="page-action-link" title="{{.Session.SourcePath}}">
                         <svg viewBox="0 0 20 20" fill="currentColor"><path fill-rule="evenodd" d="M4 4a2 2 0 012-2h4.586A2 2 0 0112 2.586L15.414 6A2 2 0 0116 7.414V16a2 2 0 01-2 2H6a2 2 0 01-2-2V4z" clip-rule="evenodd"/></svg>
@@ -100,6 +100,10 @@ const sessionTemplate = `<!DOCTYPE html>
             {{if .Session.Messages}}
             <div class="message-list">
                 {{range $idx, $msg := .Session.Messages}}
+                {{if $msg.Event}}
+                <div class="system-event {{$msg.Event.Kind}}">— {{$msg.Event.Summary}} —</div>
+                {{else if $.ToolCalls.ResultsOnly $msg}}
+                {{else}}
                 <div class="message {{$msg.Role}}">
                     <div class="message-body">
                         <div class="message-header">
@@ -111,15 +115,19 @@ const sessionTemplate = `<!DOCTYPE html>
                             {{if eq $block.Type "text"}}
                             {{RenderText $block.Text}}
                             {{else if eq $block.Type "tool_use"}}
-                            <div class="tool-block">
+                            {{$call := $.ToolCalls.Joined $block.ToolUseID}}
+                            <div class="tool-block{{if and $call $call.IsError}} tool-error{{end}}">
                                 <div class="tool-header" onclick="toggleTool('tool-{{$idx}}-{{$bidx}}')">
-                                    <span class="tool-icon">T</span>
-                                    <span class="tool-name">{{$block.ToolName}}</span>
+                                    {{if and $call $call.IsError}}<span class="tool-icon error">!</span>{{else}}<span class="tool-icon">T</span>{{end}}
+                                    <span class="tool-name">{{$block.ToolName}}{{with ToolLabel $block.ToolName $block.ToolInput}} · {{.}}{{end}}{{if $call}}{{with $call.DurationLabel}} · {{.}}{{end}}{{end}}</span>
                                     <span class="tool-toggle">▼</span>
                                 </div>
-                                <div id="tool-{{$idx}}-{{$bidx}}" class="tool-content collapsed">{{$block.ToolInput}}</div>
+                                <div id="tool-{{$idx}}-{{$bidx}}" class="tool-content collapsed">{{$block.ToolInput}}{{if $call}}
+
+{{if $call.IsError}}Result (error){{else}}Result{{end}}:
+{{$call.Output}}{{end}}</div>
                             </div>
-                            {{else if eq $block.Type "tool_result"}}
+                            {{else if and (eq $block.Type "tool_result") (not ($.ToolCalls.Joined $block.ToolUseID))}}
                             <div class="tool-block">
                                 <div class="tool-header" onclick="toggleTool('result-{{$idx}}-{{$bidx}}')">
                                     <span class="tool-icon result">R</span>
@@ -134,6 +142,7 @@ const sessionTemplate = `<!DOCTYPE html>
                     </div>
                 </div>
                 {{end}}
+                {{end}}
             </div>
             {{else}}
             <div class="empty-state">
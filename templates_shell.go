@@ -26,7 +26,7 @@ const sessionShellTemplate = `<!DOCTYPE html>
                     <img src="../../claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
                     Claude Code Logs
                 </a>
-                <div class="sidebar-subtitle">{{len .AllProjects}} projects</div>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .AllProjects}} projects</div>
                 <a href="/search" class="stats-nav-link">
                     <svg viewBox="0 0 20 20" fill="currentColor">
                         <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
@@ -42,16 +42,16 @@ const sessionShellTemplate = `<!DOCTYPE html>
                 <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
                 <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
             </div>
-            <ul class="project-list">
+            <ul class="project-list" data-live="tree">
                 {{range .AllProjects}}
-                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{ProjectSlug .Path}}">
+                <li class="tree-node{{if ne .Path $.Project.Path}} collapsed{{end}}" data-project="{{.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len .Sessions) 0}} hidden{{end}}" aria-expanded="{{if eq .Path $.Project.Path}}true{{else}}false{{end}}" aria-label="Toggle {{.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="../../{{ProjectSlug .Path}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
+                        <a href="../../{{.Slug}}/index.html" class="tree-node-link{{if eq .Path $.Project.Path}} active{{end}}">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{.Path}}</span>
                                 <span class="tree-node-meta">{{len .Sessions}} sessions</span>
@@ -82,8 +82,8 @@ const sessionShellTemplate = `<!DOCTYPE html>
                     <span class="page-breadcrumb-separator">/</span>
                     <a href="index.html">{{.Project.Path}}</a>
                 </div>
-                <h1 class="page-title" id="session-title">{{.Session.Summary}}</h1>
-                <p class="page-subtitle" id="session-meta">{{.Session.CreatedAt.Format "January 2, 2006 at 3:04 PM"}} · {{len .Session.Messages}} messages</p>
+                <h1 class="page-title" id="session-title" data-live="session-title">{{.Session.Summary}}</h1>
+                <p class="page-subtitle" id="session-meta" data-live="session-meta">{{.Session.CreatedAt.Format "January 2, 2006 at 3:04 PM"}} · {{.Session.MessageCount}} messages{{if .Session.Archived}} · <span class="session-archived-badge" title="The session file is gone; this is the archived Markdown">archived</span>{{end}}</p>
                 <div class="page-actions" id="action-bar">
                     <button class="action-btn" id="copy-jsonl-path-btn" title="{{.Session.SourcePath}}" data-path="{{.Session.SourcePath}}">
                         <svg viewBox="0 0 20 20" fill="currentColor"><path fill-rule="evenodd" d="M4 4a2 2 0 012-2h4.586A2 2 0 0112 2.586L15.414 6A2 2 0 0116 7.414V16a2 2 0 01-2 2H6a2 2 0 01-2-2V4z" clip-rule="evenodd"/></svg>
@@ -114,6 +114,16 @@ const sessionShellTemplate = `<!DOCTYPE html>
                     <input type="checkbox" id="hideTools">
                     <span class="filter-checkbox-label">Hide tool calls</span>
                 </label>
+                <label class="filter-checkbox">
+                    <input type="checkbox" id="showThinking" checked>
+                    <span class="filter-checkbox-label">Show thinking</span>
+                </label>
+                {{with .Session.ToolErrors}}
+                <label class="filter-checkbox">
+                    <input type="checkbox" id="errorsOnly">
+                    <span class="filter-checkbox-label">Errors only ({{.}})</span>
+                </label>
+                {{end}}
             </div>
 
             <!-- Content loaded dynamically from MD file -->
@@ -180,6 +190,10 @@ const sessionShellTemplate = `<!DOCTYPE html>
 
             // Style the rendered content for conversation display
             styleContent();
+            keyNodes(contentArea);
+            setupForks();
+            applyForks();
+            applyBlockFilters();
         }
 
         // Fetch and render markdown
@@ -220,10 +234,11 @@ const sessionShellTemplate = `<!DOCTYPE html>
             return { frontmatter: frontmatter, body: body };
         }
 
-        // Style the content for conversation display
-        function styleContent() {
+        // Style the content for conversation display (the content area unless given)
+        function styleContent(root) {
+            root = root || contentArea;
             // Convert ## User and ## Assistant headers to message blocks
-            var headers = contentArea.querySelectorAll('h2');
+            var headers = root.querySelectorAll('h2');
             headers.forEach(function(h2) {
                 var role = h2.textContent.toLowerCase();
                 if (role === 'user' || role === 'assistant') {
@@ -255,21 +270,88 @@ const sessionShellTemplate = `<!DOCTYPE html>
                 }
             });
 
-            // Style details elements as tool blocks
-            var details = contentArea.querySelectorAll('details');
+            // Links to sibling session Markdown files open their session pages
+            root.querySelectorAll('a[href$=".md"]').forEach(function(a) {
+                var href = a.getAttribute('href');
+                if (href.indexOf('/') === -1 && href.indexOf(':') === -1) {
+                    a.setAttribute('href', href.slice(0, -3));
+                }
+            });
+
+            // Lift fork markers, system events and session navigation out of the message
+            // they follow (last first, so they keep their order), and keep branches at the end
+            var markers = root.querySelectorAll('.message-content > .fork-point, .message-content > .system-event, .message-content > .session-nav');
+            Array.prototype.slice.call(markers).reverse().forEach(function(marker) {
+                var msg = marker.closest('.message');
+                msg.parentNode.insertBefore(marker, msg.nextSibling);
+            });
+            var branchesHome = root.querySelector('.branches');
+            if (branchesHome && branchesHome.parentNode !== root) {
+                root.appendChild(branchesHome);
+            }
+
+            // Style details elements as thinking or tool blocks
+            var details = root.querySelectorAll('details');
             details.forEach(function(detail) {
-                detail.classList.add('tool-block');
                 var summary = detail.querySelector('summary');
+                if (detail.classList.contains('system-event')) return;
+                if (detail.classList.contains('thinking')) {
+                    detail.classList.add('thinking-block');
+                    if (summary) setBlockSummary(summary, 'thinking', '…');
+                    return;
+                }
+                detail.classList.add('tool-block');
+                if (detail.classList.contains('subagent')) {
+                    detail.classList.add('subagent-block');
+                    if (summary) setBlockSummary(summary, 'subagent', 'A');
+                    return;
+                }
                 if (summary) {
                     var text = summary.textContent;
-                    var isResult = text.toLowerCase().includes('result');
-                    summary.innerHTML = '<span class="tool-icon' + (isResult ? ' result' : '') + '">' + (isResult ? 'R' : 'T') + '</span>' +
-                        '<span class="tool-name">' + text + '</span>' +
-                        '<span class="tool-toggle">▼</span>';
+                    var isResult = !detail.classList.contains('tool-call') && text.toLowerCase().includes('result');
+                    var isError = detail.classList.contains('tool-error');
+                    var tool = /^Tool: (\S+)/.exec(text);
+                    if (isError) {
+                        setBlockSummary(summary, 'error', '!');
+                    } else if (isResult) {
+                        setBlockSummary(summary, 'result', 'R');
+                    } else {
+                        setBlockSummary(summary, '', (tool && toolIcons[tool[1]]) || 'T');
+                    }
                 }
             });
         }
 
+        // Icons of tools with their own rendering
+        var toolIcons = {
+            'Edit': '±',
+            'MultiEdit': '±',
+            'Write': '+',
+            'Bash': '$',
+            'Read': '⌕',
+            'Grep': '⌕',
+            'Glob': '⌕',
+            'TodoWrite': '☑'
+        };
+
+        // Replace a block summary with an icon, its title and a toggle arrow
+        function setBlockSummary(summary, iconClass, icon) {
+            var title = summary.textContent;
+            summary.textContent = '';
+            var iconEl = document.createElement('span');
+            iconEl.className = 'tool-icon' + (iconClass ? ' ' + iconClass : '');
+            iconEl.textContent = icon;
+            var nameEl = document.createElement('span');
+            nameEl.className = 'tool-name';
+            nameEl.textContent = title;
+            var toggleEl = document.createElement('span');
+            toggleEl.className = 'tool-toggle';
+            toggleEl.textContent = '▼';
+            summary.appendChild(iconEl);
+            summary.appendChild(nameEl);
+            summary.appendChild(toggleEl);
+        }
+
         // Download button
         downloadBtn.addEventListener('click', function() {
             if (!rawMarkdown) return;
@@ -318,39 +400,86 @@ const sessionShellTemplate = `<!DOCTYPE html>
             });
         });
 
-        // Filter: Hide tool calls checkbox
-        var hideToolsCheckbox = document.getElementById('hideTools');
-        hideToolsCheckbox.addEventListener('change', function() {
-            if (this.checked) {
-                contentArea.classList.add('hide-tools');
-                // Hide messages that only contain tool calls
-                var messages = contentArea.querySelectorAll('.message');
-                messages.forEach(function(msg) {
-                    var content = msg.querySelector('.message-content');
-                    if (content) {
-                        // Check if message only has details (tool blocks) and no other visible content
-                        var children = Array.from(content.children);
-                        var hasNonToolContent = children.some(function(child) {
-                            if (child.tagName === 'DETAILS') return false;
-                            // Check if element has meaningful text
-                            var text = child.textContent.trim();
-                            return text.length > 0;
-                        });
-                        if (!hasNonToolContent) {
-                            msg.classList.add('tools-only');
-                        }
-                    }
+        // Conversation branches: fork markers switch between the path taken and alternate branches
+        var forkSelection = {}; // fork message UUID -> selected branch element id ('' = path taken)
+
+        function setupForks() {
+            contentArea.querySelectorAll('.fork-point').forEach(function(marker) {
+                if (marker.children.length > 0) return;
+                var fork = marker.getAttribute('data-fork');
+                var ids = marker.getAttribute('data-branches').split(' ');
+                var html = '<span class="fork-label">' + (ids.length + 1) + ' versions</span>' +
+                    '<button type="button" class="fork-btn active" data-fork="' + fork + '" data-branch="">' +
+                    (marker.closest('.branch') ? 'This branch' : 'Main') + '</button>';
+                ids.forEach(function(id) {
+                    html += '<button type="button" class="fork-btn" data-fork="' + fork + '" data-branch="' + id + '">' +
+                        id.replace('branch-', 'Branch ') + '</button>';
                 });
-            } else {
-                contentArea.classList.remove('hide-tools');
-                // Show all messages again
-                var toolsOnlyMsgs = contentArea.querySelectorAll('.tools-only');
-                toolsOnlyMsgs.forEach(function(msg) {
-                    msg.classList.remove('tools-only');
+                marker.innerHTML = html;
+            });
+        }
+
+        // Return all branches to their hidden home, then place the selected ones
+        function applyForks() {
+            var home = contentArea.querySelector('.branches');
+            if (!home) return;
+            contentArea.querySelectorAll('.branch').forEach(function(branch) {
+                branch.classList.remove('active');
+                home.appendChild(branch);
+            });
+            placeBranches(contentArea);
+        }
+
+        // Show the selected branch after its fork marker and hide the rest of the path it replaces
+        function placeBranches(container) {
+            var hiding = false;
+            Array.from(container.children).forEach(function(child) {
+                if (child.classList.contains('branches')) return;
+                child.classList.toggle('fork-hidden', hiding);
+                if (hiding || !child.classList.contains('fork-point')) return;
+
+                var selected = forkSelection[child.getAttribute('data-fork')] || '';
+                child.querySelectorAll('.fork-btn').forEach(function(btn) {
+                    btn.classList.toggle('active', btn.getAttribute('data-branch') === selected);
                 });
-            }
+                var branch = selected ? document.getElementById(selected) : null;
+                if (!branch) return;
+
+                branch.classList.add('active');
+                container.insertBefore(branch, child.nextSibling);
+                placeBranches(branch);
+                hiding = true;
+            });
+        }
+
+        contentArea.addEventListener('click', function(e) {
+            var btn = e.target.closest('.fork-btn');
+            if (!btn) return;
+            forkSelection[btn.getAttribute('data-fork')] = btn.getAttribute('data-branch');
+            applyForks();
+            applyBlockFilters();
         });
 
+        // Filter: Hide tool calls / show thinking checkboxes
+        var hideToolsCheckbox = document.getElementById('hideTools');
+        var showThinkingCheckbox = document.getElementById('showThinking');
+        var errorsOnlyCheckbox = document.getElementById('errorsOnly');
+        hideToolsCheckbox.addEventListener('change', function() {
+            applyBlockFilters();
+        });
+        showThinkingCheckbox.addEventListener('change', function() {
+            applyBlockFilters();
+        });
+        if (errorsOnlyCheckbox) {
+            errorsOnlyCheckbox.addEventListener('change', function() {
+                applyBlockFilters();
+            });
+        }
+
+        function errorsOnly() {
+            return errorsOnlyCheckbox !== null && errorsOnlyCheckbox.checked;
+        }
+
         // Filter: Page search
         var pageSearchInput = document.getElementById('pageSearch');
         var searchCountSpan = document.getElementById('searchCount');
@@ -363,27 +492,36 @@ const sessionShellTemplate = `<!DOCTYPE html>
             return string.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
         }
 
-        // Helper: Check if message only contains tool calls (no visible text content)
-        function isToolOnlyMessage(msg) {
+        // Helper: Check if an element is a tool or thinking block hidden by the filters
+        function isHiddenBlock(el) {
+            if (el.tagName !== 'DETAILS') return false;
+            if (el.classList.contains('thinking-block')) return !showThinkingCheckbox.checked;
+            if (el.classList.contains('tool-error') && errorsOnly()) return false;
+            return hideToolsCheckbox.checked;
+        }
+
+        // Helper: Check if message has no visible content once filtered blocks are hidden
+        function isFilteredOutMessage(msg) {
+            if (msg.closest('.fork-hidden, .branches')) return true;
+            if (errorsOnly() && !msg.querySelector('.tool-error')) return true;
             var content = msg.querySelector('.message-content');
             if (!content) return false;
             var children = Array.from(content.children);
             return !children.some(function(child) {
-                if (child.tagName === 'DETAILS') return false;
+                if (isHiddenBlock(child)) return false;
                 var text = child.textContent.trim();
                 return text.length > 0;
             });
         }
 
-        // Helper: Apply hide-tools state to mark tool-only messages
-        function applyHideToolsState() {
-            if (!hideToolsCheckbox.checked) return;
-            contentArea.classList.add('hide-tools');
+        // Helper: Apply hide-tools/hide-thinking state and hide messages left empty
+        function applyBlockFilters() {
+            contentArea.classList.toggle('hide-tools', hideToolsCheckbox.checked);
+            contentArea.classList.toggle('hide-thinking', !showThinkingCheckbox.checked);
+            contentArea.classList.toggle('errors-only', errorsOnly());
             var messages = contentArea.querySelectorAll('.message');
             messages.forEach(function(msg) {
-                if (isToolOnlyMessage(msg)) {
-                    msg.classList.add('tools-only');
-                }
+                msg.classList.toggle('filtered-out', isFilteredOutMessage(msg));
             });
         }
 
@@ -392,8 +530,9 @@ const sessionShellTemplate = `<!DOCTYPE html>
                 // Restore original content
                 contentArea.innerHTML = originalHTML;
                 styleContent();
-                // Re-apply hide tools state
-                applyHideToolsState();
+                applyForks();
+                // Re-apply block filters
+                applyBlockFilters();
             }
             currentHighlights = [];
             currentHighlightIndex = -1;
@@ -414,18 +553,18 @@ const sessionShellTemplate = `<!DOCTYPE html>
                 // Restore original before new search
                 contentArea.innerHTML = originalHTML;
                 styleContent();
+                applyForks();
             }
 
-            // Apply hide-tools state first
-            applyHideToolsState();
+            // Apply block filters first
+            applyBlockFilters();
 
             var regex = new RegExp('(' + escapeRegExp(query) + ')', 'gi');
             var messages = Array.from(contentArea.querySelectorAll('.message'));
-            var hideTools = hideToolsCheckbox.checked;
 
-            // Filter out tool-only messages if hide tools is checked
+            // Filter out messages with no visible content
             var searchableMessages = messages.filter(function(msg, idx) {
-                if (hideTools && isToolOnlyMessage(msg)) {
+                if (isFilteredOutMessage(msg)) {
                     msg.style.display = 'none';
                     return false;
                 }
@@ -434,24 +573,20 @@ const sessionShellTemplate = `<!DOCTYPE html>
 
             var totalMessages = searchableMessages.length;
 
-            // Find which messages contain matches (excluding tool content if hidden)
+            // Find which messages contain matches (excluding hidden tool/thinking content)
             var matchingIndices = [];
             searchableMessages.forEach(function(msg, idx) {
-                // When hide tools is on, only search in non-tool content
+                // Only search in blocks that are visible
                 var searchText;
-                if (hideTools) {
-                    var content = msg.querySelector('.message-content');
-                    if (content) {
-                        var textParts = [];
-                        Array.from(content.children).forEach(function(child) {
-                            if (child.tagName !== 'DETAILS') {
-                                textParts.push(child.textContent);
-                            }
-                        });
-                        searchText = textParts.join(' ');
-                    } else {
-                        searchText = msg.textContent;
-                    }
+                var content = msg.querySelector('.message-content');
+                if (content) {
+                    var textParts = [];
+                    Array.from(content.children).forEach(function(child) {
+                        if (!isHiddenBlock(child)) {
+                            textParts.push(child.textContent);
+                        }
+                    });
+                    searchText = textParts.join(' ');
                 } else {
                     searchText = msg.textContent;
                 }
@@ -484,9 +619,9 @@ const sessionShellTemplate = `<!DOCTYPE html>
             searchableMessages.forEach(function(msg, idx) {
                 if (!visibleIndices.has(idx)) return;
 
-                // Walk the DOM to find text nodes (skip details elements if hide tools)
+                // Walk the DOM to find text nodes (skip hidden tool/thinking blocks)
                 function walk(node) {
-                    if (hideTools && node.tagName === 'DETAILS') return;
+                    if (node.nodeType === 1 && isHiddenBlock(node)) return;
                     if (node.nodeType === 3) { // Text node
                         var text = node.textContent;
                         if (text.match(regex)) {
@@ -558,11 +693,90 @@ const sessionShellTemplate = `<!DOCTYPE html>
             clearHighlights();
             pageSearchInput.focus();
         });
+
+        // Live updates: when the watcher reloads this session, fetch its Markdown again and
+        // append what's new, keeping the messages already shown (and their open blocks)
+        var projectSlug = '{{.Project.Slug}}';
+        var sessionID = '{{.Session.ID}}';
+        document.addEventListener('live-session-updated', function(e) {
+            if (e.detail.project !== projectSlug || e.detail.sessionId !== sessionID || !rawMarkdown) return;
+            fetch(mdUrl, { cache: 'no-store' })
+                .then(function(response) {
+                    if (!response.ok) throw new Error('Failed to load');
+                    return response.text();
+                })
+                .then(appendUpdate)
+                .catch(function(err) {
+                    console.warn('Failed to update session:', err);
+                });
+        });
+
+        function appendUpdate(md) {
+            if (md === rawMarkdown) return;
+            rawMarkdown = md;
+            var doc = document.documentElement;
+            var followEnd = window.innerHeight + window.scrollY >= doc.scrollHeight - 100;
+            var query = pageSearchInput.value;
+            if (originalHTML) {
+                clearHighlights();
+                originalHTML = '';
+            }
+
+            var fresh = document.createElement('div');
+            fresh.innerHTML = marked.parse(parseFrontmatter(md).body);
+            styleContent(fresh);
+            keyNodes(fresh);
+
+            // Put shown branches back home so both lists are laid out as rendered, then
+            // keep the unchanged nodes and replace the rest
+            var home = contentArea.querySelector('.branches');
+            if (home) {
+                contentArea.querySelectorAll('.branch').forEach(function(branch) {
+                    home.appendChild(branch);
+                });
+            }
+            var current = Array.from(contentArea.children);
+            var next = Array.from(fresh.children);
+            var kept = 0;
+            while (kept < current.length && kept < next.length &&
+                current[kept].getAttribute('data-key') === next[kept].getAttribute('data-key')) {
+                kept++;
+            }
+            current.slice(kept).forEach(function(node) {
+                contentArea.removeChild(node);
+            });
+            next.slice(kept).forEach(function(node) {
+                if (node.classList.contains('message')) node.classList.add('message-new');
+                contentArea.appendChild(node);
+            });
+
+            setupForks();
+            applyForks();
+            applyBlockFilters();
+            if (query.length >= 2) {
+                highlightMatches(query);
+            } else if (followEnd) {
+                window.scrollTo(0, doc.scrollHeight);
+            }
+        }
+
+        // Key top-level nodes by their rendered HTML, so updates can tell which are unchanged
+        function keyNodes(root) {
+            Array.from(root.children).forEach(function(node) {
+                var html = node.outerHTML;
+                var hash = 0;
+                for (var i = 0; i < html.length; i++) {
+                    hash = (hash * 31 + html.charCodeAt(i)) | 0;
+                }
+                node.setAttribute('data-key', hash.toString(36));
+            });
+        }
     })();
     </script>
 
     <!-- Sidebar and search functionality (same as existing) -->
     <script>` + sidebarJS + `</script>
+    <script>` + liveJS + `</script>
 </body>
 </html>`
 
@@ -25,7 +25,7 @@ const statsTemplate = `<!DOCTYPE html>
                     <img src="claude-code-icon.png" alt="Claude Code" class="sidebar-logo">
                     Claude Code Logs
                 </a>
-                <div class="sidebar-subtitle">{{len .Projects}} projects</div>
+                <div class="sidebar-subtitle" data-live="project-count">{{len .Projects}} projects</div>
                 <a href="/search" class="stats-nav-link">
                     <svg viewBox="0 0 20 20" fill="currentColor">
                         <path fill-rule="evenodd" d="M8 4a4 4 0 100 8 4 4 0 000-8zM2 8a6 6 0 1110.89 3.476l4.817 4.817a1 1 0 01-1.414 1.414l-4.816-4.816A6 6 0 012 8z" clip-rule="evenodd"/>
@@ -41,16 +41,16 @@ const statsTemplate = `<!DOCTYPE html>
                 <button type="button" class="tree-control-btn" id="expandAll">Expand All</button>
                 <button type="button" class="tree-control-btn" id="collapseAll">Collapse All</button>
             </div>
-            <ul class="project-list">
+            <ul class="project-list" data-live="tree">
                 {{range $project := .Projects}}
-                <li class="tree-node collapsed" data-project="{{ProjectSlug $project.Path}}">
+                <li class="tree-node collapsed" data-project="{{$project.Slug}}">
                     <div class="tree-node-header">
                         <button type="button" class="tree-toggle{{if eq (len $project.Sessions) 0}} hidden{{end}}" aria-expanded="false" aria-label="Toggle {{$project.Path}}">
                             <svg class="tree-chevron" viewBox="0 0 20 20" fill="currentColor">
                                 <path fill-rule="evenodd" d="M7.293 14.707a1 1 0 010-1.414L10.586 10 7.293 6.707a1 1 0 011.414-1.414l4 4a1 1 0 010 1.414l-4 4a1 1 0 01-1.414 0z" clip-rule="evenodd"/>
                             </svg>
                         </button>
-                        <a href="{{ProjectSlug $project.Path}}/index.html" class="tree-node-link">
+                        <a href="{{$project.Slug}}/index.html" class="tree-node-link">
                             <span class="tree-node-content">
                                 <span class="tree-node-name">{{$project.Path}}</span>
                                 <span class="tree-node-meta">{{len $project.Sessions}} sessions</span>
@@ -62,7 +62,7 @@ const statsTemplate = `<!DOCTYPE html>
                     <ul class="tree-children session-list">
                         {{range $project.Sessions}}
                         <li class="session-item">
-                            <a href="{{ProjectSlug $project.Path}}/{{.ID}}.html" class="session-link">
+                            <a href="{{$project.Slug}}/{{.ID}}.html" class="session-link">
                                 <span class="session-title">{{.Summary}}</span>
                                 <span class="session-date">{{.CreatedAt.Format "Jan 2, 2006"}}</span>
                             </a>
@@ -183,7 +183,8 @@ const statsTemplate = `<!DOCTYPE html>
                     </div>
                     <div class="stat-card">
                         <div class="stat-value" id="stat-tokens">-</div>
-                        <div class="stat-label">Est. Tokens</div>
+                        <div class="stat-label" id="stat-tokens-label">Tokens</div>
+                        <div class="stat-note" id="stat-tokens-note"></div>
                     </div>
                     <div class="stat-card">
                         <div class="stat-value" id="stat-cost">-</div>
@@ -206,7 +207,7 @@ const statsTemplate = `<!DOCTYPE html>
                     <div class="chart-card">
                         <div class="chart-header">
                             <h3 class="chart-title">Tokens per Day</h3>
-                            <span class="chart-subtitle">Estimated usage</span>
+                            <span class="chart-subtitle" id="tokens-chart-subtitle">Measured usage</span>
                         </div>
                         <div class="chart-container">
                             <canvas id="tokensChart"></canvas>
@@ -222,6 +223,46 @@ const statsTemplate = `<!DOCTYPE html>
                             <canvas id="projectsChart"></canvas>
                         </div>
                     </div>
+
+                    <div class="chart-card chart-wide">
+                        <div class="chart-header">
+                            <h3 class="chart-title">Cost by Model</h3>
+                            <span class="chart-subtitle">All time</span>
+                        </div>
+                        <table class="model-cost-table">
+                            <thead>
+                                <tr>
+                                    <th>Model</th>
+                                    <th>Messages</th>
+                                    <th>Input</th>
+                                    <th>Output</th>
+                                    <th>Cache Write</th>
+                                    <th>Cache Read</th>
+                                    <th>Cost</th>
+                                </tr>
+                            </thead>
+                            <tbody id="model-cost-body"></tbody>
+                        </table>
+                    </div>
+
+                    <div class="chart-card chart-wide">
+                        <div class="chart-header">
+                            <h3 class="chart-title">Tool Calls</h3>
+                            <span class="chart-subtitle">All time</span>
+                        </div>
+                        <table class="model-cost-table">
+                            <thead>
+                                <tr>
+                                    <th>Tool</th>
+                                    <th>Calls</th>
+                                    <th>Failed</th>
+                                    <th>No Result</th>
+                                    <th>Avg Duration</th>
+                                </tr>
+                            </thead>
+                            <tbody id="tool-stats-body"></tbody>
+                        </table>
+                    </div>
                 </div>
 
                 <!-- Session Stats -->
@@ -236,6 +277,10 @@ const statsTemplate = `<!DOCTYPE html>
                                 <div class="stat-value" id="stat-avg-messages">-</div>
                                 <div class="stat-label">Avg Messages/Session</div>
                             </div>
+                            <div>
+                                <div class="stat-value" id="stat-compactions">-</div>
+                                <div class="stat-label">Context Compactions</div>
+                            </div>
                         </div>
                     </div>
                 </div>
@@ -280,6 +325,76 @@ const statsTemplate = `<!DOCTYPE html>
             return '$' + amount.toFixed(2);
         }
 
+        // Mark token figures as measured (API usage) or estimated (content length)
+        function updateTokenSource(data) {
+            var total = data.totalTokens || 0;
+            var estimated = data.estimatedTokens || 0;
+            var label = document.getElementById('stat-tokens-label');
+            var note = document.getElementById('stat-tokens-note');
+            var subtitle = document.getElementById('tokens-chart-subtitle');
+
+            if (total === 0 || estimated === 0) {
+                label.textContent = 'Tokens';
+                note.textContent = 'Measured';
+                subtitle.textContent = 'Measured usage';
+            } else if (estimated >= total) {
+                label.textContent = 'Est. Tokens';
+                note.textContent = 'Estimated from content length';
+                subtitle.textContent = 'Estimated usage';
+            } else {
+                var pct = Math.round(estimated / total * 100);
+                label.textContent = 'Tokens';
+                note.textContent = (pct < 1 ? '<1' : pct) + '% estimated';
+                subtitle.textContent = 'Measured + estimated usage';
+            }
+        }
+
+        function formatCallDuration(ms) {
+            if (ms < 1000) return Math.round(ms) + 'ms';
+            if (ms < 60000) return (ms / 1000).toFixed(1) + 's';
+            return Math.floor(ms / 60000) + 'm ' + Math.floor(ms % 60000 / 1000) + 's';
+        }
+
+        function renderToolStats(toolStats) {
+            var body = document.getElementById('tool-stats-body');
+            body.innerHTML = '';
+            if (!toolStats || toolStats.length === 0) {
+                body.innerHTML = '<tr><td colspan="5" class="model-cost-empty">No tool calls recorded</td></tr>';
+                return;
+            }
+            toolStats.forEach(function(t) {
+                var row = document.createElement('tr');
+                [t.tool, formatNumber(t.calls), formatNumber(t.errors), formatNumber(t.pending),
+                    t.timedCalls > 0 ? formatCallDuration(t.totalDurationMs / t.timedCalls) : '—'
+                ].forEach(function(value) {
+                    var cell = document.createElement('td');
+                    cell.textContent = value;
+                    row.appendChild(cell);
+                });
+                body.appendChild(row);
+            });
+        }
+
+        function renderModelCosts(modelStats) {
+            var body = document.getElementById('model-cost-body');
+            body.innerHTML = '';
+            if (!modelStats || modelStats.length === 0) {
+                body.innerHTML = '<tr><td colspan="7" class="model-cost-empty">No usage recorded</td></tr>';
+                return;
+            }
+            modelStats.forEach(function(m) {
+                var row = document.createElement('tr');
+                [m.model, formatNumber(m.messages), formatNumber(m.inputTokens), formatNumber(m.outputTokens),
+                    formatNumber(m.cacheWriteTokens), formatNumber(m.cacheReadTokens), formatCurrency(m.cost)
+                ].forEach(function(value) {
+                    var cell = document.createElement('td');
+                    cell.textContent = value;
+                    row.appendChild(cell);
+                });
+                body.appendChild(row);
+            });
+        }
+
         // Store full data for filtering
         var fullData = null;
         var messagesChart = null;
@@ -487,17 +602,22 @@ const statsTemplate = `<!DOCTYPE html>
             var tokensByDay = {};
             var costByDay = {};
             var totalMessages = 0;
+            var estimatedByDay = {};
             var totalTokens = 0;
+            var totalEstimatedTokens = 0;
             var totalCost = 0;
             var totalSessions = 0;
             var totalSessionMins = 0;
+            var totalCompactions = 0;
 
             projects.forEach(function(p) {
                 totalMessages += p.messages;
                 totalTokens += p.tokens;
+                totalEstimatedTokens += p.estimatedTokens || 0;
                 totalCost += p.cost || 0;
                 totalSessions += p.sessions;
                 totalSessionMins += (p.avgSessionLengthMins || 0) * p.sessions;
+                totalCompactions += p.compactions || 0;
 
                 (p.messagesPerDay || []).forEach(function(d) {
                     messagesByDay[d.date] = (messagesByDay[d.date] || 0) + d.value;
@@ -505,23 +625,65 @@ const statsTemplate = `<!DOCTYPE html>
                 (p.tokensPerDay || []).forEach(function(d) {
                     tokensByDay[d.date] = (tokensByDay[d.date] || 0) + d.value;
                     costByDay[d.date] = (costByDay[d.date] || 0) + (d.cost || 0);
+                    estimatedByDay[d.date] = (estimatedByDay[d.date] || 0) + (d.estimated || 0);
+                });
+            });
+
+            // Merge per-model totals across projects
+            var modelsByName = {};
+            projects.forEach(function(p) {
+                (p.modelStats || []).forEach(function(m) {
+                    var acc = modelsByName[m.model];
+                    if (!acc) {
+                        acc = modelsByName[m.model] = { model: m.model, messages: 0, inputTokens: 0, outputTokens: 0, cacheWriteTokens: 0, cacheReadTokens: 0, cost: 0 };
+                    }
+                    acc.messages += m.messages;
+                    acc.inputTokens += m.inputTokens;
+                    acc.outputTokens += m.outputTokens;
+                    acc.cacheWriteTokens += m.cacheWriteTokens;
+                    acc.cacheReadTokens += m.cacheReadTokens;
+                    acc.cost += m.cost;
+                });
+            });
+            var modelStats = Object.keys(modelsByName).map(function(k) { return modelsByName[k]; })
+                .sort(function(a, b) { return b.cost - a.cost; });
+
+            // Merge per-tool totals across projects
+            var toolsByName = {};
+            projects.forEach(function(p) {
+                (p.toolStats || []).forEach(function(t) {
+                    var acc = toolsByName[t.tool];
+                    if (!acc) {
+                        acc = toolsByName[t.tool] = { tool: t.tool, calls: 0, errors: 0, pending: 0, timedCalls: 0, totalDurationMs: 0 };
+                    }
+                    acc.calls += t.calls;
+                    acc.errors += t.errors;
+                    acc.pending += t.pending;
+                    acc.timedCalls += t.timedCalls;
+                    acc.totalDurationMs += t.totalDurationMs;
                 });
             });
+            var toolStats = Object.keys(toolsByName).map(function(k) { return toolsByName[k]; })
+                .sort(function(a, b) { return b.calls - a.calls; });
 
             // Convert to sorted arrays
             var dates = Object.keys(messagesByDay).sort();
             var messagesPerDay = dates.map(function(d) { return { date: d, value: messagesByDay[d] }; });
-            var tokensPerDay = dates.map(function(d) { return { date: d, value: tokensByDay[d] || 0, cost: costByDay[d] || 0 }; });
+            var tokensPerDay = dates.map(function(d) { return { date: d, value: tokensByDay[d] || 0, cost: costByDay[d] || 0, estimated: estimatedByDay[d] || 0 }; });
 
             return {
                 messages: totalMessages,
                 tokens: totalTokens,
+                estimatedTokens: totalEstimatedTokens,
                 cost: totalCost,
                 sessions: totalSessions,
                 messagesPerDay: messagesPerDay,
                 tokensPerDay: tokensPerDay,
+                modelStats: modelStats,
+                toolStats: toolStats,
                 avgSessionLengthMins: totalSessions > 0 ? totalSessionMins / totalSessions : 0,
-                avgMessagesPerSession: totalSessions > 0 ? totalMessages / totalSessions : 0
+                avgMessagesPerSession: totalSessions > 0 ? totalMessages / totalSessions : 0,
+                compactions: totalCompactions
             };
         }
 
@@ -534,10 +696,14 @@ const statsTemplate = `<!DOCTYPE html>
             var baseTokensPerDay = aggregated ? aggregated.tokensPerDay : data.tokensPerDay;
             var baseTotalMessages = aggregated ? aggregated.messages : data.totalMessages;
             var baseTotalTokens = aggregated ? aggregated.tokens : data.totalTokens;
+            var baseEstimatedTokens = aggregated ? aggregated.estimatedTokens : (data.estimatedTokens || 0);
             var baseTotalCost = aggregated ? aggregated.cost : (data.totalCost || 0);
             var baseSessions = aggregated ? aggregated.sessions : data.totalSessions;
+            var baseModelStats = aggregated ? aggregated.modelStats : (data.modelStats || []);
+            var baseToolStats = aggregated ? aggregated.toolStats : (data.toolStats || []);
             var baseAvgLength = aggregated ? aggregated.avgSessionLengthMins : data.avgSessionLengthMins;
             var baseAvgMessages = aggregated ? aggregated.avgMessagesPerSession : data.avgMessagesPerSession;
+            var baseCompactions = aggregated ? aggregated.compactions : (data.totalCompactions || 0);
 
             // If no time filter, return project-filtered data
             if (range === 'all') {
@@ -546,12 +712,16 @@ const statsTemplate = `<!DOCTYPE html>
                     totalSessions: baseSessions,
                     totalMessages: baseTotalMessages,
                     totalTokens: baseTotalTokens,
+                    estimatedTokens: baseEstimatedTokens,
                     totalCost: baseTotalCost,
                     messagesPerDay: baseMessagesPerDay || [],
                     tokensPerDay: baseTokensPerDay || [],
+                    modelStats: baseModelStats,
+                    toolStats: baseToolStats,
                     projectStats: data.projectStats,
                     avgSessionLengthMins: baseAvgLength,
                     avgMessagesPerSession: baseAvgMessages,
+                    totalCompactions: baseCompactions,
                     selectedProjects: projects
                 };
             }
@@ -579,6 +749,7 @@ const statsTemplate = `<!DOCTYPE html>
             // Recalculate totals from filtered data
             var totalMessages = filteredMessages.reduce(function(sum, d) { return sum + d.value; }, 0);
             var totalTokens = filteredTokens.reduce(function(sum, d) { return sum + d.value; }, 0);
+            var estimatedTokens = filteredTokens.reduce(function(sum, d) { return sum + (d.estimated || 0); }, 0);
             var totalCost = filteredTokens.reduce(function(sum, d) { return sum + (d.cost || 0); }, 0);
 
             return {
@@ -586,12 +757,16 @@ const statsTemplate = `<!DOCTYPE html>
                 totalSessions: baseSessions,
                 totalMessages: totalMessages,
                 totalTokens: totalTokens,
+                estimatedTokens: estimatedTokens,
                 totalCost: totalCost,
                 messagesPerDay: filteredMessages,
                 tokensPerDay: filteredTokens,
+                modelStats: baseModelStats,
+                toolStats: baseToolStats,
                 projectStats: data.projectStats,
                 avgSessionLengthMins: baseAvgLength,
                 avgMessagesPerSession: baseAvgMessages,
+                totalCompactions: baseCompactions,
                 selectedProjects: projects
             };
         }
@@ -603,10 +778,14 @@ const statsTemplate = `<!DOCTYPE html>
             document.getElementById('stat-sessions').textContent = formatNumber(data.totalSessions);
             document.getElementById('stat-projects').textContent = formatNumber(data.totalProjects);
             document.getElementById('stat-cost').textContent = formatCurrency(data.totalCost || 0);
+            updateTokenSource(data);
+            renderModelCosts(data.modelStats);
+            renderToolStats(data.toolStats);
 
             // Update bottom stats (now filtered!)
             document.getElementById('stat-avg-length').textContent = formatMinutes(data.avgSessionLengthMins);
             document.getElementById('stat-avg-messages').textContent = data.avgMessagesPerSession.toFixed(1);
+            document.getElementById('stat-compactions').textContent = formatNumber(data.totalCompactions || 0);
 
             // Update charts
             updateMessagesChart(data.messagesPerDay);
@@ -630,6 +809,7 @@ const statsTemplate = `<!DOCTYPE html>
                     return new Date(d.date).toLocaleDateString('en-US', { month: 'short', day: 'numeric' });
                 });
                 tokensChart.data.datasets[0].data = data.map(function(d) { return d.value; });
+                tokensChart.data.datasets[1].data = data.map(function(d) { return d.estimated || 0; });
                 tokensChart.update();
             }
         }
@@ -678,8 +858,12 @@ const statsTemplate = `<!DOCTYPE html>
                 document.getElementById('stat-projects').textContent = formatNumber(data.totalProjects);
                 document.getElementById('stat-tokens').textContent = formatNumber(filtered.totalTokens);
                 document.getElementById('stat-cost').textContent = formatCurrency(filtered.totalCost || 0);
+                updateTokenSource(filtered);
+                renderModelCosts(filtered.modelStats);
+                renderToolStats(filtered.toolStats);
                 document.getElementById('stat-avg-length').textContent = formatMinutes(data.avgSessionLengthMins);
                 document.getElementById('stat-avg-messages').textContent = data.avgMessagesPerSession.toFixed(1);
+                document.getElementById('stat-compactions').textContent = formatNumber(filtered.totalCompactions || 0);
 
                 // Render charts with filtered data
                 messagesChart = renderMessagesChart(filtered.messagesPerDay);
@@ -695,6 +879,20 @@ const statsTemplate = `<!DOCTYPE html>
                 loading.innerHTML = '<div class="error-state"><div class="error-icon">!</div><p>Failed to load statistics</p></div>';
             });
 
+        // Live updates: refetch stats when the watcher reloads sessions, keeping the filters
+        document.addEventListener('live-stats-changed', function() {
+            if (!fullData) return;
+            fetch('/api/stats')
+                .then(function(r) { return r.json(); })
+                .then(function(data) {
+                    fullData = data;
+                    updateDisplay(filterData(fullData, currentRange, selectedProjects));
+                })
+                .catch(function(err) {
+                    console.warn('Failed to refresh stats:', err);
+                });
+        });
+
         // ===============================
         // Account Filter Functionality
         // ===============================
@@ -849,10 +1047,14 @@ const statsTemplate = `<!DOCTYPE html>
             var baseTokensPerDay = aggregated ? aggregated.tokensPerDay : data.tokensPerDay;
             var baseTotalMessages = aggregated ? aggregated.messages : data.totalMessages;
             var baseTotalTokens = aggregated ? aggregated.tokens : data.totalTokens;
+            var baseEstimatedTokens = aggregated ? aggregated.estimatedTokens : (data.estimatedTokens || 0);
             var baseTotalCost = aggregated ? aggregated.cost : (data.totalCost || 0);
             var baseSessions = aggregated ? aggregated.sessions : data.totalSessions;
+            var baseModelStats = aggregated ? aggregated.modelStats : (data.modelStats || []);
+            var baseToolStats = aggregated ? aggregated.toolStats : (data.toolStats || []);
             var baseAvgLength = aggregated ? aggregated.avgSessionLengthMins : data.avgSessionLengthMins;
             var baseAvgMessages = aggregated ? aggregated.avgMessagesPerSession : data.avgMessagesPerSession;
+            var baseCompactions = aggregated ? aggregated.compactions : (data.totalCompactions || 0);
 
             // Determine project count
             var projectCount = projects.length > 0 ? projects.length : (selectedAccount ? accountFilteredStats.length : data.totalProjects);
@@ -864,12 +1066,16 @@ const statsTemplate = `<!DOCTYPE html>
                     totalSessions: baseSessions,
                     totalMessages: baseTotalMessages,
                     totalTokens: baseTotalTokens,
+                    estimatedTokens: baseEstimatedTokens,
                     totalCost: baseTotalCost,
                     messagesPerDay: baseMessagesPerDay || [],
                     tokensPerDay: baseTokensPerDay || [],
+                    modelStats: baseModelStats,
+                    toolStats: baseToolStats,
                     projectStats: accountFilteredStats,
                     avgSessionLengthMins: baseAvgLength,
                     avgMessagesPerSession: baseAvgMessages,
+                    totalCompactions: baseCompactions,
                     selectedProjects: projects
                 };
             }
@@ -897,6 +1103,7 @@ const statsTemplate = `<!DOCTYPE html>
             // Recalculate totals from filtered data
             var totalMessages = filteredMessages.reduce(function(sum, d) { return sum + d.value; }, 0);
             var totalTokens = filteredTokens.reduce(function(sum, d) { return sum + d.value; }, 0);
+            var estimatedTokens = filteredTokens.reduce(function(sum, d) { return sum + (d.estimated || 0); }, 0);
             var totalCost = filteredTokens.reduce(function(sum, d) { return sum + (d.cost || 0); }, 0);
 
             return {
@@ -904,12 +1111,16 @@ const statsTemplate = `<!DOCTYPE html>
                 totalSessions: baseSessions,
                 totalMessages: totalMessages,
                 totalTokens: totalTokens,
+                estimatedTokens: estimatedTokens,
                 totalCost: totalCost,
                 messagesPerDay: filteredMessages,
                 tokensPerDay: filteredTokens,
+                modelStats: baseModelStats,
+                toolStats: baseToolStats,
                 projectStats: accountFilteredStats,
                 avgSessionLengthMins: baseAvgLength,
                 avgMessagesPerSession: baseAvgMessages,
+                totalCompactions: baseCompactions,
                 selectedProjects: projects
             };
         };
@@ -1056,6 +1267,15 @@ const statsTemplate = `<!DOCTYPE html>
                         tension: 0.3,
                         pointRadius: 2,
                         pointHoverRadius: 5
+                    }, {
+                        label: 'Estimated',
+                        data: data.map(function(d) { return d.estimated || 0; }),
+                        borderColor: '#A8A29E',
+                        borderDash: [4, 4],
+                        fill: false,
+                        tension: 0.3,
+                        pointRadius: 0,
+                        pointHoverRadius: 4
                     }]
                 },
                 options: {
@@ -1274,6 +1494,7 @@ const statsTemplate = `<!DOCTYPE html>
         });
     })();
     </script>
+    <script>` + liveJS + `</script>
 </body>
 </html>`
 
@@ -1942,6 +2163,12 @@ const statsCSS = `
     letter-spacing: 0.05em;
 }
 
+.stat-note {
+    margin-top: 4px;
+    font-size: 0.75rem;
+    color: var(--text-muted);
+}
+
 .stat-card-wide {
     grid-column: span 2;
 }
@@ -2001,6 +2228,48 @@ const statsCSS = `
     height: 300px;
 }
 
+/* Cost by Model */
+.model-cost-table {
+    width: 100%;
+    border-collapse: collapse;
+    font-size: 0.85rem;
+}
+
+.model-cost-table th,
+.model-cost-table td {
+    padding: 8px 12px;
+    text-align: right;
+    border-bottom: 1px solid var(--border-subtle);
+}
+
+.model-cost-table th:first-child,
+.model-cost-table td:first-child {
+    text-align: left;
+}
+
+.model-cost-table th {
+    font-weight: 500;
+    color: var(--text-muted);
+    text-transform: uppercase;
+    letter-spacing: 0.05em;
+    font-size: 0.75rem;
+}
+
+.model-cost-table td {
+    color: var(--text-secondary);
+    font-variant-numeric: tabular-nums;
+}
+
+.model-cost-table td:first-child {
+    font-family: var(--font-mono);
+    color: var(--text-primary);
+}
+
+.model-cost-table .model-cost-empty {
+    text-align: center;
+    color: var(--text-muted);
+}
+
 /* Session Stats */
 .session-stats {
     display: grid;
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tool call statuses
+const (
+	ToolCallOK      = "ok"
+	ToolCallError   = "error"
+	ToolCallPending = "pending" // No result recorded, e.g. the session was interrupted
+)
+
+// ToolCall joins a tool_use block with the tool_result that answered it
+type ToolCall struct {
+	ID         string         // tool_use ID shared by both blocks
+	Name       string         // Tool name
+	Input      string         // JSON string of tool input
+	Output     string         // Text of the result ("" while pending)
+	Parts      []ContentBlock // Image and document parts of the result
+	IsError    bool           // The tool reported a failure
+	HasResult  bool           // A tool_result was found
+	AgentID    string         // For Task calls: the subagent that ran
+	UseUUID    string         // Message holding the tool_use
+	ResultUUID string         // Message holding the tool_result
+	StartedAt  time.Time      // Timestamp of the tool_use message
+	EndedAt    time.Time      // Timestamp of the tool_result message
+}
+
+// Status returns ToolCallOK, ToolCallError or ToolCallPending
+func (c *ToolCall) Status() string {
+	switch {
+	case !c.HasResult:
+		return ToolCallPending
+	case c.IsError:
+		return ToolCallError
+	}
+	return ToolCallOK
+}
+
+// Duration returns the time between the call and its result, or 0 if unknown
+func (c *ToolCall) Duration() time.Duration {
+	if !c.HasResult || c.StartedAt.IsZero() || c.EndedAt.Before(c.StartedAt) {
+		return 0
+	}
+	return c.EndedAt.Sub(c.StartedAt)
+}
+
+// joinToolCalls pairs the tool_use and tool_result blocks of a session's messages by
+// tool use ID, in call order. Results without a matching call are left out.
+func joinToolCalls(messages []Message) []ToolCall {
+	var calls []ToolCall
+	byID := make(map[string]int)
+
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case "tool_use":
+				if block.ToolUseID == "" {
+					continue
+				}
+				if _, ok := byID[block.ToolUseID]; ok {
+					continue // Replayed in a resumed or branched transcript
+				}
+				byID[block.ToolUseID] = len(calls)
+				calls = append(calls, ToolCall{
+					ID:        block.ToolUseID,
+					Name:      block.ToolName,
+					Input:     block.ToolInput,
+					UseUUID:   msg.UUID,
+					StartedAt: msg.Timestamp,
+				})
+
+			case "tool_result":
+				i, ok := byID[block.ToolUseID]
+				if !ok || calls[i].HasResult {
+					continue
+				}
+				call := &calls[i]
+				call.HasResult = true
+				call.Output = block.ToolOutput
+				call.Parts = block.Parts
+				call.IsError = block.IsError
+				call.AgentID = block.AgentID
+				call.ResultUUID = msg.UUID
+				call.EndedAt = msg.Timestamp
+			}
+		}
+	}
+
+	return calls
+}
+
+// toolCallLookup is a session's tool calls keyed by tool use ID, built once when the
+// session is rendered so each message and block is a map lookup
+type toolCallLookup map[string]*ToolCall
+
+// Joined returns the call with the given tool use ID if its result was found, or nil
+func (l toolCallLookup) Joined(id string) *ToolCall {
+	if call := l[id]; call != nil && call.HasResult {
+		return call
+	}
+	return nil
+}
+
+// ResultsOnly reports whether a message holds nothing but results already shown with
+// their calls
+func (l toolCallLookup) ResultsOnly(msg Message) bool {
+	return onlyJoinedResults(&msg, l)
+}
+
+// toolCallIndex returns the session's tool calls keyed by tool use ID
+func toolCallIndex(calls []ToolCall) toolCallLookup {
+	index := make(toolCallLookup, len(calls))
+	for i := range calls {
+		index[calls[i].ID] = &calls[i]
+	}
+	return index
+}
+
+// formatDuration formats a tool call duration compactly, e.g. "350ms", "1.2s" or "2m 5s"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// DurationLabel returns the formatted duration of the call, or "" if unknown
+func (c *ToolCall) DurationLabel() string {
+	if d := c.Duration(); d > 0 {
+		return formatDuration(d)
+	}
+	return ""
+}
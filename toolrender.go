@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// toolView is how the input of a tool call is presented
+type toolView struct {
+	Label string // One-line description shown after the tool name, "" if none
+	Body  string // Markdown for the input, "" when the label says it all
+}
+
+// renderToolInput presents a tool call's input for reading: diffs for edits, files for
+// writes, commands for Bash, one-liners for lookups and checklists for todos. Other
+// tools, and inputs that don't have the expected shape, are shown as JSON.
+func renderToolInput(name, input string) toolView {
+	var view toolView
+	var ok bool
+	switch name {
+	case "Edit":
+		view, ok = renderEditInput(input)
+	case "MultiEdit":
+		view, ok = renderMultiEditInput(input)
+	case "Write":
+		view, ok = renderWriteInput(input)
+	case "Bash":
+		view, ok = renderBashInput(input)
+	case "Read":
+		view, ok = renderReadInput(input)
+	case "Grep":
+		view, ok = renderGrepInput(input)
+	case "Glob":
+		view, ok = renderGlobInput(input)
+	case "TodoWrite":
+		view, ok = renderTodoWriteInput(input)
+	}
+	if !ok {
+		return toolView{Body: "```json\n" + input + "\n```\n\n"}
+	}
+	return view
+}
+
+// toolLabel returns the one-line description of a tool call, or "" if it has none
+func toolLabel(name, input string) string {
+	return renderToolInput(name, input).Label
+}
+
+type editInput struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
+func renderEditInput(input string) (toolView, bool) {
+	var in struct {
+		FilePath string `json:"file_path"`
+		editInput
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.FilePath == "" {
+		return toolView{}, false
+	}
+
+	label := in.FilePath
+	if in.ReplaceAll {
+		label += " (all occurrences)"
+	}
+	diff := diffHeader(in.FilePath) + strings.Join(diffLines(in.OldString, in.NewString), "\n")
+	return toolView{Label: label, Body: codeFence("diff", diff)}, true
+}
+
+func renderMultiEditInput(input string) (toolView, bool) {
+	var in struct {
+		FilePath string      `json:"file_path"`
+		Edits    []editInput `json:"edits"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.FilePath == "" || len(in.Edits) == 0 {
+		return toolView{}, false
+	}
+
+	var diff strings.Builder
+	diff.WriteString(diffHeader(in.FilePath))
+	for i, edit := range in.Edits {
+		if len(in.Edits) > 1 {
+			diff.WriteString(fmt.Sprintf("@@ edit %d of %d @@\n", i+1, len(in.Edits)))
+		}
+		for _, line := range diffLines(edit.OldString, edit.NewString) {
+			diff.WriteString(line)
+			diff.WriteString("\n")
+		}
+	}
+	label := fmt.Sprintf("%s (%d edits)", in.FilePath, len(in.Edits))
+	if len(in.Edits) == 1 {
+		label = in.FilePath
+	}
+	return toolView{Label: label, Body: codeFence("diff", diff.String())}, true
+}
+
+func renderWriteInput(input string) (toolView, bool) {
+	var in struct {
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.FilePath == "" {
+		return toolView{}, false
+	}
+	return toolView{Label: in.FilePath, Body: codeFence(languageForPath(in.FilePath), in.Content)}, true
+}
+
+func renderBashInput(input string) (toolView, bool) {
+	var in struct {
+		Command         string `json:"command"`
+		Description     string `json:"description"`
+		RunInBackground bool   `json:"run_in_background"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.Command == "" {
+		return toolView{}, false
+	}
+
+	label := in.Description
+	if in.RunInBackground {
+		label = strings.TrimSpace(label + " (background)")
+	}
+	return toolView{Label: label, Body: codeFence("bash", in.Command)}, true
+}
+
+func renderReadInput(input string) (toolView, bool) {
+	var in struct {
+		FilePath string `json:"file_path"`
+		Offset   int    `json:"offset"`
+		Limit    int    `json:"limit"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.FilePath == "" {
+		return toolView{}, false
+	}
+
+	label := in.FilePath
+	start := max(in.Offset, 1)
+	switch {
+	case in.Limit > 0:
+		label += fmt.Sprintf(" (lines %d–%d)", start, start+in.Limit-1)
+	case in.Offset > 0:
+		label += fmt.Sprintf(" (from line %d)", start)
+	}
+	return toolView{Label: label}, true
+}
+
+func renderGrepInput(input string) (toolView, bool) {
+	var in struct {
+		Pattern         string `json:"pattern"`
+		Path            string `json:"path"`
+		Glob            string `json:"glob"`
+		Type            string `json:"type"`
+		CaseInsensitive bool   `json:"-i"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.Pattern == "" {
+		return toolView{}, false
+	}
+
+	label := fmt.Sprintf("%q", in.Pattern)
+	if in.Path != "" {
+		label += " in " + in.Path
+	}
+	var filters []string
+	if in.Glob != "" {
+		filters = append(filters, in.Glob)
+	}
+	if in.Type != "" {
+		filters = append(filters, in.Type+" files")
+	}
+	if in.CaseInsensitive {
+		filters = append(filters, "case-insensitive")
+	}
+	if len(filters) > 0 {
+		label += " (" + strings.Join(filters, ", ") + ")"
+	}
+	return toolView{Label: label}, true
+}
+
+func renderGlobInput(input string) (toolView, bool) {
+	var in struct {
+		Pattern string `json:"pattern"`
+		Path    string `json:"path"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.Pattern == "" {
+		return toolView{}, false
+	}
+
+	label := in.Pattern
+	if in.Path != "" {
+		label += " in " + in.Path
+	}
+	return toolView{Label: label}, true
+}
+
+func renderTodoWriteInput(input string) (toolView, bool) {
+	var in struct {
+		Todos []struct {
+			Content string `json:"content"`
+			Status  string `json:"status"`
+		} `json:"todos"`
+	}
+	if json.Unmarshal([]byte(input), &in) != nil || in.Todos == nil {
+		return toolView{}, false
+	}
+
+	var body strings.Builder
+	done := 0
+	for _, todo := range in.Todos {
+		text := strings.Join(strings.Fields(todo.Content), " ")
+		switch todo.Status {
+		case "completed":
+			done++
+			body.WriteString("- [x] " + text + "\n")
+		case "in_progress":
+			body.WriteString("- [ ] **" + text + "** _(in progress)_\n")
+		default:
+			body.WriteString("- [ ] " + text + "\n")
+		}
+	}
+	body.WriteString("\n")
+	return toolView{Label: fmt.Sprintf("%d of %d done", done, len(in.Todos)), Body: body.String()}, true
+}
+
+// codeFence wraps text in a fenced code block, using a fence longer than any run of
+// backticks in the text so it can't be closed early
+func codeFence(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimSuffix(text, "\n") + "\n" + fence + "\n\n"
+}
+
+// languageForPath returns the highlight.js language of a file, or "" if unknown
+func languageForPath(path string) string {
+	switch strings.ToLower(filepath.Base(path)) {
+	case "dockerfile":
+		return "dockerfile"
+	case "makefile":
+		return "makefile"
+	}
+	return fileLanguages[strings.ToLower(filepath.Ext(path))]
+}
+
+// fileLanguages maps file extensions to highlight.js languages
+var fileLanguages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".java":  "java",
+	".kt":    "kotlin",
+	".swift": "swift",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".php":   "php",
+	".sh":    "bash",
+	".bash":  "bash",
+	".zsh":   "bash",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".md":    "markdown",
+	".html":  "html",
+	".xml":   "xml",
+	".css":   "css",
+	".scss":  "scss",
+	".sql":   "sql",
+	".lua":   "lua",
+}
+
+// diffHeader returns the file header of a unified diff
+func diffHeader(path string) string {
+	return fmt.Sprintf("--- %s\n+++ %s\n", path, path)
+}
+
+// maxDiffCells bounds the work of diffLines; larger edits are shown as a removal
+// followed by an addition
+const maxDiffCells = 250000
+
+// diffLines returns a line diff from oldText to newText, each line prefixed with
+// " " when kept, "-" when removed or "+" when added
+func diffLines(oldText, newText string) []string {
+	a, b := splitLines(oldText), splitLines(newText)
+	var out []string
+
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			out = append(out, "-"+line)
+		}
+		for _, line := range b {
+			out = append(out, "+"+line)
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, " "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			out = append(out, "+"+b[j])
+			j++
+		default:
+			out = append(out, "-"+a[i])
+			i++
+		}
+	}
+	return out
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,165 @@
+package main
+
+// ConversationTree is a session's messages arranged by parentUuid: the main path
+// from the root to the active leaf, and the alternate branches left behind by
+// edited prompts and rewinds
+type ConversationTree struct {
+	Main     []Message
+	Branches []Branch
+
+	// Forks maps a message UUID to the indices in Branches of the branches
+	// diverging after it
+	Forks map[string][]int
+}
+
+// Branch is an alternate path through the conversation
+type Branch struct {
+	Number   int       // 1-based branch number, in discovery order
+	ForkUUID string    // Message the branch diverges after
+	Messages []Message // Messages from the fork to the branch leaf
+}
+
+// bridgeEntry records a skipped entry so messages parented to it thread through to
+// its own parent. Compaction boundaries have no parent but a logical one.
+func bridgeEntry(bridges map[string]string, entry *jsonlEntry) {
+	if entry.UUID == "" {
+		return
+	}
+	parent := entry.LogicalParentUUID
+	if entry.ParentUUID != nil && *entry.ParentUUID != "" {
+		parent = *entry.ParentUUID
+	}
+	bridges[entry.UUID] = parent
+}
+
+// resolveParent follows bridges until it reaches a parent that is not a skipped entry
+func resolveParent(bridges map[string]string, parent string) string {
+	for i := 0; i < len(bridges); i++ {
+		next, ok := bridges[parent]
+		if !ok {
+			break
+		}
+		parent = next
+	}
+	return parent
+}
+
+// BuildConversationTree arranges a session's messages into a tree. Messages without
+// a known parent earlier in the file continue from the message before them, so no
+// content is dropped when a chain is broken and parentUuid cycles can't form. The
+// main path ends at the session's ActiveLeaf, or at the last leaf written to the file.
+func BuildConversationTree(session *Session) *ConversationTree {
+	tree := &ConversationTree{Forks: make(map[string][]int)}
+	n := len(session.Messages)
+	if n == 0 {
+		return tree
+	}
+
+	// Index messages; the first occurrence of a repeated UUID wins
+	index := make(map[string]int, n)
+	for i, msg := range session.Messages {
+		if msg.UUID == "" {
+			continue
+		}
+		if _, ok := index[msg.UUID]; !ok {
+			index[msg.UUID] = i
+		}
+	}
+
+	parent := make([]int, n)
+	children := make([][]int, n)
+	for i, msg := range session.Messages {
+		parent[i] = -1
+		if msg.UUID != "" && index[msg.UUID] != i {
+			continue // Duplicate entry
+		}
+		p, ok := index[msg.ParentUUID]
+		if !ok || p >= i {
+			p = i - 1
+			for p >= 0 && session.Messages[p].UUID != "" && index[session.Messages[p].UUID] != p {
+				p--
+			}
+		}
+		if p >= 0 {
+			parent[i] = p
+			children[p] = append(children[p], i)
+		}
+	}
+
+	// Active leaf: from a summary entry (continued to its latest descendant if the
+	// conversation went on after the summary), else the last leaf in file order
+	leaf := -1
+	if i, ok := index[session.ActiveLeaf]; ok && session.ActiveLeaf != "" {
+		for len(children[i]) > 0 {
+			i = children[i][len(children[i])-1]
+		}
+		leaf = i
+	}
+	for i := n - 1; leaf < 0 && i >= 0; i-- {
+		msg := session.Messages[i]
+		if len(children[i]) == 0 && (msg.UUID == "" || index[msg.UUID] == i) {
+			leaf = i
+		}
+	}
+
+	var mainPath []int
+	for i := leaf; i >= 0; i = parent[i] {
+		mainPath = append([]int{i}, mainPath...)
+	}
+
+	// Walk each path; children not taken start branches of their own, followed
+	// to their most recent leaf
+	onPath := make(map[int]bool, n)
+	for _, i := range mainPath {
+		onPath[i] = true
+	}
+	type pending struct{ fork, start int }
+	var queue []pending
+	enqueue := func(path []int) {
+		for _, i := range path {
+			for _, c := range children[i] {
+				if !onPath[c] {
+					queue = append(queue, pending{fork: i, start: c})
+				}
+			}
+		}
+	}
+
+	tree.Main = pickMessages(session.Messages, mainPath)
+	enqueue(mainPath)
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		var path []int
+		for i := next.start; ; {
+			path = append(path, i)
+			onPath[i] = true
+			if len(children[i]) == 0 {
+				break
+			}
+			i = children[i][len(children[i])-1]
+		}
+
+		forkUUID := session.Messages[next.fork].UUID
+		tree.Forks[forkUUID] = append(tree.Forks[forkUUID], len(tree.Branches))
+		tree.Branches = append(tree.Branches, Branch{
+			Number:   len(tree.Branches) + 1,
+			ForkUUID: forkUUID,
+			Messages: pickMessages(session.Messages, path),
+		})
+		enqueue(path)
+	}
+
+	return tree
+}
+
+// pickMessages returns the messages at the given indices
+func pickMessages(messages []Message, indices []int) []Message {
+	result := make([]Message, len(indices))
+	for i, idx := range indices {
+		result[i] = messages[idx]
+	}
+	return result
+}
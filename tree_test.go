@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func uuids(messages []Message) []string {
+	result := make([]string, len(messages))
+	for i, msg := range messages {
+		result[i] = msg.UUID
+	}
+	return result
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildConversationTree_EditedPrompt(t *testing.T) {
+	// u2 was edited into u2b; the conversation continued from the edit
+	session := &Session{
+		Messages: []Message{
+			{UUID: "u1", Role: "user"},
+			{UUID: "a1", ParentUUID: "u1", Role: "assistant"},
+			{UUID: "u2", ParentUUID: "a1", Role: "user"},
+			{UUID: "a2", ParentUUID: "u2", Role: "assistant"},
+			{UUID: "u2b", ParentUUID: "a1", Role: "user"},
+			{UUID: "a2b", ParentUUID: "u2b", Role: "assistant"},
+		},
+	}
+
+	tree := BuildConversationTree(session)
+
+	if got := uuids(tree.Main); !equalStrings(got, []string{"u1", "a1", "u2b", "a2b"}) {
+		t.Errorf("Main path = %v, want [u1 a1 u2b a2b]", got)
+	}
+	if len(tree.Branches) != 1 {
+		t.Fatalf("Expected 1 branch, got %d", len(tree.Branches))
+	}
+	branch := tree.Branches[0]
+	if branch.Number != 1 || branch.ForkUUID != "a1" {
+		t.Errorf("Branch = #%d forked at %q, want #1 at a1", branch.Number, branch.ForkUUID)
+	}
+	if got := uuids(branch.Messages); !equalStrings(got, []string{"u2", "a2"}) {
+		t.Errorf("Branch messages = %v, want [u2 a2]", got)
+	}
+	if got := tree.Forks["a1"]; len(got) != 1 || got[0] != 0 {
+		t.Errorf("Forks[a1] = %v, want [0]", got)
+	}
+}
+
+func TestBuildConversationTree_ActiveLeaf(t *testing.T) {
+	session := &Session{
+		ActiveLeaf: "a2",
+		Messages: []Message{
+			{UUID: "u1", Role: "user"},
+			{UUID: "u2", ParentUUID: "u1", Role: "user"},
+			{UUID: "a2", ParentUUID: "u2", Role: "assistant"},
+			{UUID: "u2b", ParentUUID: "u1", Role: "user"},
+		},
+	}
+
+	tree := BuildConversationTree(session)
+
+	if got := uuids(tree.Main); !equalStrings(got, []string{"u1", "u2", "a2"}) {
+		t.Errorf("Main path = %v, want summary leaf path [u1 u2 a2]", got)
+	}
+	if len(tree.Branches) != 1 || tree.Branches[0].Messages[0].UUID != "u2b" {
+		t.Errorf("Expected u2b as the only branch, got %+v", tree.Branches)
+	}
+}
+
+func TestBuildConversationTree_Linear(t *testing.T) {
+	// Messages without UUIDs or with unknown parents continue in file order
+	session := &Session{
+		Messages: []Message{
+			{Role: "user"},
+			{Role: "assistant"},
+			{UUID: "u2", ParentUUID: "missing", Role: "user"},
+			{UUID: "a2", ParentUUID: "u2", Role: "assistant"},
+		},
+	}
+
+	tree := BuildConversationTree(session)
+
+	if len(tree.Main) != 4 {
+		t.Errorf("Expected all 4 messages on the main path, got %d", len(tree.Main))
+	}
+	if len(tree.Branches) != 0 {
+		t.Errorf("Expected no branches, got %d", len(tree.Branches))
+	}
+}
+
+func TestBuildConversationTree_ParentCycle(t *testing.T) {
+	// A malformed file where a and b are each other's parent; a parent later in the file
+	// is ignored, so the cycle is broken where it closes
+	session := &Session{
+		ActiveLeaf: "a",
+		Messages: []Message{
+			{UUID: "a", ParentUUID: "b", Role: "user"},
+			{UUID: "b", ParentUUID: "a", Role: "assistant"},
+			{UUID: "c", ParentUUID: "b", Role: "user"},
+		},
+	}
+
+	tree := BuildConversationTree(session)
+
+	if got := uuids(tree.Main); !equalStrings(got, []string{"a", "b", "c"}) {
+		t.Errorf("Main path = %v, want [a b c]", got)
+	}
+	if len(tree.Branches) != 0 {
+		t.Errorf("Expected no branches, got %d", len(tree.Branches))
+	}
+}
+
+func TestParseSession_BridgesSkippedEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "test-session.jsonl")
+
+	// The assistant reply is parented to an entry type that isn't parsed, and the
+	// post-compaction prompt to a boundary whose logical parent is the reply
+	jsonlContent := `{"type":"user","uuid":"u1","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"Hi"}}
+{"type":"progress","uuid":"p1","parentUuid":"u1","timestamp":"2025-12-29T10:00:01.000Z"}
+{"type":"assistant","uuid":"a1","parentUuid":"p1","timestamp":"2025-12-29T10:00:02.000Z","message":{"role":"assistant","content":[{"type":"text","text":"Hello"}]}}
+{"type":"system","subtype":"compact_boundary","uuid":"c1","parentUuid":null,"logicalParentUuid":"a1","timestamp":"2025-12-29T10:00:03.000Z","content":"Conversation compacted","compactMetadata":{"trigger":"auto","preTokens":155000}}
+{"type":"user","uuid":"u2","parentUuid":"c1","timestamp":"2025-12-29T10:00:04.000Z","message":{"role":"user","content":"Continue"}}
+{"type":"summary","summary":"Greeting","leafUuid":"u2"}
+`
+
+	if err := os.WriteFile(sessionPath, []byte(jsonlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test JSONL file: %v", err)
+	}
+
+	session, err := ParseSession(sessionPath, "test-session")
+	if err != nil {
+		t.Fatalf("ParseSession failed: %v", err)
+	}
+
+	if len(session.Messages) != 4 {
+		t.Fatalf("Expected 4 messages including the compact boundary, got %d", len(session.Messages))
+	}
+	if session.Messages[1].ParentUUID != "u1" {
+		t.Errorf("a1 parent = %q, want u1", session.Messages[1].ParentUUID)
+	}
+	if session.Messages[2].ParentUUID != "a1" {
+		t.Errorf("c1 parent = %q, want a1", session.Messages[2].ParentUUID)
+	}
+	if session.Messages[3].ParentUUID != "c1" {
+		t.Errorf("u2 parent = %q, want c1", session.Messages[3].ParentUUID)
+	}
+	if session.ActiveLeaf != "u2" {
+		t.Errorf("ActiveLeaf = %q, want u2", session.ActiveLeaf)
+	}
+
+	tree := BuildConversationTree(session)
+	if len(tree.Main) != 4 || len(tree.Branches) != 0 {
+		t.Errorf("Expected a linear path through the boundary, got %d messages and %d branches",
+			len(tree.Main), len(tree.Branches))
+	}
+}
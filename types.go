@@ -10,36 +10,110 @@ type Project struct {
 	Path       string    // Decoded path: /Users/name/project
 	FolderName string    // Encoded folder name: -Users-name-project
 	Sessions   []Session // Sessions in this project
+	// Sessions whose file is gone, kept from their Markdown by the archive orphan policy
+	ArchivedSessions []Session
+	Source           string // Name of the source the project was found in
+	SourceDir        string // Directory of that source, holding FolderName
+
+	slug string // Unique among the loaded projects; see Slug
 }
 
 // Session represents a single chat session
 type Session struct {
 	ID         string    // UUID from filename
 	Summary    string    // Session title from summary entry
-	Messages   []Message // All messages in the session
+	Messages   []Message // All messages in the session (not loaded by LoadSessionMeta)
 	CreatedAt  time.Time // First message timestamp
 	UpdatedAt  time.Time // Last message timestamp
 	SourcePath string    // Full path to source JSONL file
 	CWD        string    // Working directory from JSONL (actual project path)
+	ActiveLeaf string    // UUID of the current conversation leaf from a summary entry ("" if none)
+	LeafRefs   []string  // Summary leafUuids pointing outside this session (resumed sessions)
+
+	// Claude Code environment, as of the last message that reported it
+	GitBranch      string   // Checked-out git branch ("" outside a repository)
+	GitBranches    []string // Every branch the session ran on, in order of first use
+	Version        string   // Claude Code version
+	PermissionMode string   // Permission mode, e.g. "default", "acceptEdits", "plan"
+	UserType       string   // "external" for regular users
+
+	// Resume/continue lineage: sessions of one logical conversation
+	PredecessorID  string   // Session this one resumes ("" if none)
+	SuccessorIDs   []string // Sessions resuming this one
+	ConversationID string   // ID of the first session of the conversation
+
+	// Tool calls joined with their results, in call order
+	ToolCalls []ToolCall
+
+	// Counts and link data summarizing the messages, also set when they aren't loaded
+	Meta SessionMeta
+
+	// Subagent (sidechain) transcripts spawned by Task calls in this session
+	Subagents []Session
+
+	// Set on subagent transcripts only
+	AgentID         string // Subagent ID from the transcript entries
+	ParentSessionID string // Session that spawned the subagent
+	ParentToolUseID string // Task tool_use that spawned the subagent ("" if unknown)
+
+	// Problems met while parsing the session file (nil if none)
+	Diagnostics *ParseDiagnostics
+
+	// The session's file is gone: loaded from its archived copy (see Archive), or read
+	// back from its Markdown (see OrphanArchive)
+	Archived bool
 }
 
 // Message represents a single message in a session
 type Message struct {
 	UUID       string         // Message UUID
-	ParentUUID string         // Parent message UUID for threading
+	ParentUUID string         // Parent message UUID for threading (skipped entries are bridged)
 	Role       string         // "user" or "assistant"
 	Content    []ContentBlock // Content blocks
 	Timestamp  time.Time      // Message timestamp
+	Model      string         // Model ID that produced the message (assistant messages only)
+	Usage      *Usage         // Token usage reported by the API (assistant messages only)
+	ResponseID string         // API message ID, shared by entries split from one response
+	Sidechain  bool           // Message belongs to a subagent transcript
+	Event      *SystemEvent   // Set for system events (Role "system"): compactions, commands, hooks, notices
+
+	// Environment when the message was written; changes mid-session (branch switch, mode toggle)
+	GitBranch      string
+	Version        string
+	PermissionMode string
+	UserType       string
+}
+
+// Usage holds the token counts reported by the API for a single response
+type Usage struct {
+	InputTokens                int64 // Uncached input tokens
+	OutputTokens               int64 // Generated output tokens
+	CacheCreationInputTokens   int64 // Input tokens written to the prompt cache (all TTLs)
+	CacheCreation1hInputTokens int64 // Part of CacheCreationInputTokens written with the 1 hour TTL
+	CacheReadInputTokens       int64 // Input tokens served from the prompt cache
+}
+
+// Total returns the sum of all token counts
+func (u Usage) Total() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 }
 
 // ContentBlock represents a block of content within a message
 type ContentBlock struct {
-	Type       string // "text", "tool_use", "tool_result"
-	Text       string // For text blocks
+	Type       string // "text", "tool_use", "tool_result", "thinking", "redacted_thinking", "image", "document"
+	Text       string // For text blocks, and the reasoning of thinking blocks
 	ToolName   string // For tool_use blocks
 	ToolInput  string // JSON string of tool input
 	ToolUseID  string // For tool_result blocks
 	ToolOutput string // For tool_result blocks
+	MediaType  string // For image/document blocks, e.g. "image/png"
+	Data       []byte // Decoded image/document content
+	SourceURL  string // For image/document blocks referenced by URL instead of inline data
+	Title      string // Optional document title
+	AgentID    string // For tool_result blocks of Task calls: the subagent that ran
+	IsError    bool   // For tool_result blocks: the tool call failed
+
+	Parts []ContentBlock // For tool_result blocks: image and document parts of the output
 }
 
 // jsonlEntry represents a raw JSONL line (used for initial parsing)
@@ -52,21 +126,70 @@ type jsonlEntry struct {
 	Timestamp  string          `json:"timestamp,omitempty"`
 	Message    json.RawMessage `json:"message,omitempty"`
 	CWD        string          `json:"cwd,omitempty"` // Working directory (actual project path)
+
+	IsSidechain       bool            `json:"isSidechain,omitempty"`       // Entry belongs to a subagent transcript
+	AgentID           string          `json:"agentId,omitempty"`           // Subagent ID (sidechain entries)
+	SessionID         string          `json:"sessionId,omitempty"`         // Session the entry belongs to
+	ToolUseResult     json.RawMessage `json:"toolUseResult,omitempty"`     // Structured tool result metadata
+	LogicalParentUUID string          `json:"logicalParentUuid,omitempty"` // Parent across a compaction boundary
+
+	Subtype         string              `json:"subtype,omitempty"`         // System entry subtype, e.g. "compact_boundary"
+	Level           string              `json:"level,omitempty"`           // System entry level: "info", "warning", "error"
+	Content         json.RawMessage     `json:"content,omitempty"`         // System entry text
+	CompactMetadata *rawCompactMetadata `json:"compactMetadata,omitempty"` // Compact boundary details
+
+	GitBranch      string `json:"gitBranch,omitempty"`      // Checked-out git branch
+	Version        string `json:"version,omitempty"`        // Claude Code version
+	PermissionMode string `json:"permissionMode,omitempty"` // Permission mode (user entries)
+	UserType       string `json:"userType,omitempty"`       // "external" for regular users
 }
 
 // messageContent represents the message field in a JSONL entry
 type messageContent struct {
-	Role    string            `json:"role"`
-	Content json.RawMessage   `json:"content"`
+	ID      string          `json:"id,omitempty"`
+	Model   string          `json:"model,omitempty"`
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+	Usage   *rawUsage       `json:"usage,omitempty"`
+}
+
+// rawUsage represents the usage field of an assistant message in JSONL
+type rawUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	CacheCreation            *struct {
+		Ephemeral5mInputTokens int64 `json:"ephemeral_5m_input_tokens"`
+		Ephemeral1hInputTokens int64 `json:"ephemeral_1h_input_tokens"`
+	} `json:"cache_creation,omitempty"`
 }
 
 // rawContentBlock represents a content block as it appears in JSONL
 type rawContentBlock struct {
 	Type      string          `json:"type"`
 	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Source    *rawMediaSource `json:"source,omitempty"`
+	Title     string          `json:"title,omitempty"`
 	ID        string          `json:"id,omitempty"`
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
 	ToolUseID string          `json:"tool_use_id,omitempty"`
 	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// rawCompactMetadata represents the compactMetadata field of a compact boundary entry
+type rawCompactMetadata struct {
+	Trigger   string `json:"trigger"` // "auto" or "manual"
+	PreTokens int64  `json:"preTokens"`
+}
+
+// rawMediaSource represents the source of an image or document block
+type rawMediaSource struct {
+	Type      string `json:"type"` // "base64", "text" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
@@ -14,11 +14,23 @@ import (
 
 // WatchConfig configures the file watcher
 type WatchConfig struct {
-	SourceDir        string        // Claude projects directory
+	SourceDir        string        // Claude projects directory (used when Sources is empty)
+	Sources          []Source      // Claude projects directories to watch
 	OutputDir        string        // HTML output directory
 	PollInterval     time.Duration // Interval for scanning new directories
 	DebounceDelay    time.Duration // Delay before regenerating after changes
 	SelectedProjects []string      // Project folder names to watch (nil = all projects)
+	Pricing          *PriceTable   // Prices for generated Markdown (nil = built-in prices)
+	Jobs             int           // Session files parsed at once when reloading (0 = number of CPUs)
+	Cache            *ParseCache   // Metadata of unchanged session files (nil = kept in memory)
+	Strict           bool          // Skip regenerating when a session file has parse problems
+	Projects         []Project     // Projects already loaded and generated, e.g. at startup (nil = load all on the first change)
+	Orphans          OrphanPolicy  // What to do with the Markdown of removed sessions ("" = keep)
+	Archive          *Archive      // Archives changed session files and loads removed ones from it (nil = none)
+
+	// Called with the reloaded projects (the selected ones) after they are regenerated,
+	// e.g. Server.Reload (nil = none)
+	OnReload func(projects []Project)
 }
 
 // DefaultWatchConfig returns the default watcher configuration
@@ -29,6 +41,26 @@ func DefaultWatchConfig() WatchConfig {
 	}
 }
 
+// roots returns the projects directories to watch
+func (c WatchConfig) roots() []string {
+	if len(c.Sources) == 0 {
+		return []string{c.SourceDir}
+	}
+	roots := make([]string, len(c.Sources))
+	for i, source := range c.Sources {
+		roots[i] = source.Path
+	}
+	return roots
+}
+
+// sources returns the sources to load projects from
+func (c WatchConfig) sources() []Source {
+	if len(c.Sources) == 0 {
+		return []Source{{Path: c.SourceDir}}
+	}
+	return c.Sources
+}
+
 // Watcher monitors for file changes and triggers regeneration
 type Watcher struct {
 	config    WatchConfig
@@ -79,7 +111,7 @@ func (w *Watcher) Watch(ctx context.Context) error {
 	if w.config.SelectedProjects != nil {
 		fmt.Printf("Watching %d selected projects for changes\n", len(w.config.SelectedProjects))
 	} else {
-		fmt.Printf("Watching for changes in %s\n", w.config.SourceDir)
+		fmt.Printf("Watching for changes in %s\n", strings.Join(w.config.roots(), ", "))
 	}
 	logVerbose("Poll interval: %v, Debounce delay: %v", w.config.PollInterval, w.config.DebounceDelay)
 
@@ -112,15 +144,32 @@ func (w *Watcher) Watch(ctx context.Context) error {
 	}
 }
 
-// addWatches adds watches for the source directory and all project subdirectories
+// addWatches adds watches for the source directories and all project subdirectories.
+// A source that can't be watched is skipped with a warning, unless none can.
 func (w *Watcher) addWatches() error {
+	roots := w.config.roots()
+	var failed int
+	for _, root := range roots {
+		if err := w.addRootWatches(root); err != nil {
+			failed++
+			if failed == len(roots) {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: failed to watch %s: %v\n", root, err)
+		}
+	}
+	return nil
+}
+
+// addRootWatches watches a source directory and its project subdirectories
+func (w *Watcher) addRootWatches(root string) error {
 	// Watch the root projects directory (for new projects)
-	if err := w.fsWatcher.Add(w.config.SourceDir); err != nil {
+	if err := w.fsWatcher.Add(root); err != nil {
 		return fmt.Errorf("watching source directory: %w", err)
 	}
 
 	// Watch each project subdirectory
-	entries, err := os.ReadDir(w.config.SourceDir)
+	entries, err := os.ReadDir(root)
 	if err != nil {
 		return fmt.Errorf("reading source directory: %w", err)
 	}
@@ -135,16 +184,60 @@ func (w *Watcher) addWatches() error {
 			continue
 		}
 
-		projectPath := filepath.Join(w.config.SourceDir, entry.Name())
+		projectPath := filepath.Join(root, entry.Name())
 		if err := w.fsWatcher.Add(projectPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to watch %s: %v\n", projectPath, err)
 			continue
 		}
+		w.addSubagentWatches(projectPath)
 	}
 
 	return nil
 }
 
+// addSubagentWatches watches the <session>/subagents directories of a project,
+// where newer Claude Code versions write subagent transcripts
+func (w *Watcher) addSubagentWatches(projectPath string) {
+	entries, err := os.ReadDir(projectPath)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		sessionDir := filepath.Join(projectPath, entry.Name())
+		subagentDir := filepath.Join(sessionDir, "subagents")
+		if info, err := os.Stat(subagentDir); err != nil || !info.IsDir() {
+			continue
+		}
+		w.fsWatcher.Add(sessionDir)
+		w.fsWatcher.Add(subagentDir)
+	}
+}
+
+// projectFolderOf returns the project folder containing a path below a source directory
+func (w *Watcher) projectFolderOf(path string) string {
+	for _, root := range w.config.roots() {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return strings.Split(rel, string(filepath.Separator))[0]
+	}
+	return filepath.Base(filepath.Dir(path))
+}
+
+// isRoot reports whether dir is one of the watched source directories
+func (w *Watcher) isRoot(dir string) bool {
+	for _, root := range w.config.roots() {
+		if filepath.Clean(root) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
 // isProjectSelected returns true if the project should be watched
 // Returns true if no filter is set (SelectedProjects is nil) or if the project is in the filter
 func (w *Watcher) isProjectSelected(projectFolder string) bool {
@@ -166,33 +259,35 @@ func (w *Watcher) scanForNewDirectories() {
 		return
 	}
 
-	entries, err := os.ReadDir(w.config.SourceDir)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to scan for new directories: %v\n", err)
-		return
-	}
-
 	watchList := w.fsWatcher.WatchList()
 	watchSet := make(map[string]bool)
 	for _, path := range watchList {
 		watchSet[path] = true
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+	for _, root := range w.config.roots() {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to scan for new directories: %v\n", err)
 			continue
 		}
 
-		projectPath := filepath.Join(w.config.SourceDir, entry.Name())
-		if !watchSet[projectPath] {
-			if err := w.fsWatcher.Add(projectPath); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to watch new directory %s: %v\n", projectPath, err)
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
 				continue
 			}
-			fmt.Printf("Now watching new project: %s\n", entry.Name())
 
-			// Trigger regeneration for the new project
-			w.scheduleRegeneration(entry.Name())
+			projectPath := filepath.Join(root, entry.Name())
+			if !watchSet[projectPath] {
+				if err := w.fsWatcher.Add(projectPath); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to watch new directory %s: %v\n", projectPath, err)
+					continue
+				}
+				fmt.Printf("Now watching new project: %s\n", entry.Name())
+
+				// Trigger regeneration for the new project
+				w.scheduleRegeneration(entry.Name())
+			}
 		}
 	}
 }
@@ -205,6 +300,13 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 		if event.Op&fsnotify.Create != 0 && w.config.SelectedProjects == nil {
 			info, err := os.Stat(event.Name)
 			if err == nil && info.IsDir() {
+				if !w.isRoot(filepath.Dir(event.Name)) {
+					// Session or subagents directory inside a project
+					if err := w.fsWatcher.Add(event.Name); err == nil {
+						w.scheduleRegeneration(w.projectFolderOf(event.Name))
+					}
+					return
+				}
 				// New project directory - add watch
 				if err := w.fsWatcher.Add(event.Name); err == nil {
 					projectFolder := filepath.Base(event.Name)
@@ -218,8 +320,8 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 
 	// Extract project folder from path
 	// Path format: /path/to/projects/-Project-Name/session.jsonl
-	dir := filepath.Dir(event.Name)
-	projectFolder := filepath.Base(dir)
+	// or /path/to/projects/-Project-Name/<session>/subagents/agent-<id>.jsonl
+	projectFolder := w.projectFolderOf(event.Name)
 
 	// Skip if project is not in our selection
 	if !w.isProjectSelected(projectFolder) {
@@ -291,33 +393,188 @@ func StartWatcher(ctx context.Context, config WatchConfig) error {
 	defer watcher.Close()
 
 	// Set up regeneration callback
-	watcher.SetRegenerateCallback(func(projectFolder string) error {
-		return regenerateProject(config.SourceDir, config.OutputDir, projectFolder)
-	})
+	watcher.SetRegenerateCallback(newProjectReloader(config).regenerate)
 
 	return watcher.Watch(ctx)
 }
 
-// regenerateProject reloads a project and regenerates its Markdown files
-func regenerateProject(sourceDir, outputDir, projectFolder string) error {
-	_ = projectFolder // Currently regenerates all projects; mtime check handles efficiency
+// projectReloader keeps the projects the watcher regenerates, so that a change reloads
+// only the project it happened in: its changed session files are parsed, and the other
+// projects keep the sessions loaded before.
+type projectReloader struct {
+	config WatchConfig
+	cache  *ParseCache // config.Cache, or one kept in memory
+
+	mu            sync.Mutex // Regenerations of different projects can be due at once
+	projects      []Project  // The selected projects, as last loaded
+	loaded        bool
+	archiveTimers map[string]*time.Timer // Project folder ("" = all) -> archive sync once quiet
+}
 
-	// Load all projects (needed for index files)
-	allProjects, err := LoadAllProjects(sourceDir)
-	if err != nil {
-		return fmt.Errorf("loading all projects: %w", err)
+// archiveQuietDelay is how long a growing session file must go unwritten before watch
+// mode archives it again, so a session being written isn't compressed on every message
+const archiveQuietDelay = time.Minute
+
+func newProjectReloader(config WatchConfig) *projectReloader {
+	r := &projectReloader{config: config, cache: config.Cache, archiveTimers: make(map[string]*time.Timer)}
+	if r.cache == nil {
+		r.cache = newMemoryParseCache()
 	}
+	if config.Projects != nil {
+		r.projects = config.Projects
+		r.loaded = true
+	}
+	return r
+}
 
-	// Generate markdown (with force=false for incremental updates)
-	result, err := GenerateAllMarkdown(allProjects, outputDir, sourceDir, false)
-	if err != nil {
-		return fmt.Errorf("generating markdown: %w", err)
+// regenerate reloads a project and regenerates its Markdown files and the main index.
+// All projects are loaded on the first call, unless they were given in the config.
+func (r *projectReloader) regenerate(projectFolder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	opts := LoadOptions{Jobs: r.config.Jobs, Cache: r.cache, Strict: r.config.Strict, Archive: r.config.Archive}
+	if r.config.Archive != nil {
+		// Archive the change before loading, so a session whose file is then gone still loads
+		folders := []string{projectFolder}
+		if !r.loaded {
+			folders = nil
+		}
+		r.syncArchive(folders)
+	}
+	gen := NewMarkdownGenerator(r.config.OutputDir, r.config.sources()[0].Path, false)
+	gen.sourceDirs = sourcePaths(r.config.sources())
+	if r.config.Pricing != nil {
+		gen.pricing = r.config.Pricing
 	}
+	gen.cache = r.cache
+	gen.orphans = r.config.Orphans
+
+	var projects []Project
+	var result *GenerationResult
+	if !r.loaded {
+		all, err := LoadAllProjectMetasFrom(r.config.sources(), opts)
+		if err != nil {
+			return fmt.Errorf("loading all projects: %w", err)
+		}
+		projects = filterProjects(all, r.config.SelectedProjects)
+		if result, err = gen.GenerateAll(projects); err != nil {
+			return fmt.Errorf("generating markdown: %w", err)
+		}
+	} else {
+		var err error
+		if projects, err = r.reload(projectFolder, opts); err != nil {
+			return err
+		}
+		if result, err = gen.GenerateProject(projects, projectFolder); err != nil {
+			return fmt.Errorf("generating markdown: %w", err)
+		}
+	}
+	r.projects = projects
+	r.loaded = true
 
 	fmt.Printf("Regenerated: %d generated, %d skipped\n", result.Generated, result.Skipped)
+	if r.config.OnReload != nil {
+		r.config.OnReload(result.withArchivedProjects(projects))
+	}
+	if r.config.Cache != nil {
+		if err := r.config.Cache.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save parse cache: %v\n", err)
+		}
+	}
 	return nil
 }
 
+// reload returns the kept projects with those in projectFolder loaded again from every
+// source holding it. A project whose path didn't change keeps its slug.
+func (r *projectReloader) reload(projectFolder string, opts LoadOptions) ([]Project, error) {
+	var reloaded []Project
+	for _, source := range r.config.sources() {
+		info, err := os.Stat(filepath.Join(source.Path, projectFolder))
+		if (err != nil || !info.IsDir() || !isProjectFolder(projectFolder)) && !opts.Archive.hasProject(source, projectFolder) {
+			continue // Removed, or not a project
+		}
+		reloaded = append(reloaded, Project{
+			FolderName: projectFolder,
+			Path:       resolveProjectPath(projectFolder, nil),
+			Source:     source.Name,
+			SourceDir:  source.Path,
+		})
+	}
+
+	warnings := loadProjectSessions(reloaded, r.cache.LoadSessionMeta, opts)
+	if opts.Strict {
+		if err := strictError(CollectDiagnostics(reloaded)); err != nil {
+			return nil, err
+		}
+	}
+	printLoadWarnings(warnings)
+
+	previous := make(map[string]Project)
+	projects := make([]Project, 0, len(r.projects)+len(reloaded))
+	for _, p := range r.projects {
+		if p.FolderName == projectFolder {
+			previous[p.SourceDir] = p
+			continue
+		}
+		projects = append(projects, p)
+	}
+	for _, p := range reloaded {
+		if old, ok := previous[p.SourceDir]; ok && old.Path == p.Path {
+			p.slug = old.slug
+		}
+		projects = append(projects, p)
+	}
+	assignProjectSlugs(projects)
+	sortProjectsByUpdate(projects)
+	return projects, nil
+}
+
+// syncArchive archives the changed session files of the given project folders (all if
+// nil) and saves the archive manifest. Files still growing are archived by another sync
+// once they have gone archiveQuietDelay without a change. The caller holds r.mu.
+func (r *projectReloader) syncArchive(folders []string) {
+	result := r.config.Archive.Sync(r.config.sources(), folders, archiveQuietDelay)
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if err := r.config.Archive.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save archive: %v\n", err)
+	}
+
+	key := strings.Join(folders, "/")
+	if timer, ok := r.archiveTimers[key]; ok {
+		timer.Stop()
+		delete(r.archiveTimers, key)
+	}
+	if result.Deferred > 0 {
+		r.archiveTimers[key] = time.AfterFunc(archiveQuietDelay, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.syncArchive(folders)
+		})
+	}
+}
+
+// filterProjects returns the projects in the given folders, or all of them if folders
+// is nil
+func filterProjects(projects []Project, folders []string) []Project {
+	if folders == nil {
+		return projects
+	}
+	selected := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		selected[folder] = true
+	}
+	var kept []Project
+	for _, project := range projects {
+		if selected[project.FolderName] {
+			kept = append(kept, project)
+		}
+	}
+	return kept
+}
+
 // WatchInBackground starts the watcher in a background goroutine
 // Returns a cancel function to stop the watcher
 func WatchInBackground(config WatchConfig) (context.CancelFunc, error) {
@@ -327,9 +584,7 @@ func WatchInBackground(config WatchConfig) (context.CancelFunc, error) {
 	}
 
 	// Set up regeneration callback
-	watcher.SetRegenerateCallback(func(projectFolder string) error {
-		return regenerateProject(config.SourceDir, config.OutputDir, projectFolder)
-	})
+	watcher.SetRegenerateCallback(newProjectReloader(config).regenerate)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
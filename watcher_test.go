@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -437,3 +438,128 @@ func TestWatchInBackground(t *testing.T) {
 		t.Error("cancel() blocked for too long")
 	}
 }
+
+func TestWatcherMultipleSources(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	projectDir := filepath.Join(second, "-backup-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("failed to create project dir: %v", err)
+	}
+
+	config := WatchConfig{
+		Sources:       []Source{{Name: "main", Path: first}, {Name: "backup", Path: second}},
+		OutputDir:     t.TempDir(),
+		PollInterval:  10 * time.Second,
+		DebounceDelay: 100 * time.Millisecond,
+	}
+
+	watcher, err := NewWatcher(config)
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	var mu sync.Mutex
+	var folders []string
+	watcher.SetRegenerateCallback(func(projectFolder string) error {
+		mu.Lock()
+		folders = append(folders, projectFolder)
+		mu.Unlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Watch(ctx)
+	time.Sleep(200 * time.Millisecond)
+
+	// A session written in the second root is picked up with its project folder
+	testFile := filepath.Join(projectDir, "test-session.jsonl")
+	if err := os.WriteFile(testFile, []byte(`{"type":"summary","summary":"test"}`), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(folders) != 1 || folders[0] != "-backup-project" {
+		t.Errorf("Regenerated %v, want [-backup-project]", folders)
+	}
+}
+
+func TestProjectReloaderRegeneratesOnlyChangedProject(t *testing.T) {
+	sourceDir := t.TempDir()
+	outputDir := t.TempDir()
+	line := func(uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","timestamp":"2025-12-29T10:00:00.000Z","message":{"role":"user","content":"` + text + `"}}` + "\n"
+	}
+	files := map[string]string{
+		"-work-active/a1.jsonl": line("u1", "first"),
+		"-work-active/a2.jsonl": line("u2", "untouched"),
+		"-work-idle/b1.jsonl":   line("u3", "idle"),
+	}
+	for name, content := range files {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	var reloaded []Project
+	reloader := newProjectReloader(WatchConfig{
+		SourceDir: sourceDir,
+		OutputDir: outputDir,
+		OnReload:  func(projects []Project) { reloaded = projects },
+	})
+
+	// The first change loads and generates everything
+	if err := reloader.regenerate("-work-active"); err != nil {
+		t.Fatalf("regenerate failed: %v", err)
+	}
+	if got := reloader.cache.metaMisses; got != 3 {
+		t.Fatalf("First regeneration parsed %d files, want 3", got)
+	}
+	idleMD := filepath.Join(outputDir, "work-idle", "b1.md")
+	idleInfo, err := os.Stat(idleMD)
+	if err != nil {
+		t.Fatalf("Idle project not generated: %v", err)
+	}
+
+	// A line appended to one session reparses that file alone
+	f, err := os.OpenFile(filepath.Join(sourceDir, "-work-active", "a1.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	f.WriteString(line("u4", "appended"))
+	f.Close()
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(sourceDir, "-work-active", "a1.jsonl"), future, future)
+
+	if err := reloader.regenerate("-work-active"); err != nil {
+		t.Fatalf("regenerate failed: %v", err)
+	}
+	c := reloader.cache
+	if c.metaMisses != 3 || c.metaResumes != 1 || c.metaHits != 1 {
+		t.Errorf("Second regeneration: %d misses, %d resumed, %d hits; want 3, 1 and 1 (the idle project untouched)",
+			c.metaMisses, c.metaResumes, c.metaHits)
+	}
+
+	md, err := os.ReadFile(filepath.Join(outputDir, "work-active", "a1.md"))
+	if err != nil || !strings.Contains(string(md), "appended") {
+		t.Errorf("Changed session not regenerated (err %v)", err)
+	}
+	if info, err := os.Stat(idleMD); err != nil || !info.ModTime().Equal(idleInfo.ModTime()) {
+		t.Errorf("Idle project's session was rewritten")
+	}
+	index, err := os.ReadFile(filepath.Join(outputDir, "index.md"))
+	if err != nil || !strings.Contains(string(index), "work-idle") || !strings.Contains(string(index), "work-active") {
+		t.Errorf("Main index doesn't list both projects:\n%s", index)
+	}
+	if len(reloaded) != 2 {
+		t.Errorf("OnReload got %d projects, want 2", len(reloaded))
+	}
+}